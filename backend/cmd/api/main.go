@@ -11,9 +11,12 @@ import (
 
 	"improview/backend/internal/api"
 	"improview/backend/internal/app"
+	"improview/backend/internal/sandbox"
 )
 
 func main() {
+	sandbox.Init()
+
 	clock := api.RealClock{}
 	services, err := app.NewServicesFromEnv(clock)
 	if err != nil {
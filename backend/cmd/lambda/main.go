@@ -13,9 +13,12 @@ import (
 	"improview/backend/internal/api"
 	"improview/backend/internal/app"
 	"improview/backend/internal/runtime"
+	"improview/backend/internal/sandbox"
 )
 
 func main() {
+	sandbox.Init()
+
 	if err := runtime.LoadLLMEnvFromSecret(context.Background()); err != nil {
 		log.Fatalf("lambda bootstrap: %v", err)
 	}
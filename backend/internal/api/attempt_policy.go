@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"improview/backend/internal/auth"
+	"improview/backend/internal/domain"
+)
+
+// loadAttempt fetches an attempt the caller may act on. Only the attempt's owner and members
+// of the admin groups may read, run or submit it; anyone else gets ErrNotFound so attempt IDs
+// cannot be probed.
+func (s *Server) loadAttempt(ctx context.Context, attemptID string) (domain.Attempt, error) {
+	attempt, err := s.services.Attempts.Get(ctx, attemptID)
+	if err != nil {
+		return domain.Attempt{}, err
+	}
+	if err := s.authorizeAttempt(ctx, attempt); err != nil {
+		return domain.Attempt{}, err
+	}
+	return attempt, nil
+}
+
+// authorizeAttempt compares the attempt owner with the caller identity. Without an
+// authenticator both sides are empty for anonymous attempts, which keeps local runs open.
+func (s *Server) authorizeAttempt(ctx context.Context, attempt domain.Attempt) error {
+	if strings.TrimSpace(attempt.UserID) == CallerID(ctx) {
+		return nil
+	}
+	if identity, ok := IdentityFromContext(ctx); ok && inGroups(identity, s.services.AdminGroups) {
+		return nil
+	}
+	return fmt.Errorf("%w: attempt %s", ErrNotFound, attempt.ID)
+}
+
+// inGroups reports whether the identity belongs to any of groups, ignoring case.
+func inGroups(identity auth.Identity, groups []string) bool {
+	for _, group := range identity.Groups {
+		for _, allowed := range groups {
+			if strings.EqualFold(strings.TrimSpace(group), allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"strings"
 
 	"improview/backend/internal/auth"
 )
@@ -22,3 +23,23 @@ func IdentityFromContext(ctx context.Context) (auth.Identity, bool) {
 	identity, ok := val.(auth.Identity)
 	return identity, ok
 }
+
+// CallerID returns the authenticated user's identifier, or "" for anonymous calls. Stores
+// record it as an attempt's owner, so it must match what the API checks callers against.
+func CallerID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	order := []string{identity.Subject, identity.Username, identity.Email}
+	for _, candidate := range order {
+		if trimmed := strings.TrimSpace(candidate); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
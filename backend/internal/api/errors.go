@@ -18,8 +18,19 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrNotImplemented is returned for handlers without a backend implementation yet.
 	ErrNotImplemented = errors.New("not implemented")
+	// ErrUpstream indicates a dependency such as the problem generator returned unusable data.
+	ErrUpstream = errors.New("upstream error")
+	// ErrRetryable indicates a write lost a race with a concurrent write; the same request
+	// can be retried.
+	ErrRetryable = errors.New("retryable conflict")
+	// ErrConflict indicates a conditional write found the resource at a different version
+	// than the caller expected.
+	ErrConflict = errors.New("conflict")
 )
 
+// retryAfterSeconds is the Retry-After hint sent with ErrRetryable responses.
+const retryAfterSeconds = "1"
+
 // writeError serializes the provided error into a JSON envelope.
 func writeError(w http.ResponseWriter, err error) {
 	status := http.StatusInternalServerError
@@ -40,6 +51,16 @@ func writeError(w http.ResponseWriter, err error) {
 	case errors.Is(err, ErrNotImplemented):
 		status = http.StatusNotImplemented
 		errorCode = "not_implemented"
+	case errors.Is(err, ErrUpstream):
+		status = http.StatusBadGateway
+		errorCode = "upstream_error"
+	case errors.Is(err, ErrConflict):
+		status = http.StatusConflict
+		errorCode = "conflict"
+	case errors.Is(err, ErrRetryable):
+		status = http.StatusServiceUnavailable
+		errorCode = "retryable"
+		w.Header().Set("Retry-After", retryAfterSeconds)
 	}
 
 	w.WriteHeader(status)
@@ -183,14 +183,14 @@ func TestLiveGenerate(t *testing.T) {
 	if strings.TrimSpace(problem.Pack.Problem.Title) == "" {
 		t.Fatalf("expected problem title in pack")
 	}
-	if len(problem.Pack.Tests.Public) == 0 || len(problem.Pack.Tests.Hidden) == 0 {
-		t.Fatalf("expected both public and hidden tests")
+	if len(problem.Pack.Tests.Public) == 0 || problem.Pack.Tests.HiddenCount == 0 {
+		t.Fatalf("expected public tests and a hidden test count")
 	}
-	if len(problem.Pack.Solutions) == 0 {
-		t.Fatalf("expected at least one solution outline")
+	if problem.Pack.SolutionCount == 0 {
+		t.Fatalf("expected at least one solution to be available")
 	}
-	if strings.TrimSpace(problem.Pack.Hint) == "" {
-		t.Fatalf("expected hint in problem pack")
+	if !problem.Pack.HasHint {
+		t.Fatalf("expected a hint to be available")
 	}
 }
 
@@ -216,12 +216,25 @@ func TestLiveAttemptLifecycle(t *testing.T) {
 		t.Fatalf("expected attempt id in response")
 	}
 
+	var unlocked struct {
+		Attempt   domain.Attempt           `json:"attempt"`
+		Solutions []domain.SolutionOutline `json:"solutions"`
+	}
+	unlockResp := suite.post(t, "/api/attempt/"+attemptID+"/solutions", map[string]string{}, &unlocked)
+	if unlockResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from solutions unlock, got %d", unlockResp.StatusCode)
+	}
+	if len(unlocked.Solutions) == 0 {
+		t.Fatalf("expected a reference solution to submit")
+	}
+	solution := unlocked.Solutions[0].Code
+
 	var runSummary struct {
 		Summary domain.RunSummary `json:"summary"`
 	}
 	runResp := suite.post(t, "/api/run-tests", map[string]string{
 		"attempt_id": attemptID,
-		"code":       "function solution(){ return 42; }",
+		"code":       solution,
 		"which":      "public",
 	}, &runSummary)
 	if runResp.StatusCode != http.StatusOK {
@@ -236,7 +249,7 @@ func TestLiveAttemptLifecycle(t *testing.T) {
 	}
 	submitResp := suite.post(t, "/api/submit", map[string]string{
 		"attempt_id": attemptID,
-		"code":       "function solution(){ return 42; }",
+		"code":       solution,
 	}, &submitSummary)
 	if submitResp.StatusCode != http.StatusOK {
 		t.Fatalf("expected 200 from submit, got %d", submitResp.StatusCode)
@@ -260,7 +273,7 @@ func TestLiveAttemptLifecycle(t *testing.T) {
 		t.Fatalf("expected problem id %q, got %q", problem.ProblemID, attemptDetail.Attempt.ProblemID)
 	}
 
-	var pack domain.ProblemPack
+	var pack domain.ProblemView
 	problemResp := suite.get(t, "/api/problem/"+problem.ProblemID, &pack)
 	if problemResp.StatusCode != http.StatusOK {
 		t.Fatalf("expected 200 from get problem, got %d", problemResp.StatusCode)
@@ -268,7 +281,7 @@ func TestLiveAttemptLifecycle(t *testing.T) {
 	if strings.TrimSpace(pack.Problem.Title) == "" {
 		t.Fatalf("expected stored problem title")
 	}
-	if len(pack.Tests.Public) == 0 || len(pack.Tests.Hidden) == 0 {
+	if len(pack.Tests.Public) == 0 || pack.Tests.HiddenCount == 0 {
 		t.Fatalf("expected stored problem tests")
 	}
 
@@ -287,11 +300,11 @@ func TestLiveAttemptLifecycle(t *testing.T) {
 
 func generateProblem(t *testing.T, suite *liveSuite) *struct {
 	ProblemID string             `json:"problem_id"`
-	Pack      domain.ProblemPack `json:"pack"`
+	Pack      domain.ProblemView `json:"pack"`
 } {
 	var payload struct {
 		ProblemID string             `json:"problem_id"`
-		Pack      domain.ProblemPack `json:"pack"`
+		Pack      domain.ProblemView `json:"pack"`
 	}
 
 	request := map[string]any{
@@ -24,6 +24,12 @@ func NewServer(services Services) *Server {
 	if services.Clock == nil {
 		services.Clock = RealClock{}
 	}
+	if len(services.AuthorGroups) == 0 {
+		services.AuthorGroups = DefaultAuthorGroups
+	}
+	if len(services.AdminGroups) == 0 {
+		services.AdminGroups = DefaultAdminGroups
+	}
 
 	s := &Server{services: services, mux: http.NewServeMux()}
 	// Core routes
@@ -36,6 +42,8 @@ func NewServer(services Services) *Server {
 	s.mux.Handle("/api/user/profile", s.guard(http.HandlerFunc(s.handleUserProfile)))
 	s.mux.Handle("/api/user/saved-problems", s.guard(http.HandlerFunc(s.handleSavedProblemsCollection)))
 	s.mux.Handle("/api/user/saved-problems/", s.guard(http.HandlerFunc(s.handleSavedProblemResource)))
+	s.mux.Handle("/api/users/", s.jsonHandler(http.MethodGet, s.handlePublicProfile))
+	s.mux.Handle("/api/llm/models", s.guard(s.jsonHandler(http.MethodGet, s.handleLLMModels)))
 	s.mux.Handle("/api/healthz", http.HandlerFunc(s.handleHealth))
 	s.mux.Handle("/api/version", http.HandlerFunc(s.handleVersion))
 
@@ -115,17 +123,45 @@ func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) error {
 		return ErrBadRequest
 	}
 
-	pack, err := s.services.Generator.Generate(r.Context(), req)
+	generated, err := s.services.Generator.Generate(r.Context(), req)
 	if err != nil {
 		return err
 	}
+	pack := generated.Pack
+	if err := domain.ValidateProblemPack(pack); err != nil {
+		return fmt.Errorf("%w: %v", ErrUpstream, err)
+	}
 
-	id, err := s.services.Problems.Save(r.Context(), pack)
+	id, err := s.services.Problems.Save(r.Context(), domain.ProblemRecord{
+		Category:   strings.TrimSpace(req.Category),
+		Difficulty: strings.TrimSpace(req.Difficulty),
+		PromptHash: domain.PromptHash(req.Category, req.Difficulty, req.CustomPrompt),
+		CreatedBy:  CallerID(r.Context()),
+		CreatedAt:  s.services.Clock.Now().UnixMilli(),
+		Pack:       pack,
+		Generation: generated.Metadata,
+	})
 	if err != nil {
 		return err
 	}
 
-	return json.NewEncoder(w).Encode(GenerateResponse{ProblemID: id, Pack: pack})
+	return json.NewEncoder(w).Encode(GenerateResponse{ProblemID: id, Pack: pack.View(), Metadata: generated.Metadata})
+}
+
+func (s *Server) handleLLMModels(w http.ResponseWriter, r *http.Request) error {
+	if s.services.Models == nil {
+		return ErrNotImplemented
+	}
+
+	providers, err := s.services.Models.ListModels(r.Context())
+	if err != nil {
+		return err
+	}
+	if providers == nil {
+		providers = []domain.LLMProvider{}
+	}
+
+	return json.NewEncoder(w).Encode(LLMModelsResponse{Providers: providers})
 }
 
 func (s *Server) handleCreateAttempt(w http.ResponseWriter, r *http.Request) error {
@@ -155,13 +191,22 @@ func (s *Server) handleRunTests(w http.ResponseWriter, r *http.Request) error {
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return ErrBadRequest
 	}
+	if strings.TrimSpace(req.AttemptID) == "" || strings.TrimSpace(req.Code) == "" {
+		return ErrBadRequest
+	}
+	if s.services.Attempts != nil {
+		if _, err := s.loadAttempt(r.Context(), req.AttemptID); err != nil {
+			return err
+		}
+	}
 
 	summary, err := s.services.Tests.Run(r.Context(), req)
 	if err != nil {
 		return err
 	}
 	if s.services.Attempts != nil {
-		if err := s.services.Attempts.RecordRun(r.Context(), req.AttemptID, summary); err != nil {
+		run := domain.AttemptRun{Which: req.Which.String(), CodeHash: domain.CodeHash(req.Code), Results: summary.Results}
+		if _, err := s.services.Attempts.RecordRun(r.Context(), req.AttemptID, run); err != nil {
 			return err
 		}
 	}
@@ -178,21 +223,49 @@ func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) error {
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return ErrBadRequest
 	}
-
-	summary, err := s.services.Submission.Submit(r.Context(), req)
-	if err != nil {
-		return err
+	if strings.TrimSpace(req.AttemptID) == "" || strings.TrimSpace(req.Code) == "" {
+		return ErrBadRequest
 	}
 	if s.services.Attempts != nil {
-		if err := s.services.Attempts.Complete(r.Context(), req.AttemptID, summary); err != nil {
+		if _, err := s.loadAttempt(r.Context(), req.AttemptID); err != nil {
 			return err
 		}
 	}
 
+	// The submission evaluator records the hidden run and completes the attempt.
+	summary, err := s.services.Submission.Submit(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
 	return json.NewEncoder(w).Encode(SubmitResponse{Summary: summary})
 }
 
 func (s *Server) handleAttemptByID(w http.ResponseWriter, r *http.Request) {
+	trimmed := strings.TrimPrefix(r.URL.Path, "/api/attempt/")
+	trimmed = strings.TrimSuffix(trimmed, "/")
+	if trimmed == "" {
+		writeError(w, ErrBadRequest)
+		return
+	}
+
+	if strings.Contains(trimmed, "/") {
+		parts := strings.Split(trimmed, "/")
+		if len(parts) != 2 || parts[0] == "" {
+			writeError(w, ErrBadRequest)
+			return
+		}
+		switch parts[1] {
+		case string(domain.UnlockHint), string(domain.UnlockSolutions):
+			s.jsonHandler(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+				return s.unlockAttempt(w, r, parts[0], domain.Unlock(parts[1]))
+			}).ServeHTTP(w, r)
+		default:
+			writeError(w, ErrBadRequest)
+		}
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		w.Header().Set("Allow", http.MethodGet)
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -204,29 +277,87 @@ func (s *Server) handleAttemptByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	attemptID := strings.TrimPrefix(r.URL.Path, "/api/attempt/")
-	if attemptID == "" {
-		writeError(w, ErrBadRequest)
-		return
-	}
-
-	attempt, runs, err := s.services.Attempts.Get(r.Context(), attemptID)
+	resp, err := s.getAttempt(r, trimmed)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
 
-	resp := struct {
-		Attempt domain.Attempt     `json:"attempt"`
-		Runs    []domain.RunResult `json:"runs"`
-	}{Attempt: attempt, Runs: runs}
-
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		writeError(w, err)
 	}
 }
 
+// getAttempt loads the attempt and the page of run history selected by the limit and
+// next_token query parameters.
+func (s *Server) getAttempt(r *http.Request, attemptID string) (AttemptResponse, error) {
+	query := r.URL.Query()
+	limit, err := parseListLimit(query.Get("limit"))
+	if err != nil {
+		return AttemptResponse{}, err
+	}
+
+	attempt, err := s.loadAttempt(r.Context(), attemptID)
+	if err != nil {
+		return AttemptResponse{}, err
+	}
+	page, err := s.services.Attempts.ListRuns(r.Context(), attemptID, domain.RunListOptions{
+		Limit:     int32(limit),
+		NextToken: strings.TrimSpace(query.Get("next_token")),
+	})
+	if err != nil {
+		return AttemptResponse{}, err
+	}
+
+	resp := AttemptResponse{Attempt: attempt, Runs: page.Runs}
+	if resp.Runs == nil {
+		resp.Runs = []domain.AttemptRun{}
+	}
+	if strings.TrimSpace(page.NextToken) != "" {
+		resp.NextToken = &page.NextToken
+	}
+	return resp, nil
+}
+
+// unlockAttempt releases the hint or reference solutions of the attempt's problem and
+// records the unlock on the attempt.
+func (s *Server) unlockAttempt(w http.ResponseWriter, r *http.Request, attemptID string, unlock domain.Unlock) error {
+	if s.services.Attempts == nil || s.services.Problems == nil {
+		return ErrNotImplemented
+	}
+
+	attempt, err := s.loadAttempt(r.Context(), attemptID)
+	if err != nil {
+		return err
+	}
+	pack, err := s.services.Problems.Get(r.Context(), attempt.ProblemID)
+	if err != nil {
+		return err
+	}
+
+	switch unlock {
+	case domain.UnlockHint:
+		if strings.TrimSpace(pack.Hint) == "" {
+			return fmt.Errorf("%w: problem has no hint", ErrNotFound)
+		}
+	case domain.UnlockSolutions:
+		if len(pack.Solutions) == 0 {
+			return fmt.Errorf("%w: problem has no solutions", ErrNotFound)
+		}
+	}
+
+	attempt, err = s.services.Attempts.RecordUnlock(r.Context(), attemptID, unlock)
+	if err != nil {
+		return err
+	}
+
+	if unlock == domain.UnlockHint {
+		return json.NewEncoder(w).Encode(HintResponse{Attempt: attempt, Hint: pack.Hint})
+	}
+	return json.NewEncoder(w).Encode(SolutionsResponse{Attempt: attempt, Solutions: pack.Solutions})
+}
+
 func (s *Server) handleProblemByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.Header().Set("Allow", http.MethodGet)
@@ -239,20 +370,36 @@ func (s *Server) handleProblemByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := strings.TrimPrefix(r.URL.Path, "/api/problem/")
-	if id == "" {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/problem/"), "/")
+	full := false
+	if trimmed, ok := strings.CutSuffix(id, "/full"); ok {
+		id, full = trimmed, true
+	}
+	if id == "" || strings.Contains(id, "/") {
 		writeError(w, ErrBadRequest)
 		return
 	}
 
+	if full {
+		if err := s.requireAuthor(r.Context()); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
 	pack, err := s.services.Problems.Get(r.Context(), id)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
 
+	var payload any = pack.View()
+	if full {
+		payload = pack
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(pack); err != nil {
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
 		writeError(w, err)
 	}
 }
@@ -290,6 +437,7 @@ func (s *Server) getUserProfile(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	w.Header().Set("ETag", formatETag(profile.Version))
 	return json.NewEncoder(w).Encode(UserProfileResponse{Profile: profile})
 }
 
@@ -303,6 +451,11 @@ func (s *Server) updateUserProfile(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
+	ifVersion, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
 	var req UpdateUserProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return ErrBadRequest
@@ -315,6 +468,7 @@ func (s *Server) updateUserProfile(w http.ResponseWriter, r *http.Request) error
 		AvatarURL:   req.AvatarURL,
 		Timezone:    req.Timezone,
 		Preferences: req.Preferences,
+		IfVersion:   ifVersion,
 	}
 
 	now := s.services.Clock.Now()
@@ -323,9 +477,60 @@ func (s *Server) updateUserProfile(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
+	w.Header().Set("ETag", formatETag(profile.Version))
 	return json.NewEncoder(w).Encode(UserProfileResponse{Profile: profile})
 }
 
+// handlePublicProfile serves the public projection of the profile holding a handle. It is
+// not guarded: the response never includes the user ID, and stats are only added when the
+// user opted in through their preferences.
+func (s *Server) handlePublicProfile(w http.ResponseWriter, r *http.Request) error {
+	if s.services.Profiles == nil {
+		return ErrNotImplemented
+	}
+
+	raw := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/users/"), "/")
+	handle, err := domain.NormalizeHandle(raw)
+	if err != nil || strings.Contains(raw, "/") {
+		return ErrNotFound
+	}
+
+	profile, err := s.services.Profiles.GetProfileByHandle(r.Context(), handle)
+	if err != nil {
+		return err
+	}
+
+	public := profile.Public()
+	if profile.StatsArePublic() && s.services.SavedProblems != nil {
+		stats, err := s.savedProblemStats(r.Context(), profile.UserID)
+		if err != nil {
+			return err
+		}
+		public.Stats = &stats
+	}
+
+	return json.NewEncoder(w).Encode(PublicProfileResponse{Profile: public})
+}
+
+// savedProblemStats pages through all of the user's saved problems and aggregates them.
+func (s *Server) savedProblemStats(ctx context.Context, userID string) (domain.PublicProfileStats, error) {
+	var stats domain.PublicProfileStats
+	opts := domain.SavedProblemListOptions{Limit: 200}
+	for {
+		page, err := s.services.SavedProblems.ListSavedProblems(ctx, userID, opts)
+		if err != nil {
+			return domain.PublicProfileStats{}, err
+		}
+		for _, summary := range page.Items {
+			stats.Add(summary)
+		}
+		if page.NextToken == "" {
+			return stats, nil
+		}
+		opts.NextToken = page.NextToken
+	}
+}
+
 func (s *Server) handleSavedProblemsCollection(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -365,16 +570,9 @@ func (s *Server) listSavedProblems(w http.ResponseWriter, r *http.Request) error
 		status = parsed
 	}
 
-	limit := 50
-	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
-		value, convErr := strconv.Atoi(raw)
-		if convErr != nil || value <= 0 {
-			return fmt.Errorf("%w: invalid limit", ErrBadRequest)
-		}
-		if value > 200 {
-			value = 200
-		}
-		limit = value
+	limit, err := parseListLimit(query.Get("limit"))
+	if err != nil {
+		return err
 	}
 
 	opts := domain.SavedProblemListOptions{
@@ -441,6 +639,7 @@ func (s *Server) createSavedProblem(w http.ResponseWriter, r *http.Request) erro
 		return err
 	}
 
+	w.Header().Set("ETag", formatETag(summary.Version))
 	return json.NewEncoder(w).Encode(SavedProblemResponse{SavedProblem: summary})
 }
 
@@ -500,6 +699,7 @@ func (s *Server) getSavedProblem(w http.ResponseWriter, r *http.Request, savedPr
 		return err
 	}
 
+	w.Header().Set("ETag", formatETag(detail.Version))
 	return json.NewEncoder(w).Encode(SavedProblemDetailResponse{SavedProblem: detail})
 }
 
@@ -512,6 +712,11 @@ func (s *Server) updateSavedProblem(w http.ResponseWriter, r *http.Request, save
 		return err
 	}
 
+	ifVersion, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
 	var req UpdateSavedProblemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return ErrBadRequest
@@ -537,6 +742,7 @@ func (s *Server) updateSavedProblem(w http.ResponseWriter, r *http.Request, save
 		Tags:         req.Tags,
 		Notes:        notesPtr,
 		HintUnlocked: req.HintUnlocked,
+		IfVersion:    ifVersion,
 	}
 
 	summary, err := s.services.SavedProblems.UpdateSavedProblem(r.Context(), userID, savedProblemID, input, s.services.Clock.Now())
@@ -544,6 +750,7 @@ func (s *Server) updateSavedProblem(w http.ResponseWriter, r *http.Request, save
 		return err
 	}
 
+	w.Header().Set("ETag", formatETag(summary.Version))
 	return json.NewEncoder(w).Encode(SavedProblemResponse{SavedProblem: summary})
 }
 
@@ -658,19 +865,61 @@ func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) requireUserID(ctx context.Context) (string, error) {
+	if userID := CallerID(ctx); userID != "" {
+		return userID, nil
+	}
+	return "", ErrUnauthenticated
+}
+
+// requireAuthor ensures the caller belongs to one of the configured author groups.
+func (s *Server) requireAuthor(ctx context.Context) error {
 	identity, ok := IdentityFromContext(ctx)
 	if !ok {
-		return "", ErrUnauthenticated
+		return ErrUnauthenticated
+	}
+	if inGroups(identity, s.services.AuthorGroups) {
+		return nil
 	}
+	return ErrForbidden
+}
 
-	order := []string{identity.Subject, identity.Username, identity.Email}
-	for _, candidate := range order {
-		if trimmed := strings.TrimSpace(candidate); trimmed != "" {
-			return trimmed, nil
-		}
+// parseListLimit reads a page size query parameter: 50 when absent, capped at 200.
+func parseListLimit(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 50, nil
 	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%w: invalid limit", ErrBadRequest)
+	}
+	if value > 200 {
+		value = 200
+	}
+	return value, nil
+}
 
-	return "", ErrUnauthenticated
+// formatETag renders a resource version as a strong entity tag.
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch reads the version an update expects from the If-Match header. An absent
+// header or "*" returns zero, which makes the update unconditional.
+func parseIfMatch(r *http.Request) (int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" || raw == "*" {
+		return 0, nil
+	}
+	unquoted, ok := strings.CutPrefix(raw, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil || version <= 0 {
+		return 0, fmt.Errorf("%w: invalid If-Match %q", ErrBadRequest, raw)
+	}
+	return version, nil
 }
 
 func parseSavedProblemStatus(raw string) (domain.SavedProblemStatus, error) {
@@ -3,8 +3,10 @@ package api_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -136,14 +138,74 @@ func TestGenerateReturnsProblemPack(t *testing.T) {
 	if resp.Pack.API.FunctionName == "" || resp.Pack.API.Signature == "" {
 		t.Fatalf("expected API metadata to be populated")
 	}
-	if len(resp.Pack.Tests.Public) == 0 || len(resp.Pack.Tests.Hidden) == 0 {
-		t.Fatalf("expected both public and hidden tests")
+	if len(resp.Pack.Tests.Public) == 0 || resp.Pack.Tests.HiddenCount == 0 {
+		t.Fatalf("expected public tests and a hidden test count")
 	}
-	if len(resp.Pack.Solutions) == 0 {
-		t.Fatalf("expected at least one solution outline")
+	if resp.Pack.SolutionCount == 0 {
+		t.Fatalf("expected at least one solution to be available")
 	}
-	if strings.TrimSpace(resp.Pack.Hint) == "" {
-		t.Fatalf("expected hint to be populated")
+	if !resp.Pack.HasHint {
+		t.Fatalf("expected hint to be available")
+	}
+	if report := resp.Metadata.Verification; report == nil || report.Checked == 0 || report.Verified != report.Checked {
+		t.Fatalf("expected verified tests in metadata, got %+v", resp.Metadata.Verification)
+	}
+	assertRedacted(t, rec.Body.Bytes(), "pack")
+}
+
+func TestGenerateStoresVerificationReport(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	server := api.NewServer(services)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"category":"random","difficulty":"easy"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp api.GenerateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	record, err := services.Problems.GetRecord(context.Background(), resp.ProblemID)
+	if err != nil {
+		t.Fatalf("get record: %v", err)
+	}
+	if record.ID != resp.ProblemID || record.Generation.Generator != "static" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if record.Category != "random" || record.Difficulty != "easy" || record.PromptHash != domain.PromptHash("random", "easy", "") || record.CreatedAt == 0 {
+		t.Fatalf("expected request details on record, got %+v", record)
+	}
+	if record.Generation.Verification == nil || record.Generation.Verification.Solutions != 1 {
+		t.Fatalf("expected stored verification report, got %+v", record.Generation.Verification)
+	}
+}
+
+// assertRedacted fails when the candidate-facing pack leaks hidden tests, solutions or the hint.
+func assertRedacted(t *testing.T, body []byte, key string) {
+	t.Helper()
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	raw := body
+	if key != "" {
+		raw = envelope[key]
+	}
+	var pack struct {
+		Hint      *string          `json:"hint"`
+		Solutions *json.RawMessage `json:"solutions"`
+		Tests     struct {
+			Hidden *json.RawMessage `json:"hidden"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &pack); err != nil {
+		t.Fatalf("decode pack: %v", err)
+	}
+	if pack.Hint != nil || pack.Solutions != nil || pack.Tests.Hidden != nil {
+		t.Fatalf("expected redacted pack, got %s", raw)
 	}
 }
 
@@ -171,7 +233,25 @@ func TestAttemptLifecycle(t *testing.T) {
 		t.Fatalf("create attempt returned %d", attemptRec.Code)
 	}
 
-	runReqBody := `{"attempt_id":"` + getAttemptID(t, attemptRec.Body.Bytes()) + `","code":"function solution(){ return 42; }","which":"public"}`
+	solutionsRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(solutionsRec, httptest.NewRequest(http.MethodPost, "/api/attempt/"+getAttemptID(t, attemptRec.Body.Bytes())+"/solutions", nil))
+	if solutionsRec.Code != http.StatusOK {
+		t.Fatalf("unlock solutions returned %d", solutionsRec.Code)
+	}
+	var solutionsResp api.SolutionsResponse
+	if err := json.Unmarshal(solutionsRec.Body.Bytes(), &solutionsResp); err != nil {
+		t.Fatalf("decode solutions response: %v", err)
+	}
+	if len(solutionsResp.Solutions) == 0 || !solutionsResp.Attempt.SolutionsUnlocked {
+		t.Fatalf("expected solutions to be released and recorded on the attempt")
+	}
+
+	solution, err := json.Marshal(solutionsResp.Solutions[0].Code)
+	if err != nil {
+		t.Fatalf("encode solution: %v", err)
+	}
+
+	runReqBody := `{"attempt_id":"` + getAttemptID(t, attemptRec.Body.Bytes()) + `","code":` + string(solution) + `,"which":"public"}`
 	runReq := httptest.NewRequest(http.MethodPost, "/api/run-tests", strings.NewReader(runReqBody))
 	runReq.Header.Set("Content-Type", "application/json")
 	runRec := httptest.NewRecorder()
@@ -181,7 +261,7 @@ func TestAttemptLifecycle(t *testing.T) {
 	}
 
 	attemptID := getAttemptID(t, attemptRec.Body.Bytes())
-	submitReq := httptest.NewRequest(http.MethodPost, "/api/submit", strings.NewReader(`{"attempt_id":"`+attemptID+`","code":"function solution(){ return 42; }"}`))
+	submitReq := httptest.NewRequest(http.MethodPost, "/api/submit", strings.NewReader(`{"attempt_id":"`+attemptID+`","code":`+string(solution)+`}`))
 	submitReq.Header.Set("Content-Type", "application/json")
 	submitRec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(submitRec, submitReq)
@@ -195,16 +275,17 @@ func TestAttemptLifecycle(t *testing.T) {
 	if problemRec.Code != http.StatusOK {
 		t.Fatalf("get problem returned %d", problemRec.Code)
 	}
-	var storedPack domain.ProblemPack
+	var storedPack domain.ProblemView
 	if err := json.Unmarshal(problemRec.Body.Bytes(), &storedPack); err != nil {
-		t.Fatalf("decode problem pack: %v", err)
+		t.Fatalf("decode problem view: %v", err)
 	}
 	if storedPack.Problem.Title != genResp.Pack.Problem.Title {
 		t.Fatalf("expected stored problem title %q, got %q", genResp.Pack.Problem.Title, storedPack.Problem.Title)
 	}
-	if len(storedPack.Tests.Public) == 0 || len(storedPack.Tests.Hidden) == 0 {
-		t.Fatalf("expected stored tests to include both public and hidden")
+	if len(storedPack.Tests.Public) == 0 || storedPack.Tests.HiddenCount == 0 {
+		t.Fatalf("expected stored view to include public tests and a hidden count")
 	}
+	assertRedacted(t, problemRec.Body.Bytes(), "")
 	if storedPack.API.FunctionName == "" {
 		t.Fatalf("expected stored API function name")
 	}
@@ -215,18 +296,18 @@ func TestAttemptLifecycle(t *testing.T) {
 	if attemptRecGet.Code != http.StatusOK {
 		t.Fatalf("get attempt returned %d", attemptRecGet.Code)
 	}
-	var attemptPayload struct {
-		Attempt domain.Attempt     `json:"attempt"`
-		Runs    []domain.RunResult `json:"runs"`
-	}
+	var attemptPayload api.AttemptResponse
 	if err := json.Unmarshal(attemptRecGet.Body.Bytes(), &attemptPayload); err != nil {
 		t.Fatalf("decode attempt payload: %v", err)
 	}
 	if attemptPayload.Attempt.ID != attemptID {
 		t.Fatalf("expected attempt id %q, got %q", attemptID, attemptPayload.Attempt.ID)
 	}
-	if attemptPayload.Attempt.PassCount != 2 {
-		t.Fatalf("expected pass count 2, got %d", attemptPayload.Attempt.PassCount)
+	if !attemptPayload.Attempt.SolutionsUnlocked || attemptPayload.Attempt.HintUsed {
+		t.Fatalf("expected only the solutions unlock to be recorded")
+	}
+	if attemptPayload.Attempt.PassCount != 1 || attemptPayload.Attempt.Lifetime.PassCount != 2 || attemptPayload.Attempt.Lifetime.Runs != 2 {
+		t.Fatalf("expected latest pass count 1 and lifetime 2 over 2 runs, got %+v", attemptPayload.Attempt)
 	}
 	if attemptPayload.Attempt.EndedAt < attemptPayload.Attempt.StartedAt {
 		t.Fatalf("expected ended_at to be >= started_at")
@@ -237,8 +318,392 @@ func TestAttemptLifecycle(t *testing.T) {
 	if len(attemptPayload.Runs) != 2 {
 		t.Fatalf("expected two recorded runs, got %d", len(attemptPayload.Runs))
 	}
-	if attemptPayload.Runs[0].TestID == "" {
-		t.Fatalf("expected run test ids to be populated")
+	if attemptPayload.Runs[0].Which != "public" || attemptPayload.Runs[1].Which != "hidden" || attemptPayload.Runs[0].Results[0].TestID == "" {
+		t.Fatalf("expected runs grouped by selection with test ids, got %+v", attemptPayload.Runs)
+	}
+	if attemptPayload.Runs[0].CodeHash != attemptPayload.Runs[1].CodeHash || attemptPayload.Attempt.LastRunID != attemptPayload.Runs[1].ID {
+		t.Fatalf("expected both runs to hash the same code and the submission to be the latest run")
+	}
+}
+
+func TestRunTestsResolvesSelectorServerSide(t *testing.T) {
+	server := setupServer(t)
+
+	genRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(genRec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"category":"random","difficulty":"easy"}`)))
+	if genRec.Code != http.StatusOK {
+		t.Fatalf("generate returned %d", genRec.Code)
+	}
+	var genResp api.GenerateResponse
+	if err := json.Unmarshal(genRec.Body.Bytes(), &genResp); err != nil {
+		t.Fatalf("decode generate response: %v", err)
+	}
+
+	attemptRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(attemptRec, httptest.NewRequest(http.MethodPost, "/api/attempt", strings.NewReader(`{"problem_id":"`+genResp.ProblemID+`","lang":"javascript"}`)))
+	if attemptRec.Code != http.StatusOK {
+		t.Fatalf("create attempt returned %d", attemptRec.Code)
+	}
+	attemptID := getAttemptID(t, attemptRec.Body.Bytes())
+
+	run := func(which string) *httptest.ResponseRecorder {
+		body := `{"attempt_id":"` + attemptID + `","code":"function twoSum(){ return [2, 3]; }","which":` + which + `}`
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/run-tests", strings.NewReader(body)))
+		return rec
+	}
+
+	cases := []struct {
+		which  string
+		status int
+		ids    []string
+	}{
+		{which: `"all"`, status: http.StatusOK, ids: []string{"public_1", "hidden_1"}},
+		{which: `["hidden_1"]`, status: http.StatusOK, ids: []string{"hidden_1"}},
+		{which: `null`, status: http.StatusOK, ids: []string{"public_1"}},
+		{which: `["hidden_2"]`, status: http.StatusBadRequest},
+		{which: `"everything"`, status: http.StatusBadRequest},
+		{which: `42`, status: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		rec := run(tc.which)
+		if rec.Code != tc.status {
+			t.Fatalf("which=%s: expected %d, got %d (%s)", tc.which, tc.status, rec.Code, rec.Body.String())
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var resp api.RunTestsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode run response: %v", err)
+		}
+		if len(resp.Summary.Results) != len(tc.ids) {
+			t.Fatalf("which=%s: expected %d results, got %d", tc.which, len(tc.ids), len(resp.Summary.Results))
+		}
+		for i, id := range tc.ids {
+			if resp.Summary.Results[i].TestID != id {
+				t.Fatalf("which=%s: expected test %q at %d, got %q", tc.which, id, i, resp.Summary.Results[i].TestID)
+			}
+		}
+	}
+}
+
+func TestGetAttemptPagesRunHistory(t *testing.T) {
+	server := setupServer(t)
+
+	genRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(genRec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"category":"random","difficulty":"easy"}`)))
+	var genResp api.GenerateResponse
+	if err := json.Unmarshal(genRec.Body.Bytes(), &genResp); err != nil {
+		t.Fatalf("decode generate response: %v", err)
+	}
+
+	attemptRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(attemptRec, httptest.NewRequest(http.MethodPost, "/api/attempt", strings.NewReader(`{"problem_id":"`+genResp.ProblemID+`","lang":"javascript"}`)))
+	attemptID := getAttemptID(t, attemptRec.Body.Bytes())
+
+	for _, which := range []string{`"public"`, `"hidden"`} {
+		body := `{"attempt_id":"` + attemptID + `","code":"function twoSum(){ return [2, 3]; }","which":` + which + `}`
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/run-tests", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("run-tests returned %d", rec.Code)
+		}
+	}
+
+	get := func(query string) api.AttemptResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/attempt/"+attemptID+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get attempt%s returned %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var resp api.AttemptResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode attempt: %v", err)
+		}
+		return resp
+	}
+
+	first := get("?limit=1")
+	if len(first.Runs) != 1 || first.Runs[0].Which != "public" || first.NextToken == nil {
+		t.Fatalf("expected first page with the public run and a next token, got %+v", first)
+	}
+	second := get("?limit=1&next_token=" + url.QueryEscape(*first.NextToken))
+	if len(second.Runs) != 1 || second.Runs[0].Which != "hidden" || second.Runs[0].Results[0].TestID != "hidden_1" {
+		t.Fatalf("expected second page with the hidden run, got %+v", second)
+	}
+	if second.NextToken != nil {
+		if last := get("?limit=1&next_token=" + url.QueryEscape(*second.NextToken)); len(last.Runs) != 0 || last.NextToken != nil {
+			t.Fatalf("expected an empty final page, got %+v", last)
+		}
+	}
+	if all := get(""); len(all.Runs) != 2 || all.NextToken != nil {
+		t.Fatalf("expected both runs on the default page, got %+v", all)
+	}
+
+	badLimit := httptest.NewRecorder()
+	server.Handler().ServeHTTP(badLimit, httptest.NewRequest(http.MethodGet, "/api/attempt/"+attemptID+"?limit=zero", nil))
+	if badLimit.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid limit, got %d", badLimit.Code)
+	}
+}
+
+func TestSubmitCompletesAttemptOnce(t *testing.T) {
+	server := setupServer(t)
+
+	genRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(genRec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"category":"random","difficulty":"easy"}`)))
+	var genResp api.GenerateResponse
+	if err := json.Unmarshal(genRec.Body.Bytes(), &genResp); err != nil {
+		t.Fatalf("decode generate response: %v", err)
+	}
+
+	attemptRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(attemptRec, httptest.NewRequest(http.MethodPost, "/api/attempt", strings.NewReader(`{"problem_id":"`+genResp.ProblemID+`","lang":"javascript"}`)))
+	attemptID := getAttemptID(t, attemptRec.Body.Bytes())
+
+	submit := func() int {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/submit", strings.NewReader(`{"attempt_id":"`+attemptID+`","code":"function twoSum(){ return [2, 3]; }"}`)))
+		return rec.Code
+	}
+	if code := submit(); code != http.StatusOK {
+		t.Fatalf("submit returned %d", code)
+	}
+	if code := submit(); code != http.StatusBadRequest {
+		t.Fatalf("expected a second submit to be rejected, got %d", code)
+	}
+}
+
+func TestHintUnlockIsRecorded(t *testing.T) {
+	server := setupServer(t)
+
+	genRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(genRec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"category":"bfs","difficulty":"easy"}`)))
+	var genResp api.GenerateResponse
+	if err := json.Unmarshal(genRec.Body.Bytes(), &genResp); err != nil {
+		t.Fatalf("decode generate response: %v", err)
+	}
+
+	attemptRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(attemptRec, httptest.NewRequest(http.MethodPost, "/api/attempt", strings.NewReader(`{"problem_id":"`+genResp.ProblemID+`","lang":"javascript"}`)))
+	attemptID := getAttemptID(t, attemptRec.Body.Bytes())
+
+	getHint := httptest.NewRecorder()
+	server.Handler().ServeHTTP(getHint, httptest.NewRequest(http.MethodGet, "/api/attempt/"+attemptID+"/hint", nil))
+	if getHint.Code != http.StatusBadRequest {
+		t.Fatalf("expected hint unlock to require POST, got %d", getHint.Code)
+	}
+
+	hintRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(hintRec, httptest.NewRequest(http.MethodPost, "/api/attempt/"+attemptID+"/hint", nil))
+	if hintRec.Code != http.StatusOK {
+		t.Fatalf("unlock hint returned %d", hintRec.Code)
+	}
+	var hintResp api.HintResponse
+	if err := json.Unmarshal(hintRec.Body.Bytes(), &hintResp); err != nil {
+		t.Fatalf("decode hint response: %v", err)
+	}
+	if strings.TrimSpace(hintResp.Hint) == "" || !hintResp.Attempt.HintUsed || hintResp.Attempt.SolutionsUnlocked {
+		t.Fatalf("unexpected hint response %+v", hintResp)
+	}
+
+	unknownRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(unknownRec, httptest.NewRequest(http.MethodPost, "/api/attempt/"+attemptID+"/answers", nil))
+	if unknownRec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown unlock, got %d", unknownRec.Code)
+	}
+
+	missingRec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(missingRec, httptest.NewRequest(http.MethodPost, "/api/attempt/missing/hint", nil))
+	if missingRec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown attempt, got %d", missingRec.Code)
+	}
+}
+
+func TestFullProblemRequiresAuthorGroup(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	identities := map[string]auth.Identity{
+		"candidate": {Subject: "user-1"},
+		"author":    {Subject: "user-2", Groups: []string{"Author"}},
+	}
+	services.Authenticator = tokenAuthenticator(identities)
+	server := api.NewServer(services)
+
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"category":"bfs","difficulty":"easy"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	genRec := call(http.MethodPost, "/api/generate", "candidate")
+	var genResp api.GenerateResponse
+	if err := json.Unmarshal(genRec.Body.Bytes(), &genResp); err != nil {
+		t.Fatalf("decode generate response: %v", err)
+	}
+
+	if rec := call(http.MethodGet, "/api/problem/"+genResp.ProblemID+"/full", "candidate"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for candidate, got %d", rec.Code)
+	}
+
+	rec := call(http.MethodGet, "/api/problem/"+genResp.ProblemID+"/full", "author")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for author, got %d", rec.Code)
+	}
+	var pack domain.ProblemPack
+	if err := json.Unmarshal(rec.Body.Bytes(), &pack); err != nil {
+		t.Fatalf("decode full pack: %v", err)
+	}
+	if len(pack.Tests.Hidden) == 0 || len(pack.Solutions) == 0 || pack.Hint == "" {
+		t.Fatalf("expected full pack for author")
+	}
+}
+
+func TestAttemptEndpointsEnforceOwnership(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{
+		"owner": {Subject: "user-1"},
+		"other": {Subject: "user-2", Groups: []string{"author"}},
+		"admin": {Subject: "user-3", Groups: []string{"Admin"}},
+	}
+	server := api.NewServer(services)
+
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	var genResp api.GenerateResponse
+	if err := json.Unmarshal(call(http.MethodPost, "/api/generate", "owner", `{"category":"random","difficulty":"easy"}`).Body.Bytes(), &genResp); err != nil {
+		t.Fatalf("decode generate response: %v", err)
+	}
+	attemptID := getAttemptID(t, call(http.MethodPost, "/api/attempt", "owner", `{"problem_id":"`+genResp.ProblemID+`","lang":"javascript"}`).Body.Bytes())
+	runBody := `{"attempt_id":"` + attemptID + `","code":"function twoSum(){ return [2, 3]; }","which":"public"}`
+
+	foreign := []struct {
+		name, method, path, body string
+	}{
+		{name: "get", method: http.MethodGet, path: "/api/attempt/" + attemptID},
+		{name: "run-tests", method: http.MethodPost, path: "/api/run-tests", body: runBody},
+		{name: "submit", method: http.MethodPost, path: "/api/submit", body: runBody},
+		{name: "hint", method: http.MethodPost, path: "/api/attempt/" + attemptID + "/hint"},
+		{name: "solutions", method: http.MethodPost, path: "/api/attempt/" + attemptID + "/solutions"},
+	}
+	for _, tc := range foreign {
+		if rec := call(tc.method, tc.path, "other", tc.body); rec.Code != http.StatusNotFound {
+			t.Fatalf("%s by another user: expected 404, got %d (%s)", tc.name, rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec := call(http.MethodPost, "/api/run-tests", "owner", runBody); rec.Code != http.StatusOK {
+		t.Fatalf("run-tests by owner returned %d", rec.Code)
+	}
+
+	rec := call(http.MethodGet, "/api/attempt/"+attemptID, "admin", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected admin to read the attempt, got %d", rec.Code)
+	}
+	var resp api.AttemptResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode attempt: %v", err)
+	}
+	if resp.Attempt.UserID != "user-1" || resp.Attempt.Lifetime.Runs != 1 || resp.Attempt.SolutionsUnlocked {
+		t.Fatalf("expected only the owner's run to be recorded, got %+v", resp.Attempt)
+	}
+}
+
+func TestAttemptAdminGroupsAreConfigurable(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{
+		"owner":   {Subject: "user-1"},
+		"admin":   {Subject: "user-2", Groups: []string{"admin"}},
+		"support": {Subject: "user-3", Groups: []string{"support"}},
+	}
+	services.AdminGroups = []string{"support"}
+	server := api.NewServer(services)
+
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	attemptID := getAttemptID(t, call(http.MethodPost, "/api/attempt", "owner", `{"problem_id":"prob_1","lang":"javascript"}`).Body.Bytes())
+	if rec := call(http.MethodGet, "/api/attempt/"+attemptID, "support", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected configured admin group to read the attempt, got %d", rec.Code)
+	}
+	if rec := call(http.MethodGet, "/api/attempt/"+attemptID, "admin", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected default admin group to be replaced, got %d", rec.Code)
+	}
+}
+
+type tokenAuthenticator map[string]auth.Identity
+
+func (a tokenAuthenticator) Authenticate(_ context.Context, token string) (auth.Identity, error) {
+	identity, ok := a[token]
+	if !ok {
+		return auth.Identity{}, auth.ErrUnauthenticated
+	}
+	return identity, nil
+}
+
+func TestLLMModelsListsCatalog(t *testing.T) {
+	server := setupServer(t)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/llm/models", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp api.LLMModelsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode models response: %v", err)
+	}
+	if resp.Providers == nil {
+		t.Fatalf("expected providers array in response")
+	}
+	for _, provider := range resp.Providers {
+		if provider.BaseURL == "" || len(provider.Models) == 0 || provider.DefaultModel == "" {
+			t.Fatalf("incomplete provider entry %+v", provider)
+		}
+	}
+}
+
+type fixedGenerator struct {
+	pack domain.ProblemPack
+}
+
+func (g fixedGenerator) Generate(context.Context, api.GenerateRequest) (domain.GeneratedProblem, error) {
+	return domain.GeneratedProblem{Pack: g.pack}, nil
+}
+
+func TestGenerateRejectsInvalidPack(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Generator = fixedGenerator{pack: domain.ProblemPack{
+		Problem: domain.ProblemMetadata{Title: "Broken", Statement: "..."},
+		API:     domain.APISignature{FunctionName: "solve", Signature: "function other(a)", Params: []domain.APIParam{{Name: "a"}}},
+	}}
+	server := api.NewServer(services)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"category":"bfs","difficulty":"easy"}`)))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	var errResp api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if errResp.Error != "upstream_error" || !strings.Contains(errResp.Message, `does not mention function "solve"`) {
+		t.Fatalf("unexpected error envelope %+v", errResp)
 	}
 }
 
@@ -328,10 +793,10 @@ func TestErrorEnvelopeAndHealthEndpoints(t *testing.T) {
 		t.Fatalf("create attempt: %v", err)
 	}
 	time.Sleep(time.Millisecond)
-	if err := attempt.Complete(nil, created.ID, domain.SubmissionSummary{AttemptID: created.ID}); err != nil {
+	if err := attempt.Complete(nil, created.ID, domain.AttemptRun{Which: api.TestSelectionHidden}, domain.SubmissionSummary{AttemptID: created.ID}); err != nil {
 		t.Fatalf("complete attempt: %v", err)
 	}
-	got, _, err := attempt.Get(nil, created.ID)
+	got, err := attempt.Get(nil, created.ID)
 	if err != nil {
 		t.Fatalf("get attempt: %v", err)
 	}
@@ -339,3 +804,238 @@ func TestErrorEnvelopeAndHealthEndpoints(t *testing.T) {
 		t.Fatalf("expected positive duration, got %d", got.DurationMS)
 	}
 }
+
+func TestUserEndpointsWorkWithInMemoryStores(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{"owner": {Subject: "user-1"}}
+	server := api.NewServer(services)
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer owner")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := call(http.MethodGet, "/api/user/profile", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before the profile exists, got %d", rec.Code)
+	}
+	if rec := call(http.MethodPut, "/api/user/profile", `{"display_name":"Ada"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update profile returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var profile api.UserProfileResponse
+	if err := json.Unmarshal(call(http.MethodGet, "/api/user/profile", "").Body.Bytes(), &profile); err != nil {
+		t.Fatalf("decode profile: %v", err)
+	}
+	if profile.Profile.DisplayName != "Ada" {
+		t.Fatalf("expected stored profile, got %+v", profile.Profile)
+	}
+
+	rec := call(http.MethodPost, "/api/user/saved-problems", `{"problem_id":"prob_1","language":"javascript","tags":["dp","DP"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create saved problem returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var saved api.SavedProblemResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil {
+		t.Fatalf("decode saved problem: %v", err)
+	}
+	if len(saved.SavedProblem.Tags) != 1 {
+		t.Fatalf("expected normalized tags, got %v", saved.SavedProblem.Tags)
+	}
+
+	attemptsPath := "/api/user/saved-problems/" + saved.SavedProblem.ID + "/attempts"
+	if rec := call(http.MethodPost, attemptsPath, `{"attempt_id":"a1","code":"return 1","status":"passed","pass_count":3}`); rec.Code != http.StatusOK {
+		t.Fatalf("append attempt returned %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var list api.SavedProblemListResponse
+	if err := json.Unmarshal(call(http.MethodGet, "/api/user/saved-problems", "").Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.SavedProblems) != 1 || list.SavedProblems[0].LastAttempt == nil || list.SavedProblems[0].LastAttempt.AttemptID != "a1" {
+		t.Fatalf("expected saved problem with last attempt, got %+v", list.SavedProblems)
+	}
+
+	if rec := call(http.MethodDelete, "/api/user/saved-problems/"+saved.SavedProblem.ID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete returned %d", rec.Code)
+	}
+	if rec := call(http.MethodGet, attemptsPath, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected attempts to be deleted with the saved problem, got %d", rec.Code)
+	}
+}
+
+type racingSavedProblems struct {
+	api.SavedProblemStore
+}
+
+func (racingSavedProblems) AppendAttempt(context.Context, string, string, domain.SavedProblemAttemptInput, time.Time) (domain.SavedAttemptSnapshot, error) {
+	return domain.SavedAttemptSnapshot{}, fmt.Errorf("%w: a newer attempt was recorded concurrently", api.ErrRetryable)
+}
+
+func TestRetryableStoreErrorsReturn503(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{"owner": {Subject: "user-1"}}
+	services.SavedProblems = racingSavedProblems{services.SavedProblems}
+	server := api.NewServer(services)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/saved-problems/sp_1/attempts", strings.NewReader(`{"attempt_id":"a1"}`))
+	req.Header.Set("Authorization", "Bearer owner")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected Retry-After header")
+	}
+	var errResp api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if errResp.Error != "retryable" {
+		t.Fatalf("unexpected error envelope %+v", errResp)
+	}
+}
+
+func TestUserUpdatesHonourIfMatch(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{"owner": {Subject: "user-1"}}
+	server := api.NewServer(services)
+
+	call := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer owner")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := call(http.MethodPut, "/api/user/profile", "", `{"display_name":"Ada"}`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected created profile with ETag \"1\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	profileTag := call(http.MethodGet, "/api/user/profile", "", "").Header().Get("ETag")
+	if rec := call(http.MethodPut, "/api/user/profile", profileTag, `{"bio":"first tab"}`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected update at current ETag to succeed, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	rec := call(http.MethodPut, "/api/user/profile", profileTag, `{"bio":"second tab"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for stale If-Match, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var errResp api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Error != "conflict" {
+		t.Fatalf("unexpected error envelope %+v (%v)", errResp, err)
+	}
+	if rec := call(http.MethodPut, "/api/user/profile", "2", `{"bio":"unquoted"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed If-Match, got %d", rec.Code)
+	}
+
+	rec = call(http.MethodPost, "/api/user/saved-problems", "", `{"problem_id":"prob_1","language":"javascript"}`)
+	var saved api.SavedProblemResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil {
+		t.Fatalf("decode saved problem: %v", err)
+	}
+	savedPath := "/api/user/saved-problems/" + saved.SavedProblem.ID
+	savedTag := call(http.MethodGet, savedPath, "", "").Header().Get("ETag")
+	if savedTag != rec.Header().Get("ETag") || savedTag != `"1"` {
+		t.Fatalf("expected matching ETags on create and get, got %q and %q", rec.Header().Get("ETag"), savedTag)
+	}
+	if rec := call(http.MethodPut, savedPath, savedTag, `{"notes":"first tab"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update at current ETag returned %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodPut, savedPath, savedTag, `{"notes":"second tab"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for stale If-Match, got %d", rec.Code)
+	}
+	if rec := call(http.MethodPut, savedPath, "*", `{"notes":"forced"}`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("expected If-Match * to update unconditionally, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestProfileHandlesAreValidatedAndUnique(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{"ada": {Subject: "user-1"}, "grace": {Subject: "user-2"}}
+	server := api.NewServer(services)
+
+	put := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/user/profile", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := put("ada", `{"handle":"ada!"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid handle, got %d", rec.Code)
+	}
+	if rec := put("ada", `{"handle":"Ada"}`); rec.Code != http.StatusOK {
+		t.Fatalf("claim handle returned %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := put("grace", `{"handle":"ada"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a taken handle, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var errResp api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Error != "conflict" {
+		t.Fatalf("unexpected error envelope %+v (%v)", errResp, err)
+	}
+}
+
+func TestPublicProfileByHandle(t *testing.T) {
+	services := app.NewInMemoryServices(api.RealClock{})
+	services.Authenticator = tokenAuthenticator{"ada": {Subject: "user-1", Email: "ada@example.com"}}
+	server := api.NewServer(services)
+
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := call(http.MethodPut, "/api/user/profile", "ada", `{"handle":"ada","display_name":"Ada","timezone":"Europe/London"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update profile returned %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodPost, "/api/user/saved-problems", "ada", `{"problem_id":"prob_1","language":"javascript","status":"completed"}`); rec.Code != http.StatusOK {
+		t.Fatalf("create saved problem returned %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := call(http.MethodGet, "/api/users/ADA", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected anonymous lookup to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, leaked := range []string{"user-1", "ada@example.com", "Europe/London", "preferences"} {
+		if strings.Contains(rec.Body.String(), leaked) {
+			t.Fatalf("public profile leaked %q: %s", leaked, rec.Body.String())
+		}
+	}
+	var resp api.PublicProfileResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode public profile: %v", err)
+	}
+	if resp.Profile.Handle != "ada" || resp.Profile.DisplayName != "Ada" || resp.Profile.JoinedAt == 0 || resp.Profile.Stats != nil {
+		t.Fatalf("unexpected public profile %+v", resp.Profile)
+	}
+
+	if rec := call(http.MethodPut, "/api/user/profile", "ada", `{"preferences":{"profile.stats_visibility":"public"}}`); rec.Code != http.StatusOK {
+		t.Fatalf("opt into public stats returned %d", rec.Code)
+	}
+	if err := json.Unmarshal(call(http.MethodGet, "/api/users/ada", "", "").Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode public profile: %v", err)
+	}
+	if resp.Profile.Stats == nil || resp.Profile.Stats.SavedProblems != 1 || resp.Profile.Stats.Completed != 1 {
+		t.Fatalf("expected opted-in stats, got %+v", resp.Profile.Stats)
+	}
+
+	for _, path := range []string{"/api/users/grace", "/api/users/no%20such", "/api/users/ada/extra"} {
+		if rec := call(http.MethodGet, path, "", ""); rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+}
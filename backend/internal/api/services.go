@@ -10,27 +10,35 @@ import (
 
 // ProblemGenerator creates problem packs based on the requested parameters.
 type ProblemGenerator interface {
-	Generate(ctx context.Context, req GenerateRequest) (domain.ProblemPack, error)
+	Generate(ctx context.Context, req GenerateRequest) (domain.GeneratedProblem, error)
 }
 
 // ProblemRepository persists generated problem packs for reuse.
 type ProblemRepository interface {
-	Save(ctx context.Context, pack domain.ProblemPack) (string, error)
+	// Save stores the record under a new identifier; record.ID is ignored.
+	Save(ctx context.Context, record domain.ProblemRecord) (string, error)
 	Get(ctx context.Context, id string) (domain.ProblemPack, error)
+	GetRecord(ctx context.Context, id string) (domain.ProblemRecord, error)
 }
 
 // AttemptStore persists attempt metadata and run history.
 type AttemptStore interface {
 	Create(ctx context.Context, req CreateAttemptRequest) (domain.Attempt, error)
-	RecordRun(ctx context.Context, attemptID string, run domain.RunSummary) error
-	Get(ctx context.Context, attemptID string) (domain.Attempt, []domain.RunResult, error)
-	Complete(ctx context.Context, attemptID string, summary domain.SubmissionSummary) error
+	RecordRun(ctx context.Context, attemptID string, run domain.AttemptRun) (domain.AttemptRun, error)
+	Get(ctx context.Context, attemptID string) (domain.Attempt, error)
+	ListRuns(ctx context.Context, attemptID string, opts domain.RunListOptions) (domain.RunListResult, error)
+	// Complete ends the attempt and records the submission's run in the same conditional
+	// write, so a rejected second Complete leaves the history and totals untouched.
+	Complete(ctx context.Context, attemptID string, run domain.AttemptRun, summary domain.SubmissionSummary) error
+	RecordUnlock(ctx context.Context, attemptID string, unlock domain.Unlock) (domain.Attempt, error)
 }
 
 // UserProfileStore manages user profile persistence.
 type UserProfileStore interface {
 	GetProfile(ctx context.Context, userID string) (domain.UserProfile, error)
 	UpsertProfile(ctx context.Context, userID string, update domain.UserProfileUpdate, now time.Time) (domain.UserProfile, error)
+	// GetProfileByHandle resolves a normalized handle through its reservation.
+	GetProfileByHandle(ctx context.Context, handle string) (domain.UserProfile, error)
 }
 
 // SavedProblemStore persists saved problem metadata and attempt snapshots.
@@ -54,6 +62,11 @@ type SubmissionEvaluator interface {
 	Submit(ctx context.Context, req SubmitRequest) (domain.SubmissionSummary, error)
 }
 
+// ModelCatalog lists the LLM providers and models callers may request.
+type ModelCatalog interface {
+	ListModels(ctx context.Context) ([]domain.LLMProvider, error)
+}
+
 // HealthReporter exposes readiness checks for monitoring endpoints.
 type HealthReporter interface {
 	Check(ctx context.Context) error
@@ -73,11 +86,24 @@ type Services struct {
 	SavedProblems SavedProblemStore
 	Tests         TestRunner
 	Submission    SubmissionEvaluator
+	Models        ModelCatalog
 	Health        HealthReporter
 	Clock         Clock
 	Authenticator auth.Authenticator
+	// AuthorGroups lists the identity groups allowed to read full problem packs.
+	// Defaults to DefaultAuthorGroups when empty.
+	AuthorGroups []string
+	// AdminGroups lists the identity groups allowed to access any user's attempts.
+	// Defaults to DefaultAdminGroups when empty.
+	AdminGroups []string
 }
 
+// DefaultAuthorGroups are the Cognito groups that may read unredacted problem packs.
+var DefaultAuthorGroups = []string{"admin", "author"}
+
+// DefaultAdminGroups are the Cognito groups that may access every user's attempts.
+var DefaultAdminGroups = []string{"admin"}
+
 // RealClock provides the default wall-clock implementation.
 type RealClock struct{}
 
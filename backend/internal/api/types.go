@@ -1,21 +1,32 @@
 package api
 
-import "improview/backend/internal/domain"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
 
-// GenerateRequest receives category/difficulty selection from the frontend.
+	"improview/backend/internal/domain"
+)
+
+// GenerateRequest receives category/difficulty selection from the frontend. Language names
+// the solution language the pack is meant for; it only changes how the signature is written.
 type GenerateRequest struct {
 	Category     string             `json:"category"`
 	Difficulty   string             `json:"difficulty"`
 	CustomPrompt string             `json:"customPrompt,omitempty"`
 	Provider     string             `json:"provider,omitempty"`
 	Mode         string             `json:"mode,omitempty"`
+	Language     string             `json:"lang,omitempty"`
 	LLM          *LLMRequestOptions `json:"llm,omitempty"`
 }
 
-// GenerateResponse mirrors the ProblemPack contract.
+// GenerateResponse returns the stored problem identifier, its candidate-facing view and
+// how the pack was generated.
 type GenerateResponse struct {
-	ProblemID string             `json:"problem_id"`
-	Pack      domain.ProblemPack `json:"pack"`
+	ProblemID string                    `json:"problem_id"`
+	Pack      domain.ProblemView        `json:"pack"`
+	Metadata  domain.GenerationMetadata `json:"metadata"`
 }
 
 // LLMRequestOptions carries per-request overrides for the LLM generator.
@@ -25,6 +36,11 @@ type LLMRequestOptions struct {
 	BaseURL  string `json:"baseUrl,omitempty"`
 }
 
+// LLMModelsResponse lists the providers and models accepted in GenerateRequest.LLM.
+type LLMModelsResponse struct {
+	Providers []domain.LLMProvider `json:"providers"`
+}
+
 // CreateAttemptRequest represents metadata when the user starts solving.
 type CreateAttemptRequest struct {
 	ProblemID string `json:"problem_id"`
@@ -36,11 +52,88 @@ type CreateAttemptResponse struct {
 	Attempt domain.Attempt `json:"attempt"`
 }
 
-// RunTestsRequest asks the runner to execute code against specific tests.
+// AttemptResponse returns an attempt with one page of its run history.
+type AttemptResponse struct {
+	Attempt   domain.Attempt      `json:"attempt"`
+	Runs      []domain.AttemptRun `json:"runs"`
+	NextToken *string             `json:"next_token,omitempty"`
+}
+
+// RunTestsRequest asks the runner to execute code against specific tests. The tests are
+// always resolved server-side from the attempt's problem pack.
 type RunTestsRequest struct {
-	AttemptID string `json:"attempt_id"`
-	Code      string `json:"code"`
-	Which     string `json:"which"`
+	AttemptID string       `json:"attempt_id"`
+	Code      string       `json:"code"`
+	Which     TestSelector `json:"which"`
+}
+
+// Named test selections accepted by TestSelector.
+const (
+	TestSelectionPublic = "public"
+	TestSelectionHidden = "hidden"
+	TestSelectionAll    = "all"
+)
+
+// TestSelector chooses which tests to run. On the wire it is either a named selection
+// ("public", "hidden" or "all") or an array of test IDs such as ["public_1", "hidden_2"].
+// The zero value selects the public tests.
+type TestSelector struct {
+	Set string
+	IDs []string
+}
+
+// SelectTests builds a selector for a named set of tests.
+func SelectTests(set string) TestSelector {
+	return TestSelector{Set: set}
+}
+
+// SelectTestIDs builds a selector for explicit test IDs.
+func SelectTestIDs(ids ...string) TestSelector {
+	return TestSelector{IDs: ids}
+}
+
+// UnmarshalJSON accepts either a string or an array of strings.
+func (s *TestSelector) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(trimmed, []byte("null")):
+		*s = TestSelector{}
+		return nil
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		var ids []string
+		if err := json.Unmarshal(trimmed, &ids); err != nil {
+			return fmt.Errorf("%w: which must be a string or an array of test ids", ErrBadRequest)
+		}
+		*s = TestSelector{IDs: ids}
+		return nil
+	default:
+		var set string
+		if err := json.Unmarshal(trimmed, &set); err != nil {
+			return fmt.Errorf("%w: which must be a string or an array of test ids", ErrBadRequest)
+		}
+		*s = TestSelector{Set: set}
+		return nil
+	}
+}
+
+// MarshalJSON renders the selector in the same shape it is accepted in.
+func (s TestSelector) MarshalJSON() ([]byte, error) {
+	if len(s.IDs) > 0 {
+		return json.Marshal(s.IDs)
+	}
+	return json.Marshal(s.Set)
+}
+
+// String describes the selection for logs, error messages and run history. The zero value
+// reads as "public".
+func (s TestSelector) String() string {
+	if len(s.IDs) > 0 {
+		return strings.Join(s.IDs, ",")
+	}
+	if set := strings.ToLower(strings.TrimSpace(s.Set)); set != "" {
+		return set
+	}
+	return TestSelectionPublic
 }
 
 // RunTestsResponse surfaces the per-test results.
@@ -48,6 +141,18 @@ type RunTestsResponse struct {
 	Summary domain.RunSummary `json:"summary"`
 }
 
+// HintResponse releases the problem hint for an attempt.
+type HintResponse struct {
+	Attempt domain.Attempt `json:"attempt"`
+	Hint    string         `json:"hint"`
+}
+
+// SolutionsResponse releases the reference solutions for an attempt.
+type SolutionsResponse struct {
+	Attempt   domain.Attempt           `json:"attempt"`
+	Solutions []domain.SolutionOutline `json:"solutions"`
+}
+
 // SubmitRequest finalizes the problem attempt.
 type SubmitRequest struct {
 	AttemptID string `json:"attempt_id"`
@@ -74,6 +179,11 @@ type UserProfileResponse struct {
 	Profile domain.UserProfile `json:"profile"`
 }
 
+// PublicProfileResponse wraps a profile looked up by handle.
+type PublicProfileResponse struct {
+	Profile domain.PublicProfile `json:"profile"`
+}
+
 // CreateSavedProblemRequest defines the payload to persist a saved problem.
 type CreateSavedProblemRequest struct {
 	ProblemID    string   `json:"problem_id"`
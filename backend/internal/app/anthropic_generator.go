@@ -0,0 +1,251 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+)
+
+const (
+	defaultAnthropicBaseURL   = "https://api.anthropic.com/v1"
+	defaultAnthropicModel     = "claude-3-5-sonnet-latest"
+	defaultAnthropicProvider  = "anthropic"
+	defaultAnthropicMaxTokens = 8192
+
+	anthropicVersion     = "2023-06-01"
+	problemPackToolName  = "problem_pack"
+	problemPackToolUsage = "Return the generated problem pack."
+)
+
+// AnthropicProblemGenerator creates problem packs through the Anthropic Messages API. The
+// pack schema is enforced by forcing the model to call a single tool whose input schema is
+// problemPackJSONSchema.
+type AnthropicProblemGenerator struct {
+	client      *http.Client
+	catalog     *LLMCatalog
+	apiKey      string
+	temperature float64
+	maxTokens   int
+	retry       llmRetryPolicy
+}
+
+// NewAnthropicProblemGenerator constructs an Anthropic-backed problem generator instance.
+func NewAnthropicProblemGenerator(opts LLMOptions) (*AnthropicProblemGenerator, error) {
+	apiKey := strings.TrimSpace(opts.APIKey)
+	if apiKey == "" {
+		return nil, errors.New("anthropic generator: missing API key")
+	}
+
+	catalog, err := catalogFromOptions(opts, defaultAnthropicProvider, defaultAnthropicBaseURL, defaultAnthropicModel)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic generator: %w", err)
+	}
+
+	return &AnthropicProblemGenerator{
+		client:      httpClientFromOptions(opts),
+		catalog:     catalog,
+		apiKey:      apiKey,
+		temperature: temperatureFromOptions(opts),
+		maxTokens:   defaultAnthropicMaxTokens,
+		retry:       newLLMRetryPolicy(opts),
+	}, nil
+}
+
+// Catalog returns the providers and models this generator may be asked to use.
+func (g *AnthropicProblemGenerator) Catalog() *LLMCatalog {
+	if g == nil {
+		return nil
+	}
+	return g.catalog
+}
+
+// Generate asks the model for a problem pack via tool use and maps the tool input into a
+// ProblemPack. Overrides, validation and repair behave as in LLMProblemGenerator.
+func (g *AnthropicProblemGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
+	if g == nil {
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
+	}
+
+	selection, err := g.catalog.resolve(req.LLM)
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+
+	category := strings.TrimSpace(req.Category)
+	difficulty := strings.TrimSpace(req.Difficulty)
+	if category == "" || difficulty == "" {
+		return domain.GeneratedProblem{}, api.ErrBadRequest
+	}
+
+	messages := []chatMessage{
+		{Role: "system", Content: problemSystemPrompt(category, difficulty, selection.Provider)},
+		{Role: "user", Content: problemUserPrompt(category, difficulty, req.CustomPrompt, req.Language, req.Provider, selection.Provider)},
+	}
+
+	metadata := domain.GenerationMetadata{
+		Generator: string(GeneratorModeLLM),
+		Provider:  selection.Provider,
+		Model:     selection.Model,
+	}
+	pack, err := g.retry.generate(ctx, &metadata, messages, func(ctx context.Context, messages []chatMessage) (string, error) {
+		return g.complete(ctx, selection, messages)
+	})
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+
+	return domain.GeneratedProblem{Pack: pack, Metadata: metadata}, nil
+}
+
+// complete performs a single Messages API call and returns the problem_pack tool input as
+// JSON text.
+func (g *AnthropicProblemGenerator) complete(ctx context.Context, selection llmSelection, messages []chatMessage) (string, error) {
+	payload := anthropicMessagesRequest{
+		Model:       selection.Model,
+		MaxTokens:   g.maxTokens,
+		Temperature: g.temperature,
+		Tools: []anthropicTool{{
+			Name:        problemPackToolName,
+			Description: problemPackToolUsage,
+			InputSchema: problemPackJSONSchema,
+		}},
+		ToolChoice: anthropicToolChoice{Type: "tool", Name: problemPackToolName},
+	}
+	for _, message := range messages {
+		if message.Role == "system" {
+			payload.System = strings.TrimSpace(payload.System + "\n\n" + message.Content)
+			continue
+		}
+		payload.Messages = append(payload.Messages, message)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("anthropic generator: marshal request: %w", err)
+	}
+
+	endpoint := selection.BaseURL + "/messages"
+	g.logf("POST %s payload=%s", endpoint, jsonfmt.FormatForLog(body, jsonfmt.DefaultLogLimit))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("anthropic generator: create request: %w", err)
+	}
+	httpReq.Header.Set("x-api-key", g.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", &upstreamError{Message: fmt.Sprintf("anthropic generator: do request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &upstreamError{Status: resp.StatusCode, Message: fmt.Sprintf("anthropic generator: read response: %v", err)}
+	}
+
+	g.logf("response status=%d body=%s", resp.StatusCode, jsonfmt.FormatForLog(respBody, jsonfmt.DefaultLogLimit))
+
+	if resp.StatusCode >= 400 {
+		return "", &upstreamError{
+			Status:     resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header),
+			Message:    g.wrapHTTPError(resp.StatusCode, respBody).Error(),
+		}
+	}
+
+	var message anthropicMessagesResponse
+	if err := json.Unmarshal(respBody, &message); err != nil {
+		return "", &upstreamError{Status: resp.StatusCode, Message: fmt.Sprintf("anthropic generator: decode response: %v", err)}
+	}
+
+	return message.PackJSON(), nil
+}
+
+func (g *AnthropicProblemGenerator) wrapHTTPError(status int, body []byte) error {
+	var apiErr anthropicError
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("anthropic generator: upstream %d %s: %s", status, apiErr.Error.Type, apiErr.Error.Message)
+	}
+	return fmt.Errorf("anthropic generator: upstream returned %d: %s", status, bodySnippet(body))
+}
+
+func (g *AnthropicProblemGenerator) logf(format string, args ...any) {
+	if !llmDebugEnabled() {
+		return
+	}
+	log.Printf("anthropic generator: "+format, args...)
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicMessagesRequest struct {
+	Model       string              `json:"model"`
+	MaxTokens   int                 `json:"max_tokens"`
+	System      string              `json:"system,omitempty"`
+	Messages    []chatMessage       `json:"messages"`
+	Tools       []anthropicTool     `json:"tools"`
+	ToolChoice  anthropicToolChoice `json:"tool_choice"`
+	Temperature float64             `json:"temperature,omitempty"`
+}
+
+type anthropicContentBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+type anthropicMessagesResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+}
+
+// PackJSON returns the problem_pack tool input, falling back to any text blocks so the
+// repair loop can report what the model sent instead.
+func (r anthropicMessagesResponse) PackJSON() string {
+	var text strings.Builder
+	for _, block := range r.Content {
+		switch block.Type {
+		case "tool_use":
+			if block.Name == problemPackToolName && len(block.Input) > 0 {
+				return string(block.Input)
+			}
+		case "text":
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String()
+}
+
+type anthropicError struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
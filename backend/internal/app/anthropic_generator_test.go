@@ -0,0 +1,268 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+)
+
+// anthropicStub is an httptest stand-in for the Messages API. Each call pops the next
+// handler; requests are captured for assertions.
+type anthropicStub struct {
+	t        *testing.T
+	server   *httptest.Server
+	mu       sync.Mutex
+	replies  []func(w http.ResponseWriter)
+	requests []capturedAnthropicRequest
+}
+
+type capturedAnthropicRequest struct {
+	path    string
+	header  http.Header
+	payload anthropicMessagesRequest
+}
+
+func newAnthropicStub(t *testing.T, replies ...func(w http.ResponseWriter)) *anthropicStub {
+	t.Helper()
+
+	stub := &anthropicStub{t: t, replies: replies}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read request body: %v", err)
+		}
+		var payload anthropicMessagesRequest
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("decode request body: %v", err)
+		}
+
+		stub.mu.Lock()
+		stub.requests = append(stub.requests, capturedAnthropicRequest{path: r.URL.Path, header: r.Header.Clone(), payload: payload})
+		if len(stub.replies) == 0 {
+			stub.mu.Unlock()
+			t.Errorf("unexpected extra call to %s", r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		reply := stub.replies[0]
+		stub.replies = stub.replies[1:]
+		stub.mu.Unlock()
+
+		reply(w)
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func toolUseReply(t *testing.T, input any) func(w http.ResponseWriter) {
+	t.Helper()
+	raw, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("marshal tool input: %v", err)
+	}
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type":        "message",
+			"role":        "assistant",
+			"stop_reason": "tool_use",
+			"content": []any{
+				map[string]any{"type": "text", "text": "Here is the pack."},
+				map[string]any{"type": "tool_use", "id": "toolu_1", "name": problemPackToolName, "input": json.RawMessage(raw)},
+			},
+		})
+	}
+}
+
+func errorReply(status int, errType, message string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": errType, "message": message},
+		})
+	}
+}
+
+func newTestAnthropicGenerator(t *testing.T, stub *anthropicStub, opts LLMOptions) *AnthropicProblemGenerator {
+	t.Helper()
+
+	opts.APIKey = "ant-key"
+	if opts.Catalog == nil {
+		opts.BaseURL = stub.server.URL + "/v1"
+		opts.Model = defaultString(opts.Model, "claude-test")
+	}
+	opts.Timeout = 2 * time.Second
+	generator, err := NewAnthropicProblemGenerator(opts)
+	if err != nil {
+		t.Fatalf("create anthropic generator: %v", err)
+	}
+	generator.retry.sleep = func(context.Context, time.Duration) error { return nil }
+	return generator
+}
+
+func TestAnthropicProblemGeneratorGenerate(t *testing.T) {
+	samplePack := validLLMPack()
+	stub := newAnthropicStub(t, toolUseReply(t, samplePack))
+	generator := newTestAnthropicGenerator(t, stub, LLMOptions{Temperature: 0.4})
+
+	req := api.GenerateRequest{
+		Category:     "graphs",
+		Difficulty:   "medium",
+		CustomPrompt: "Focus on connected components.",
+		Provider:     "Local Provider",
+	}
+	generated, err := generator.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("generate problem pack: %v", err)
+	}
+
+	meta := generated.Metadata
+	if meta.Generator != "llm" || meta.Provider != "anthropic" || meta.Model != "claude-test" || meta.Attempts != 1 {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if generated.Pack.Problem.Title != samplePack.Problem.Title || generated.Pack.Hint != samplePack.Hint {
+		t.Fatalf("unexpected pack %+v", generated.Pack.Problem)
+	}
+
+	if len(stub.requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(stub.requests))
+	}
+	captured := stub.requests[0]
+	if captured.path != "/v1/messages" {
+		t.Fatalf("expected /v1/messages, got %s", captured.path)
+	}
+	if captured.header.Get("x-api-key") != "ant-key" || captured.header.Get("anthropic-version") != anthropicVersion {
+		t.Fatalf("unexpected auth headers %v", captured.header)
+	}
+	if captured.header.Get("Authorization") != "" {
+		t.Fatalf("anthropic requests must not send a bearer token")
+	}
+
+	payload := captured.payload
+	if payload.Model != "claude-test" || payload.MaxTokens != defaultAnthropicMaxTokens || payload.Temperature != 0.4 {
+		t.Fatalf("unexpected request parameters %+v", payload)
+	}
+	if len(payload.Tools) != 1 || payload.Tools[0].Name != problemPackToolName || payload.Tools[0].InputSchema == nil {
+		t.Fatalf("expected problem_pack tool with schema, got %+v", payload.Tools)
+	}
+	if payload.ToolChoice != (anthropicToolChoice{Type: "tool", Name: problemPackToolName}) {
+		t.Fatalf("expected forced tool choice, got %+v", payload.ToolChoice)
+	}
+	if !strings.Contains(payload.System, "Category: graphs") || !strings.Contains(payload.System, "Provider: anthropic") {
+		t.Fatalf("system prompt missing context: %s", payload.System)
+	}
+	if len(payload.Messages) != 1 || payload.Messages[0].Role != "user" {
+		t.Fatalf("expected a single user message, got %+v", payload.Messages)
+	}
+	if !strings.Contains(payload.Messages[0].Content, "Local Provider") || !strings.Contains(payload.Messages[0].Content, "Additional guidance") {
+		t.Fatalf("user prompt missing overrides: %s", payload.Messages[0].Content)
+	}
+}
+
+func TestAnthropicProblemGeneratorHonoursRequestOverrides(t *testing.T) {
+	stub := newAnthropicStub(t, toolUseReply(t, validLLMPack()))
+
+	catalog, err := NewLLMCatalog([]LLMProviderConfig{
+		{Name: "anthropic", BaseURL: "https://api.anthropic.com/v1", Models: []string{"claude-default"}},
+		{Name: "anthropic-proxy", BaseURL: stub.server.URL + "/proxy/v1", Models: []string{"claude-a", "claude-b"}},
+	})
+	if err != nil {
+		t.Fatalf("create catalog: %v", err)
+	}
+	generator := newTestAnthropicGenerator(t, stub, LLMOptions{Catalog: catalog})
+
+	req := api.GenerateRequest{
+		Category:   "graphs",
+		Difficulty: "medium",
+		LLM:        &api.LLMRequestOptions{Provider: "anthropic-proxy", Model: "claude-b"},
+	}
+	if _, err := generator.Generate(context.Background(), req); err != nil {
+		t.Fatalf("generate with overrides: %v", err)
+	}
+
+	captured := stub.requests[0]
+	if captured.path != "/proxy/v1/messages" {
+		t.Fatalf("expected override base URL, got %s", captured.path)
+	}
+	if captured.payload.Model != "claude-b" {
+		t.Fatalf("expected override model, got %s", captured.payload.Model)
+	}
+}
+
+func TestAnthropicProblemGeneratorRejectsOverridesOutsideCatalog(t *testing.T) {
+	stub := newAnthropicStub(t)
+	generator := newTestAnthropicGenerator(t, stub, LLMOptions{})
+
+	cases := map[string]api.LLMRequestOptions{
+		"foreign base url": {BaseURL: "https://attacker.example/v1"},
+		"unlisted model":   {Model: "claude-other"},
+		"unknown provider": {Provider: "openai"},
+	}
+	for name, overrides := range cases {
+		overrides := overrides
+		t.Run(name, func(t *testing.T) {
+			_, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy", LLM: &overrides})
+			if !errors.Is(err, api.ErrBadRequest) {
+				t.Fatalf("expected bad request, got %v", err)
+			}
+		})
+	}
+	if len(stub.requests) != 0 {
+		t.Fatalf("expected no upstream calls, got %d", len(stub.requests))
+	}
+}
+
+func TestAnthropicProblemGeneratorHandlesUpstreamErrors(t *testing.T) {
+	stub := newAnthropicStub(t, errorReply(http.StatusUnauthorized, "authentication_error", "invalid x-api-key"))
+	generator := newTestAnthropicGenerator(t, stub, LLMOptions{})
+
+	_, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "medium"})
+	if !errors.Is(err, api.ErrUpstream) {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "authentication_error") || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Fatalf("expected error to include upstream details, got %v", err)
+	}
+}
+
+func TestAnthropicProblemGeneratorRetriesOverloadAndRepairs(t *testing.T) {
+	broken := validLLMPack()
+	broken.Tests.Public = nil
+
+	stub := newAnthropicStub(t,
+		errorReply(529, "overloaded_error", "Overloaded"),
+		toolUseReply(t, broken),
+		toolUseReply(t, validLLMPack()),
+	)
+	generator := newTestAnthropicGenerator(t, stub, LLMOptions{})
+
+	generated, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated.Metadata.Attempts != 2 || len(generated.Metadata.History) != 3 {
+		t.Fatalf("unexpected metadata %+v", generated.Metadata)
+	}
+	if generated.Metadata.History[0].HTTPStatus != 529 {
+		t.Fatalf("expected overloaded status in history, got %+v", generated.Metadata.History[0])
+	}
+
+	repair := stub.requests[2].payload.Messages
+	if len(repair) != 3 || repair[1].Role != "assistant" || repair[2].Role != "user" {
+		t.Fatalf("expected assistant and repair turns, got %+v", repair)
+	}
+	if !strings.Contains(repair[2].Content, "tests.public is empty") {
+		t.Fatalf("expected repair prompt to list issues, got %q", repair[2].Content)
+	}
+}
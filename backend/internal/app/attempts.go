@@ -10,12 +10,17 @@ import (
 	"improview/backend/internal/domain"
 )
 
+const (
+	defaultRunPageSize = 50
+	maxRunPageSize     = 200
+)
+
 // MemoryAttemptStore keeps attempts in memory for rapid iteration.
 type MemoryAttemptStore struct {
 	mu       sync.RWMutex
 	clock    api.Clock
 	attempts map[string]domain.Attempt
-	runs     map[string][]domain.RunResult
+	runs     map[string][]domain.AttemptRun
 }
 
 // NewMemoryAttemptStore constructs a MemoryAttemptStore.
@@ -26,7 +31,7 @@ func NewMemoryAttemptStore(clock api.Clock) *MemoryAttemptStore {
 	return &MemoryAttemptStore{
 		clock:    clock,
 		attempts: make(map[string]domain.Attempt),
-		runs:     make(map[string][]domain.RunResult),
+		runs:     make(map[string][]domain.AttemptRun),
 	}
 }
 
@@ -39,20 +44,6 @@ func (s *MemoryAttemptStore) Create(ctx context.Context, req api.CreateAttemptRe
 		return domain.Attempt{}, api.ErrBadRequest
 	}
 
-	var userID string
-	if ctx != nil {
-		if identity, ok := api.IdentityFromContext(ctx); ok {
-			switch {
-			case strings.TrimSpace(identity.Subject) != "":
-				userID = strings.TrimSpace(identity.Subject)
-			case strings.TrimSpace(identity.Username) != "":
-				userID = strings.TrimSpace(identity.Username)
-			case strings.TrimSpace(identity.Email) != "":
-				userID = strings.TrimSpace(identity.Email)
-			}
-		}
-	}
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -61,20 +52,21 @@ func (s *MemoryAttemptStore) Create(ctx context.Context, req api.CreateAttemptRe
 	attempt := domain.Attempt{
 		ID:        id,
 		ProblemID: req.ProblemID,
-		UserID:    userID,
+		UserID:    api.CallerID(ctx),
 		Language:  req.Language,
 		StartedAt: now,
 	}
 
 	s.attempts[id] = attempt
-	s.runs[id] = make([]domain.RunResult, 0)
+	s.runs[id] = make([]domain.AttemptRun, 0)
 	return attempt, nil
 }
 
-// RecordRun stores per-test run results and tallies pass/fail counts.
-func (s *MemoryAttemptStore) RecordRun(_ context.Context, attemptID string, summary domain.RunSummary) error {
+// RecordRun stores the run with its own pass/fail totals and updates the attempt's latest
+// and lifetime counts.
+func (s *MemoryAttemptStore) RecordRun(_ context.Context, attemptID string, run domain.AttemptRun) (domain.AttemptRun, error) {
 	if s == nil {
-		return api.ErrNotImplemented
+		return domain.AttemptRun{}, api.ErrNotImplemented
 	}
 
 	s.mu.Lock()
@@ -82,30 +74,23 @@ func (s *MemoryAttemptStore) RecordRun(_ context.Context, attemptID string, summ
 
 	attempt, ok := s.attempts[attemptID]
 	if !ok {
-		return api.ErrNotFound
+		return domain.AttemptRun{}, api.ErrNotFound
 	}
-
-	passes := 0
-	fails := 0
-	for _, result := range summary.Results {
-		if strings.EqualFold(result.Status, "pass") {
-			passes++
-		} else {
-			fails++
-		}
+	if attempt.EndedAt > 0 {
+		return domain.AttemptRun{}, errAttemptCompleted
 	}
-	attempt.PassCount += passes
-	attempt.FailCount += fails
+
+	run = newAttemptRun(run, s.clock.Now().UnixMilli())
+	applyRun(&attempt, run)
 	s.attempts[attemptID] = attempt
-	prev := append([]domain.RunResult(nil), s.runs[attemptID]...)
-	prev = append(prev, summary.Results...)
-	s.runs[attemptID] = prev
+	s.runs[attemptID] = append(s.runs[attemptID], run)
 
-	return nil
+	return run, nil
 }
 
-// Complete finalizes an attempt after submission.
-func (s *MemoryAttemptStore) Complete(_ context.Context, attemptID string, summary domain.SubmissionSummary) error {
+// Complete finalizes an attempt after submission, recording the submission's run with it.
+// An attempt can only be completed once; a rejected Complete records nothing.
+func (s *MemoryAttemptStore) Complete(_ context.Context, attemptID string, run domain.AttemptRun, summary domain.SubmissionSummary) error {
 	if s == nil {
 		return api.ErrNotImplemented
 	}
@@ -117,20 +102,54 @@ func (s *MemoryAttemptStore) Complete(_ context.Context, attemptID string, summa
 	if !ok {
 		return api.ErrNotFound
 	}
+	if attempt.EndedAt > 0 {
+		return errAttemptCompleted
+	}
 
-	attempt.EndedAt = s.clock.Now().UnixMilli()
+	now := s.clock.Now().UnixMilli()
+	run = newAttemptRun(run, now)
+	applyRun(&attempt, run)
+	attempt.EndedAt = now
 	if attempt.StartedAt > 0 {
 		attempt.DurationMS = attempt.EndedAt - attempt.StartedAt
 	}
 
 	s.attempts[attemptID] = attempt
+	s.runs[attemptID] = append(s.runs[attemptID], run)
 	return nil
 }
 
-// Get returns the attempt metadata and aggregated run results.
-func (s *MemoryAttemptStore) Get(_ context.Context, attemptID string) (domain.Attempt, []domain.RunResult, error) {
+// RecordUnlock marks protected problem content as revealed for the attempt.
+func (s *MemoryAttemptStore) RecordUnlock(_ context.Context, attemptID string, unlock domain.Unlock) (domain.Attempt, error) {
 	if s == nil {
-		return domain.Attempt{}, nil, api.ErrNotImplemented
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[attemptID]
+	if !ok {
+		return domain.Attempt{}, api.ErrNotFound
+	}
+
+	switch unlock {
+	case domain.UnlockHint:
+		attempt.HintUsed = true
+	case domain.UnlockSolutions:
+		attempt.SolutionsUnlocked = true
+	default:
+		return domain.Attempt{}, fmt.Errorf("%w: unknown unlock %q", api.ErrBadRequest, unlock)
+	}
+
+	s.attempts[attemptID] = attempt
+	return attempt, nil
+}
+
+// Get returns the attempt metadata.
+func (s *MemoryAttemptStore) Get(_ context.Context, attemptID string) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
 	}
 
 	s.mu.RLock()
@@ -138,79 +157,148 @@ func (s *MemoryAttemptStore) Get(_ context.Context, attemptID string) (domain.At
 
 	attempt, ok := s.attempts[attemptID]
 	if !ok {
-		return domain.Attempt{}, nil, api.ErrNotFound
+		return domain.Attempt{}, api.ErrNotFound
 	}
+	return attempt, nil
+}
 
-	runs := append([]domain.RunResult(nil), s.runs[attemptID]...)
-	if runs == nil {
-		runs = make([]domain.RunResult, 0)
+// ListRuns returns a page of the attempt's run history, oldest run first.
+func (s *MemoryAttemptStore) ListRuns(_ context.Context, attemptID string, opts domain.RunListOptions) (domain.RunListResult, error) {
+	if s == nil {
+		return domain.RunListResult{}, api.ErrNotImplemented
 	}
-	return attempt, runs, nil
-}
 
-// SimpleTestRunner performs lightweight evaluation suitable for local development.
-type SimpleTestRunner struct{}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs, ok := s.runs[attemptID]
+	if !ok {
+		return domain.RunListResult{}, api.ErrNotFound
+	}
 
-// Run simulates test execution and marks code containing the word "fail" as a failure.
-func (SimpleTestRunner) Run(_ context.Context, req api.RunTestsRequest) (domain.RunSummary, error) {
-	if strings.TrimSpace(req.Code) == "" {
-		return domain.RunSummary{}, api.ErrBadRequest
+	start := 0
+	if token := strings.TrimSpace(opts.NextToken); token != "" {
+		start = -1
+		for i, run := range runs {
+			if run.ID == token {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return domain.RunListResult{}, fmt.Errorf("%w: invalid next_token", api.ErrBadRequest)
+		}
 	}
 
-	status := "pass"
-	if strings.Contains(strings.ToLower(req.Code), "fail") {
-		status = "fail"
+	end := start + int(runPageSize(opts.Limit))
+	if end > len(runs) {
+		end = len(runs)
 	}
 
-	result := domain.RunResult{
-		TestID: fmt.Sprintf("%s-0", req.Which),
-		Status: status,
-		TimeMS: 1,
-		Stdout: "",
-		Stderr: "",
+	result := domain.RunListResult{Runs: append(make([]domain.AttemptRun, 0, end-start), runs[start:end]...)}
+	if end < len(runs) {
+		result.NextToken = runs[end-1].ID
 	}
+	return result, nil
+}
 
-	return domain.RunSummary{AttemptID: req.AttemptID, Results: []domain.RunResult{result}}, nil
+// errAttemptCompleted rejects a second Complete of the same attempt.
+var errAttemptCompleted = fmt.Errorf("%w: attempt already completed", api.ErrBadRequest)
+
+// newAttemptRun assigns the run its identifier and timestamp, tallies its results and trims
+// their output to what the run history keeps.
+func newAttemptRun(run domain.AttemptRun, nowMillis int64) domain.AttemptRun {
+	run.ID = newRunID(nowMillis)
+	run.CreatedAt = nowMillis
+	run.Results = append(make([]domain.RunResult, 0, len(run.Results)), run.Results...)
+	fitRecordedOutput(run.Results)
+	run.PassCount, run.FailCount = tallyResults(run.Results)
+	return run
+}
+
+// applyRun makes run the attempt's latest run and adds it to the lifetime totals.
+func applyRun(attempt *domain.Attempt, run domain.AttemptRun) {
+	attempt.PassCount = run.PassCount
+	attempt.FailCount = run.FailCount
+	attempt.LastRunID = run.ID
+	attempt.Lifetime.Runs++
+	attempt.Lifetime.PassCount += run.PassCount
+	attempt.Lifetime.FailCount += run.FailCount
+}
+
+// newRunID returns a run identifier that sorts by creation time.
+func newRunID(nowMillis int64) string {
+	return fmt.Sprintf("%013d-%s", nowMillis, randomID())
+}
+
+func runPageSize(limit int32) int32 {
+	switch {
+	case limit <= 0:
+		return defaultRunPageSize
+	case limit > maxRunPageSize:
+		return maxRunPageSize
+	default:
+		return limit
+	}
+}
+
+func tallyResults(results []domain.RunResult) (passes, fails int) {
+	for _, result := range results {
+		if strings.EqualFold(result.Status, runStatusPass) {
+			passes++
+		} else {
+			fails++
+		}
+	}
+	return passes, fails
 }
 
 // SubmissionService coordinates hidden test execution and attempt finalization.
 type SubmissionService struct {
 	Runner   api.TestRunner
-	Attempts *MemoryAttemptStore
+	Attempts api.AttemptStore
 }
 
-// Submit runs hidden tests and emits a submission summary.
+// Submit runs the hidden tests of the attempt's problem and emits a submission summary.
+// Completed attempts are rejected before any test runs; the hidden run is recorded only
+// together with the completion.
 func (s SubmissionService) Submit(ctx context.Context, req api.SubmitRequest) (domain.SubmissionSummary, error) {
 	if s.Runner == nil || s.Attempts == nil {
 		return domain.SubmissionSummary{}, api.ErrNotImplemented
 	}
 
-	summary, err := s.Runner.Run(ctx, api.RunTestsRequest{AttemptID: req.AttemptID, Code: req.Code, Which: "hidden"})
+	attempt, err := s.Attempts.Get(ctx, req.AttemptID)
+	if err != nil {
+		return domain.SubmissionSummary{}, err
+	}
+	if attempt.EndedAt > 0 {
+		return domain.SubmissionSummary{}, errAttemptCompleted
+	}
+
+	summary, err := s.Runner.Run(ctx, api.RunTestsRequest{AttemptID: req.AttemptID, Code: req.Code, Which: api.SelectTests(api.TestSelectionHidden)})
 	if err != nil {
 		return domain.SubmissionSummary{}, err
 	}
 
 	passed := true
+	var runtimeMS int64
 	for _, result := range summary.Results {
-		if !strings.EqualFold(result.Status, "pass") {
+		runtimeMS += result.TimeMS
+		if !strings.EqualFold(result.Status, runStatusPass) {
 			passed = false
-			break
 		}
 	}
 
 	submission := domain.SubmissionSummary{
 		AttemptID:     req.AttemptID,
 		Passed:        passed,
-		RuntimeMS:     1,
+		RuntimeMS:     runtimeMS,
 		Operations:    0,
 		HiddenResults: summary.Results,
 	}
 
-	if err := s.Attempts.RecordRun(ctx, req.AttemptID, summary); err != nil {
-		return domain.SubmissionSummary{}, err
-	}
-
-	if err := s.Attempts.Complete(ctx, req.AttemptID, submission); err != nil {
+	run := domain.AttemptRun{Which: api.TestSelectionHidden, CodeHash: domain.CodeHash(req.Code), Results: summary.Results}
+	if err := s.Attempts.Complete(ctx, req.AttemptID, run, submission); err != nil {
 		return domain.SubmissionSummary{}, err
 	}
 
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+func TestMemoryAttemptStorePagesRunHistory(t *testing.T) {
+	ctx := context.Background()
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	store := NewMemoryAttemptStore(clock)
+
+	attempt, err := store.Create(ctx, api.CreateAttemptRequest{ProblemID: "prob_1", Language: "javascript"})
+	if err != nil {
+		t.Fatalf("create attempt: %v", err)
+	}
+	for _, testID := range []string{"public_1", "public_2", "hidden_1"} {
+		clock.now = clock.now.Add(time.Second)
+		run := domain.AttemptRun{Which: testID, Results: []domain.RunResult{{TestID: testID, Status: runStatusPass}}}
+		if _, err := store.RecordRun(ctx, attempt.ID, run); err != nil {
+			t.Fatalf("record run: %v", err)
+		}
+	}
+
+	var seen []string
+	opts := domain.RunListOptions{Limit: 2}
+	for page := 0; ; page++ {
+		result, err := store.ListRuns(ctx, attempt.ID, opts)
+		if err != nil {
+			t.Fatalf("list runs: %v", err)
+		}
+		for _, run := range result.Runs {
+			seen = append(seen, run.Which)
+		}
+		if result.NextToken == "" {
+			break
+		}
+		if page > 2 {
+			t.Fatalf("pagination did not terminate")
+		}
+		opts.NextToken = result.NextToken
+	}
+	if len(seen) != 3 || seen[0] != "public_1" || seen[2] != "hidden_1" {
+		t.Fatalf("expected runs oldest first across pages, got %v", seen)
+	}
+
+	if _, err := store.ListRuns(ctx, attempt.ID, domain.RunListOptions{NextToken: "unknown"}); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request for unknown token, got %v", err)
+	}
+	if _, err := store.ListRuns(ctx, "missing", domain.RunListOptions{}); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestMemoryAttemptStoreKeepsRunBoundaries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAttemptStore(nil)
+
+	attempt, err := store.Create(ctx, api.CreateAttemptRequest{ProblemID: "prob_1"})
+	if err != nil {
+		t.Fatalf("create attempt: %v", err)
+	}
+
+	first, err := store.RecordRun(ctx, attempt.ID, domain.AttemptRun{
+		Which:    "all",
+		CodeHash: domain.CodeHash("v1"),
+		Results:  []domain.RunResult{{TestID: "public_1", Status: runStatusPass}, {TestID: "hidden_1", Status: "fail"}},
+	})
+	if err != nil {
+		t.Fatalf("record first run: %v", err)
+	}
+	if first.ID == "" || first.PassCount != 1 || first.FailCount != 1 {
+		t.Fatalf("expected the run to carry its own id and totals, got %+v", first)
+	}
+	second, err := store.RecordRun(ctx, attempt.ID, domain.AttemptRun{
+		Which:    "public",
+		CodeHash: domain.CodeHash("v2"),
+		Results:  []domain.RunResult{{TestID: "public_1", Status: runStatusPass}},
+	})
+	if err != nil {
+		t.Fatalf("record second run: %v", err)
+	}
+
+	got, err := store.Get(ctx, attempt.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.PassCount != 1 || got.FailCount != 0 || got.LastRunID != second.ID {
+		t.Fatalf("expected latest-run counts, got %+v", got)
+	}
+	if got.Lifetime != (domain.RunTotals{Runs: 2, PassCount: 2, FailCount: 1}) {
+		t.Fatalf("unexpected lifetime totals %+v", got.Lifetime)
+	}
+
+	page, err := store.ListRuns(ctx, attempt.ID, domain.RunListOptions{})
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(page.Runs) != 2 || len(page.Runs[0].Results) != 2 || page.Runs[0].CodeHash == page.Runs[1].CodeHash || page.Runs[1].Which != "public" {
+		t.Fatalf("expected runs grouped as recorded, got %+v", page.Runs)
+	}
+}
+
+func TestMemoryAttemptStoreCompletesOnce(t *testing.T) {
+	ctx := context.Background()
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	store := NewMemoryAttemptStore(clock)
+
+	attempt, err := store.Create(ctx, api.CreateAttemptRequest{ProblemID: "prob_1"})
+	if err != nil {
+		t.Fatalf("create attempt: %v", err)
+	}
+
+	clock.now = clock.now.Add(90 * time.Second)
+	if err := store.Complete(ctx, attempt.ID, domain.AttemptRun{Which: api.TestSelectionHidden}, domain.SubmissionSummary{AttemptID: attempt.ID}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if err := store.Complete(ctx, attempt.ID, domain.AttemptRun{Which: api.TestSelectionHidden}, domain.SubmissionSummary{AttemptID: attempt.ID}); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected second complete to be rejected, got %v", err)
+	}
+
+	got, err := store.Get(ctx, attempt.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.DurationMS != 90_000 {
+		t.Fatalf("expected first completion to stand, got duration %d", got.DurationMS)
+	}
+}
+
+// countingRunner passes every hidden test and counts how often it is asked to run.
+type countingRunner struct {
+	calls atomic.Int32
+}
+
+func (r *countingRunner) Run(_ context.Context, req api.RunTestsRequest) (domain.RunSummary, error) {
+	r.calls.Add(1)
+	return domain.RunSummary{AttemptID: req.AttemptID, Results: []domain.RunResult{{TestID: "hidden_1", Status: runStatusPass}}}, nil
+}
+
+func TestSubmissionServiceRejectsCompletedAttemptBeforeRunning(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAttemptStore(&manualClock{now: time.Unix(1_700_000_000, 0)})
+	runner := &countingRunner{}
+	service := SubmissionService{Runner: runner, Attempts: store}
+
+	attempt, err := store.Create(ctx, api.CreateAttemptRequest{ProblemID: "prob_1"})
+	if err != nil {
+		t.Fatalf("create attempt: %v", err)
+	}
+	if _, err := service.Submit(ctx, api.SubmitRequest{AttemptID: attempt.ID, Code: "first"}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := service.Submit(ctx, api.SubmitRequest{AttemptID: attempt.ID, Code: "second"}); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected re-submit to be rejected, got %v", err)
+	}
+
+	if calls := runner.calls.Load(); calls != 1 {
+		t.Fatalf("expected hidden tests to run once, ran %d times", calls)
+	}
+	page, err := store.ListRuns(ctx, attempt.ID, domain.RunListOptions{})
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(page.Runs) != 1 || page.Runs[0].CodeHash != domain.CodeHash("first") {
+		t.Fatalf("expected only the first submission's run, got %+v", page.Runs)
+	}
+}
+
+func TestSubmissionServiceKeepsLifetimeCountsOnDoubleSubmit(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]func(t *testing.T) api.AttemptStore{
+		"memory": func(*testing.T) api.AttemptStore { return NewMemoryAttemptStore(nil) },
+		"sqlite": func(t *testing.T) api.AttemptStore {
+			db, err := OpenSQLiteDB(ctx, testSQLitePath(t))
+			if err != nil {
+				t.Fatalf("open sqlite: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			store, err := NewSQLiteAttemptStore(db, nil)
+			if err != nil {
+				t.Fatalf("attempt store: %v", err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			service := SubmissionService{Runner: &countingRunner{}, Attempts: store}
+			attempt, err := store.Create(ctx, api.CreateAttemptRequest{ProblemID: "prob_1"})
+			if err != nil {
+				t.Fatalf("create attempt: %v", err)
+			}
+
+			// Both submissions may pass the completed check before either completes; only
+			// one of them may count.
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i := range errs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, errs[i] = service.Submit(ctx, api.SubmitRequest{AttemptID: attempt.ID, Code: "code"})
+				}()
+			}
+			wg.Wait()
+			if (errs[0] == nil) == (errs[1] == nil) {
+				t.Fatalf("expected exactly one submission to be accepted, got %v and %v", errs[0], errs[1])
+			}
+
+			completed, err := store.Get(ctx, attempt.ID)
+			if err != nil {
+				t.Fatalf("get attempt: %v", err)
+			}
+			if want := (domain.RunTotals{Runs: 1, PassCount: 1}); completed.Lifetime != want {
+				t.Fatalf("expected lifetime %+v after one accepted submission, got %+v", want, completed.Lifetime)
+			}
+
+			if _, err := service.Submit(ctx, api.SubmitRequest{AttemptID: attempt.ID, Code: "code"}); !errors.Is(err, api.ErrBadRequest) {
+				t.Fatalf("expected re-submit to be rejected, got %v", err)
+			}
+			got, err := store.Get(ctx, attempt.ID)
+			if err != nil {
+				t.Fatalf("get attempt: %v", err)
+			}
+			if got.Lifetime != completed.Lifetime || got.LastRunID != completed.LastRunID {
+				t.Fatalf("expected a rejected submission to leave the counts alone, got %+v after %+v", got, completed)
+			}
+		})
+	}
+}
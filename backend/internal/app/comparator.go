@@ -0,0 +1,270 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+	"improview/backend/internal/sandbox"
+)
+
+// outputComparator grades returned values against test outputs following a problem's
+// comparator spec. Custom checkers run in checker, which must be a JavaScript executor.
+type outputComparator struct {
+	spec    domain.ComparatorSpec
+	checker sandbox.Executor
+	limits  sandbox.Limits
+}
+
+// outputCheck is one returned value to grade against a test.
+type outputCheck struct {
+	Input    []any
+	Expected any
+	Actual   any
+}
+
+// outputVerdict is the grade of one outputCheck. Err explains why a custom checker gave no
+// verdict; built-in modes never set it.
+type outputVerdict struct {
+	Match bool
+	Err   string
+}
+
+// newOutputComparator resolves spec, which may be nil for exact comparison.
+func newOutputComparator(spec *domain.ComparatorSpec, checker sandbox.Executor, limits sandbox.Limits) outputComparator {
+	c := outputComparator{spec: domain.ComparatorSpec{Mode: domain.ComparatorExact}, checker: checker, limits: limits}
+	if spec != nil && spec.Mode != "" {
+		c.spec = *spec
+	}
+	if c.spec.Mode == domain.ComparatorFloatEpsilon && c.spec.Epsilon <= 0 {
+		c.spec.Epsilon = domain.DefaultComparatorEpsilon
+	}
+	return c
+}
+
+// compare grades every check. A custom checker is called once per check in a single
+// sandbox batch; the error is reserved for failures of the sandbox itself.
+func (c outputComparator) compare(ctx context.Context, checks []outputCheck) ([]outputVerdict, error) {
+	verdicts := make([]outputVerdict, len(checks))
+	if c.spec.Mode != domain.ComparatorCustomChecker {
+		for i, check := range checks {
+			verdicts[i].Match = c.matches(check.Expected, check.Actual)
+		}
+		return verdicts, nil
+	}
+	if len(checks) == 0 {
+		return verdicts, nil
+	}
+	if c.checker == nil {
+		return nil, errors.New("comparator: no JavaScript sandbox to run the custom checker")
+	}
+
+	inputs := make([][]any, len(checks))
+	for i, check := range checks {
+		expected, err := normalizeJSON(check.Expected)
+		if err != nil {
+			return nil, fmt.Errorf("comparator: encode expected output: %w", err)
+		}
+		actual, err := normalizeJSON(check.Actual)
+		if err != nil {
+			verdicts[i].Err = fmt.Sprintf("comparator: returned value is not JSON-serializable: %v", err)
+		}
+		inputs[i] = []any{check.Input, expected, actual}
+	}
+
+	results, err := c.checker.Execute(ctx, sandbox.Request{
+		Code:         c.spec.Checker,
+		FunctionName: domain.CheckerFunctionName,
+		Inputs:       inputs,
+		Limits:       c.limits,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("comparator: run checker: %w", err)
+	}
+	if len(results) != len(checks) {
+		return nil, fmt.Errorf("comparator: expected %d checker results, got %d", len(checks), len(results))
+	}
+	for i, result := range results {
+		if verdicts[i].Err == "" {
+			verdicts[i] = checkerVerdict(result)
+		}
+	}
+	return verdicts, nil
+}
+
+func checkerVerdict(result sandbox.Result) outputVerdict {
+	switch result.Status {
+	case sandbox.StatusOK:
+		if match, ok := result.Value.(bool); ok {
+			return outputVerdict{Match: match}
+		}
+		return outputVerdict{Err: fmt.Sprintf("comparator: checker returned %s, want a boolean", jsonfmt.FormatValue(result.Value))}
+	case sandbox.StatusTimeout:
+		return outputVerdict{Err: "comparator: checker timed out"}
+	default:
+		return outputVerdict{Err: "comparator: checker failed: " + result.Error}
+	}
+}
+
+// matches grades one value under a built-in mode. Custom checkers are only run by compare,
+// so here they fall back to exact comparison.
+func (c outputComparator) matches(expected, actual any) bool {
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		return false
+	}
+	got, err := normalizeJSON(actual)
+	if err != nil {
+		return false
+	}
+
+	switch c.spec.Mode {
+	case domain.ComparatorUnordered:
+		return reflect.DeepEqual(canonicalOrder(want, false), canonicalOrder(got, false))
+	case domain.ComparatorUnorderedNested:
+		return reflect.DeepEqual(canonicalOrder(want, true), canonicalOrder(got, true))
+	case domain.ComparatorFloatEpsilon:
+		return withinEpsilon(want, got, c.spec.Epsilon)
+	case domain.ComparatorAnyOf:
+		alternatives, _ := want.([]any)
+		for _, alternative := range alternatives {
+			if reflect.DeepEqual(alternative, got) {
+				return true
+			}
+		}
+		return false
+	default:
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+// correctable reports whether a disputed test output can be replaced by what the reference
+// solutions agree on. Any-of lists and custom checkers cannot be rebuilt from one value.
+func (c outputComparator) correctable() bool {
+	switch c.spec.Mode {
+	case domain.ComparatorAnyOf, domain.ComparatorCustomChecker:
+		return false
+	default:
+		return true
+	}
+}
+
+// diff lists where actual departs from expected, both already normalised, in the terms of
+// the comparator: unordered arrays are sorted first, numbers within epsilon are not
+// reported and any-of picks the closest alternative. A custom checker may accept values
+// other than the reference output, so no diff is produced for it.
+func (c outputComparator) diff(expected, actual any) []jsonfmt.Difference {
+	switch c.spec.Mode {
+	case domain.ComparatorUnordered:
+		return jsonfmt.Diff(canonicalOrder(expected, false), canonicalOrder(actual, false), maxRunDiffs)
+	case domain.ComparatorUnorderedNested:
+		return jsonfmt.Diff(canonicalOrder(expected, true), canonicalOrder(actual, true), maxRunDiffs)
+	case domain.ComparatorFloatEpsilon:
+		var diffs []jsonfmt.Difference
+		for _, diff := range jsonfmt.Diff(expected, actual, 0) {
+			if diff.Kind == jsonfmt.DiffChanged && withinEpsilon(diff.Expected, diff.Actual, c.spec.Epsilon) {
+				continue
+			}
+			if diffs = append(diffs, diff); len(diffs) == maxRunDiffs {
+				break
+			}
+		}
+		return diffs
+	case domain.ComparatorAnyOf:
+		alternatives, ok := expected.([]any)
+		if !ok || len(alternatives) == 0 {
+			return jsonfmt.Diff(expected, actual, maxRunDiffs)
+		}
+		var closest []jsonfmt.Difference
+		for i, alternative := range alternatives {
+			diffs := jsonfmt.Diff(alternative, actual, maxRunDiffs)
+			if i == 0 || len(diffs) < len(closest) {
+				closest = diffs
+			}
+		}
+		return closest
+	case domain.ComparatorCustomChecker:
+		return nil
+	default:
+		return jsonfmt.Diff(expected, actual, maxRunDiffs)
+	}
+}
+
+// canonicalOrder sorts the elements of a decoded JSON array by their encoding so arrays
+// holding the same elements compare equal. With nested set, arrays at every depth,
+// including inside objects, are sorted as well.
+func canonicalOrder(value any, nested bool) any {
+	switch v := value.(type) {
+	case []any:
+		type keyedElement struct {
+			key   string
+			value any
+		}
+		elements := make([]keyedElement, len(v))
+		for i, element := range v {
+			if nested {
+				element = canonicalOrder(element, true)
+			}
+			encoded, _ := json.Marshal(element)
+			elements[i] = keyedElement{key: string(encoded), value: element}
+		}
+		sort.SliceStable(elements, func(a, b int) bool { return elements[a].key < elements[b].key })
+		sorted := make([]any, len(elements))
+		for i, element := range elements {
+			sorted[i] = element.value
+		}
+		return sorted
+	case map[string]any:
+		if !nested {
+			return v
+		}
+		result := make(map[string]any, len(v))
+		for key, element := range v {
+			result[key] = canonicalOrder(element, true)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// withinEpsilon compares decoded JSON values structurally, accepting numbers whose
+// absolute difference is at most epsilon.
+func withinEpsilon(expected, actual any, epsilon float64) bool {
+	switch want := expected.(type) {
+	case float64:
+		got, ok := actual.(float64)
+		return ok && math.Abs(want-got) <= epsilon
+	case []any:
+		got, ok := actual.([]any)
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !withinEpsilon(want[i], got[i], epsilon) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for key, value := range want {
+			other, ok := got[key]
+			if !ok || !withinEpsilon(value, other, epsilon) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(expected, actual)
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+	"improview/backend/internal/sandbox"
+)
+
+func TestOutputComparatorMatchesByMode(t *testing.T) {
+	cases := []struct {
+		spec     *domain.ComparatorSpec
+		expected any
+		actual   any
+		want     bool
+	}{
+		{spec: nil, expected: []int{1, 2}, actual: []any{float64(1), float64(2)}, want: true},
+		{spec: nil, expected: []int{1, 2}, actual: []int{2, 1}, want: false},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorUnordered}, expected: []int{1, 2, 2}, actual: []int{2, 1, 2}, want: true},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorUnordered}, expected: []int{1, 2, 2}, actual: []int{1, 1, 2}, want: false},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorUnordered}, expected: [][]int{{1, 2}}, actual: [][]int{{2, 1}}, want: false},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorUnorderedNested}, expected: map[string]any{"groups": [][]string{{"a", "b"}, {"c"}}}, actual: map[string]any{"groups": [][]string{{"c"}, {"b", "a"}}}, want: true},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorFloatEpsilon}, expected: []float64{0.3, 1}, actual: []float64{0.1 + 0.2, 1}, want: true},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorFloatEpsilon}, expected: 0.3, actual: 0.31, want: false},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorFloatEpsilon, Epsilon: 0.05}, expected: 0.3, actual: 0.31, want: true},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorAnyOf}, expected: [][]int{{0, 1}, {1, 0}}, actual: []int{1, 0}, want: true},
+		{spec: &domain.ComparatorSpec{Mode: domain.ComparatorAnyOf}, expected: [][]int{{0, 1}, {1, 0}}, actual: []int{1, 1}, want: false},
+	}
+	for i, tc := range cases {
+		comparator := newOutputComparator(tc.spec, nil, sandbox.Limits{})
+		verdicts, err := comparator.compare(context.Background(), []outputCheck{{Expected: tc.expected, Actual: tc.actual}})
+		if err != nil {
+			t.Fatalf("case %d: compare: %v", i, err)
+		}
+		if verdicts[0] != (outputVerdict{Match: tc.want}) {
+			t.Fatalf("case %d: expected match=%v for %v vs %v, got %+v", i, tc.want, tc.expected, tc.actual, verdicts[0])
+		}
+	}
+}
+
+func TestOutputComparatorDiffsInItsOwnTerms(t *testing.T) {
+	epsilon := newOutputComparator(&domain.ComparatorSpec{Mode: domain.ComparatorFloatEpsilon, Epsilon: 0.01}, nil, sandbox.Limits{})
+	want := []jsonfmt.Difference{{Path: "$[1]", Kind: jsonfmt.DiffChanged, Expected: float64(2), Actual: float64(2.5)}}
+	if got := epsilon.diff([]any{1.0, 2.0}, []any{1.001, 2.5}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected only the number outside epsilon, got %#v", got)
+	}
+
+	anyOf := newOutputComparator(&domain.ComparatorSpec{Mode: domain.ComparatorAnyOf}, nil, sandbox.Limits{})
+	alternatives := []any{[]any{1.0, 2.0, 3.0}, []any{3.0, 2.0, 1.0}}
+	want = []jsonfmt.Difference{{Path: "$[2]", Kind: jsonfmt.DiffChanged, Expected: float64(1), Actual: float64(9)}}
+	if got := anyOf.diff(alternatives, []any{3.0, 2.0, 9.0}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected a diff against the closest alternative, got %#v", got)
+	}
+}
@@ -7,9 +7,11 @@ import (
 
 	"improview/backend/internal/api"
 	"improview/backend/internal/domain"
+	"improview/backend/internal/sandbox"
 )
 
-// DynamicProblemGenerator chooses between static and LLM generators on each request.
+// DynamicProblemGenerator chooses between static and LLM generators on each request. The
+// LLM generator may be a FallbackChainGenerator that ends with the static generator.
 type DynamicProblemGenerator struct {
 	defaultMode GeneratorMode
 	static      api.ProblemGenerator
@@ -37,26 +39,36 @@ func NewDynamicProblemGenerator(defaultMode GeneratorMode, staticGen api.Problem
 	}, nil
 }
 
-// Generate routes to the requested generator, falling back to the default.
-func (g *DynamicProblemGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.ProblemPack, error) {
+// Generate routes to the requested generator, falling back to the default, and rewrites the
+// signature for the requested language.
+func (g *DynamicProblemGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
 	if g == nil {
-		return domain.ProblemPack{}, api.ErrNotImplemented
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
 	}
 
+	generated, err := g.route(req).Generate(ctx, req)
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+	generated.Pack.API = localizeSignature(generated.Pack.API, req.Language)
+	return generated, nil
+}
+
+func (g *DynamicProblemGenerator) route(req api.GenerateRequest) api.ProblemGenerator {
 	mode := g.selectMode(req.Mode)
 	switch mode {
 	case GeneratorModeLLM:
 		if g.llm != nil {
-			return g.llm.Generate(ctx, req)
+			return g.llm
 		}
 	case GeneratorModeStatic:
-		return g.static.Generate(ctx, req)
+		return g.static
 	}
 
 	if g.defaultMode == GeneratorModeLLM && g.llm != nil {
-		return g.llm.Generate(ctx, req)
+		return g.llm
 	}
-	return g.static.Generate(ctx, req)
+	return g.static
 }
 
 func (g *DynamicProblemGenerator) selectMode(requested string) GeneratorMode {
@@ -79,3 +91,13 @@ func (g *DynamicProblemGenerator) selectMode(requested string) GeneratorMode {
 	}
 	return mode
 }
+
+// localizeSignature writes the signature in the requested language. Generators describe
+// packs with JavaScript signatures; Go solutions need the declaration the Go sandbox's
+// harness calls, derived from the parameter and return types.
+func localizeSignature(signature domain.APISignature, language string) domain.APISignature {
+	if sandbox.NormalizeLanguage(language) == sandbox.LanguageGo {
+		signature.Signature = sandbox.GoSignature(signature.FunctionName, sandboxParams(signature.Params), signature.Returns.Type)
+	}
+	return signature
+}
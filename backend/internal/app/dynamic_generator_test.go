@@ -12,8 +12,8 @@ type stubProblemGenerator struct {
 	name string
 }
 
-func (s stubProblemGenerator) Generate(context.Context, api.GenerateRequest) (domain.ProblemPack, error) {
-	return domain.ProblemPack{Hint: s.name}, nil
+func (s stubProblemGenerator) Generate(context.Context, api.GenerateRequest) (domain.GeneratedProblem, error) {
+	return domain.GeneratedProblem{Pack: domain.ProblemPack{Hint: s.name}}, nil
 }
 
 func TestDynamicProblemGeneratorDefaultsToStatic(t *testing.T) {
@@ -23,12 +23,12 @@ func TestDynamicProblemGeneratorDefaultsToStatic(t *testing.T) {
 		t.Fatalf("create dynamic generator: %v", err)
 	}
 
-	pack, err := gen.Generate(context.Background(), api.GenerateRequest{})
+	generated, err := gen.Generate(context.Background(), api.GenerateRequest{})
 	if err != nil {
 		t.Fatalf("generate: %v", err)
 	}
-	if pack.Hint != "static" {
-		t.Fatalf("expected static generator, got %q", pack.Hint)
+	if generated.Pack.Hint != "static" {
+		t.Fatalf("expected static generator, got %q", generated.Pack.Hint)
 	}
 }
 
@@ -41,12 +41,12 @@ func TestDynamicProblemGeneratorUsesLLMOnRequest(t *testing.T) {
 		t.Fatalf("create dynamic generator: %v", err)
 	}
 
-	pack, err := gen.Generate(context.Background(), api.GenerateRequest{Mode: "llm"})
+	generated, err := gen.Generate(context.Background(), api.GenerateRequest{Mode: "llm"})
 	if err != nil {
 		t.Fatalf("generate: %v", err)
 	}
-	if pack.Hint != "llm" {
-		t.Fatalf("expected llm generator, got %q", pack.Hint)
+	if generated.Pack.Hint != "llm" {
+		t.Fatalf("expected llm generator, got %q", generated.Pack.Hint)
 	}
 }
 
@@ -58,12 +58,12 @@ func TestDynamicProblemGeneratorFallsBackWhenLLMUnavailable(t *testing.T) {
 		t.Fatalf("create dynamic generator: %v", err)
 	}
 
-	pack, err := gen.Generate(context.Background(), api.GenerateRequest{Mode: "llm"})
+	generated, err := gen.Generate(context.Background(), api.GenerateRequest{Mode: "llm"})
 	if err != nil {
 		t.Fatalf("generate: %v", err)
 	}
-	if pack.Hint != "static" {
-		t.Fatalf("expected static fallback, got %q", pack.Hint)
+	if generated.Pack.Hint != "static" {
+		t.Fatalf("expected static fallback, got %q", generated.Pack.Hint)
 	}
 }
 
@@ -75,3 +75,26 @@ func TestDynamicProblemGeneratorRequiresLLMWhenDefault(t *testing.T) {
 		t.Fatalf("expected error when defaulting to llm without llm generator")
 	}
 }
+
+func TestDynamicProblemGeneratorWritesGoSignatures(t *testing.T) {
+	gen, err := NewDynamicProblemGenerator(GeneratorModeStatic, NewStaticProblemGenerator(), nil)
+	if err != nil {
+		t.Fatalf("create dynamic generator: %v", err)
+	}
+
+	cases := map[string]string{
+		"":       "function twoSum(nums, target)",
+		"python": "function twoSum(nums, target)",
+		"go":     "func twoSum(nums []int, target int) []int",
+		"golang": "func twoSum(nums []int, target int) []int",
+	}
+	for language, want := range cases {
+		generated, err := gen.Generate(context.Background(), api.GenerateRequest{Category: "random", Difficulty: "easy", Language: language})
+		if err != nil {
+			t.Fatalf("generate %q: %v", language, err)
+		}
+		if got := generated.Pack.API.Signature; got != want {
+			t.Fatalf("lang %q: expected signature %q, got %q", language, want, got)
+		}
+	}
+}
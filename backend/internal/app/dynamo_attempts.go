@@ -0,0 +1,560 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+const (
+	entityAttempt    = "ATTEMPT"
+	entityAttemptRun = "ATTEMPT_RUN"
+
+	// anonymousAttemptOwner partitions attempts started without an identity.
+	anonymousAttemptOwner = "anonymous"
+)
+
+// DynamoAttemptStore persists attempts in the shared single table. The attempt row lives
+// under its owner (pk = USER#<user_id>, sk = ATTEMPT#<attempt_id>) and is found by ID through
+// the attempt lookup index (gsi1pk = ATTEMPT#<attempt_id>, gsi1sk = ATTEMPT). Each recorded
+// run is its own item under pk = ATTEMPT#<attempt_id>, sk = RUN#<run_id>, so the history can
+// be paged and never grows the attempt row.
+type DynamoAttemptStore struct {
+	client           *dynamodb.Client
+	tableName        string
+	attemptIndexName string
+	clock            api.Clock
+}
+
+// NewDynamoAttemptStore constructs a store on an existing client.
+func NewDynamoAttemptStore(client *dynamodb.Client, tableName, attemptIndex string, clock api.Clock) (*DynamoAttemptStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamo attempts: client is required")
+	}
+	if strings.TrimSpace(tableName) == "" {
+		return nil, errors.New("dynamo attempts: table name is required")
+	}
+	if attemptIndex == "" {
+		attemptIndex = defaultAttemptIndex
+	}
+	if clock == nil {
+		clock = api.RealClock{}
+	}
+	return &DynamoAttemptStore{
+		client:           client,
+		tableName:        tableName,
+		attemptIndexName: attemptIndex,
+		clock:            clock,
+	}, nil
+}
+
+func attemptRowSortKey(attemptID string) string {
+	return "ATTEMPT#" + attemptID
+}
+
+func attemptPartitionKey(attemptID string) string {
+	return "ATTEMPT#" + attemptID
+}
+
+func runSortKey(runID string) string {
+	return "RUN#" + runID
+}
+
+func gsi1ForAttemptRow(attemptID string) (string, string) {
+	return "ATTEMPT#" + attemptID, entityAttempt
+}
+
+type attemptItem struct {
+	PK                string `dynamodbav:"pk"`
+	SK                string `dynamodbav:"sk"`
+	Entity            string `dynamodbav:"entity"`
+	AttemptID         string `dynamodbav:"attempt_id"`
+	ProblemID         string `dynamodbav:"problem_id"`
+	UserID            string `dynamodbav:"user_id,omitempty"`
+	Language          string `dynamodbav:"lang,omitempty"`
+	StartedAt         int64  `dynamodbav:"started_at"`
+	EndedAt           int64  `dynamodbav:"ended_at,omitempty"`
+	HintUsed          bool   `dynamodbav:"hint_used"`
+	SolutionsUnlocked bool   `dynamodbav:"solutions_unlocked"`
+	PassCount         int    `dynamodbav:"pass_count"`
+	FailCount         int    `dynamodbav:"fail_count"`
+	LastRunID         string `dynamodbav:"last_run_id,omitempty"`
+	LifetimeRuns      int    `dynamodbav:"lifetime_runs"`
+	LifetimePassCount int    `dynamodbav:"lifetime_pass_count"`
+	LifetimeFailCount int    `dynamodbav:"lifetime_fail_count"`
+	DurationMS        int64  `dynamodbav:"duration_ms"`
+	CreatedAt         int64  `dynamodbav:"created_at"`
+	UpdatedAt         int64  `dynamodbav:"updated_at"`
+	GSI1PK            string `dynamodbav:"gsi1pk"`
+	GSI1SK            string `dynamodbav:"gsi1sk"`
+}
+
+type attemptRunItem struct {
+	PK        string          `dynamodbav:"pk"`
+	SK        string          `dynamodbav:"sk"`
+	Entity    string          `dynamodbav:"entity"`
+	AttemptID string          `dynamodbav:"attempt_id"`
+	RunID     string          `dynamodbav:"run_id"`
+	Which     string          `dynamodbav:"which"`
+	CodeHash  string          `dynamodbav:"code_hash,omitempty"`
+	PassCount int             `dynamodbav:"pass_count"`
+	FailCount int             `dynamodbav:"fail_count"`
+	Results   []runResultItem `dynamodbav:"results"`
+	CreatedAt int64           `dynamodbav:"created_at"`
+	UpdatedAt int64           `dynamodbav:"updated_at"`
+}
+
+// runResultItem keeps the expected and actual values and the diff as JSON strings, like the
+// problem pack, so arbitrary JSON round-trips unchanged.
+type runResultItem struct {
+	TestID   string `dynamodbav:"test_id"`
+	Status   string `dynamodbav:"status"`
+	TimeMS   int64  `dynamodbav:"time_ms"`
+	Stdout   string `dynamodbav:"stdout,omitempty"`
+	Stderr   string `dynamodbav:"stderr,omitempty"`
+	Expected string `dynamodbav:"expected,omitempty"`
+	Actual   string `dynamodbav:"actual,omitempty"`
+	Diff     string `dynamodbav:"diff,omitempty"`
+	DiffText string `dynamodbav:"diff_text,omitempty"`
+}
+
+// Create records a new attempt when a user begins solving.
+func (s *DynamoAttemptStore) Create(ctx context.Context, req api.CreateAttemptRequest) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+	if strings.TrimSpace(req.ProblemID) == "" {
+		return domain.Attempt{}, api.ErrBadRequest
+	}
+
+	userID := api.CallerID(ctx)
+	owner := userID
+	if owner == "" {
+		owner = anonymousAttemptOwner
+	}
+
+	attemptID := randomID()
+	now := s.clock.Now().UnixMilli()
+	gsi1pk, gsi1sk := gsi1ForAttemptRow(attemptID)
+	item := attemptItem{
+		PK:        userPartitionKey(owner),
+		SK:        attemptRowSortKey(attemptID),
+		Entity:    entityAttempt,
+		AttemptID: attemptID,
+		ProblemID: req.ProblemID,
+		UserID:    userID,
+		Language:  req.Language,
+		StartedAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+		GSI1PK:    gsi1pk,
+		GSI1SK:    gsi1sk,
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return domain.Attempt{}, fmt.Errorf("dynamo attempts: encode attempt: %w", err)
+	}
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &s.tableName,
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	}); err != nil {
+		return domain.Attempt{}, fmt.Errorf("dynamo attempts: create attempt: %w", err)
+	}
+
+	return toDomainAttempt(item), nil
+}
+
+// RecordRun stores the run as its own history item, makes it the attempt's latest run and
+// adds it to the lifetime totals. The run item and the attempt update are written in one
+// transaction, conditional on the attempt not having ended.
+func (s *DynamoAttemptStore) RecordRun(ctx context.Context, attemptID string, run domain.AttemptRun) (domain.AttemptRun, error) {
+	if s == nil {
+		return domain.AttemptRun{}, api.ErrNotImplemented
+	}
+
+	item, err := s.fetchAttemptItem(ctx, attemptID)
+	if err != nil {
+		return domain.AttemptRun{}, err
+	}
+	if item.EndedAt > 0 {
+		return domain.AttemptRun{}, errAttemptCompleted
+	}
+
+	now := s.clock.Now().UnixMilli()
+	run = newAttemptRun(run, now)
+	runAV, err := attributevalue.MarshalMap(newAttemptRunItem(attemptID, run))
+	if err != nil {
+		return domain.AttemptRun{}, fmt.Errorf("dynamo attempts: encode run: %w", err)
+	}
+
+	update := expression.Set(expression.Name("pass_count"), expression.Value(run.PassCount)).
+		Set(expression.Name("fail_count"), expression.Value(run.FailCount)).
+		Set(expression.Name("last_run_id"), expression.Value(run.ID)).
+		Set(expression.Name("updated_at"), expression.Value(now)).
+		Add(expression.Name("lifetime_runs"), expression.Value(1)).
+		Add(expression.Name("lifetime_pass_count"), expression.Value(run.PassCount)).
+		Add(expression.Name("lifetime_fail_count"), expression.Value(run.FailCount))
+	if err := s.putRunAndUpdateAttempt(ctx, "record run", item, runAV, update); err != nil {
+		return domain.AttemptRun{}, err
+	}
+	return run, nil
+}
+
+// Complete finalizes an attempt after submission and puts the submission's run item in the
+// same transaction. The attempt update is conditional on the attempt not having ended, so a
+// second Complete is rejected without moving ended_at or recording its run.
+func (s *DynamoAttemptStore) Complete(ctx context.Context, attemptID string, run domain.AttemptRun, summary domain.SubmissionSummary) error {
+	if s == nil {
+		return api.ErrNotImplemented
+	}
+
+	item, err := s.fetchAttemptItem(ctx, attemptID)
+	if err != nil {
+		return err
+	}
+	if item.EndedAt > 0 {
+		return errAttemptCompleted
+	}
+
+	now := s.clock.Now().UnixMilli()
+	run = newAttemptRun(run, now)
+	runAV, err := attributevalue.MarshalMap(newAttemptRunItem(attemptID, run))
+	if err != nil {
+		return fmt.Errorf("dynamo attempts: encode run: %w", err)
+	}
+
+	var duration int64
+	if item.StartedAt > 0 {
+		duration = now - item.StartedAt
+	}
+	update := expression.Set(expression.Name("ended_at"), expression.Value(now)).
+		Set(expression.Name("duration_ms"), expression.Value(duration)).
+		Set(expression.Name("updated_at"), expression.Value(now)).
+		Set(expression.Name("pass_count"), expression.Value(run.PassCount)).
+		Set(expression.Name("fail_count"), expression.Value(run.FailCount)).
+		Set(expression.Name("last_run_id"), expression.Value(run.ID)).
+		Add(expression.Name("lifetime_runs"), expression.Value(1)).
+		Add(expression.Name("lifetime_pass_count"), expression.Value(run.PassCount)).
+		Add(expression.Name("lifetime_fail_count"), expression.Value(run.FailCount))
+	return s.putRunAndUpdateAttempt(ctx, "complete attempt", item, runAV, update)
+}
+
+// putRunAndUpdateAttempt puts a new run item and applies update to its attempt in one
+// transaction. The update only applies while the attempt exists and has not ended; a
+// transaction cancelled by that condition is reported as errAttemptCompleted.
+func (s *DynamoAttemptStore) putRunAndUpdateAttempt(ctx context.Context, op string, item attemptItem, runAV map[string]types.AttributeValue, update expression.UpdateBuilder) error {
+	condition := expression.AttributeExists(expression.Name("pk")).
+		And(expression.AttributeNotExists(expression.Name("ended_at")))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return fmt.Errorf("dynamo attempts: build update: %w", err)
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:           &s.tableName,
+				Item:                runAV,
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			}},
+			{Update: &types.Update{
+				TableName: &s.tableName,
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: item.PK},
+					"sk": &types.AttributeValueMemberS{Value: item.SK},
+				},
+				UpdateExpression:          expr.Update(),
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			}},
+		},
+	})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		if reasons := canceled.CancellationReasons; len(reasons) > 1 && aws.ToString(reasons[1].Code) == "ConditionalCheckFailed" {
+			return errAttemptCompleted
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo attempts: %s: %w", op, err)
+	}
+	return nil
+}
+
+// RecordUnlock marks protected problem content as revealed for the attempt.
+func (s *DynamoAttemptStore) RecordUnlock(ctx context.Context, attemptID string, unlock domain.Unlock) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+
+	var field string
+	switch unlock {
+	case domain.UnlockHint:
+		field = "hint_used"
+	case domain.UnlockSolutions:
+		field = "solutions_unlocked"
+	default:
+		return domain.Attempt{}, fmt.Errorf("%w: unknown unlock %q", api.ErrBadRequest, unlock)
+	}
+
+	item, err := s.fetchAttemptItem(ctx, attemptID)
+	if err != nil {
+		return domain.Attempt{}, err
+	}
+
+	update := expression.Set(expression.Name(field), expression.Value(true)).
+		Set(expression.Name("updated_at"), expression.Value(s.clock.Now().UnixMilli()))
+	updated, err := s.updateAttempt(ctx, item, update, expression.AttributeExists(expression.Name("pk")))
+	if err != nil {
+		return domain.Attempt{}, err
+	}
+	return toDomainAttempt(updated), nil
+}
+
+// Get returns the attempt metadata.
+func (s *DynamoAttemptStore) Get(ctx context.Context, attemptID string) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+
+	item, err := s.fetchAttemptItem(ctx, attemptID)
+	if err != nil {
+		return domain.Attempt{}, err
+	}
+	return toDomainAttempt(item), nil
+}
+
+// ListRuns returns a page of the attempt's run history, oldest run first. NextToken is the
+// ID of the last run on the page.
+func (s *DynamoAttemptStore) ListRuns(ctx context.Context, attemptID string, opts domain.RunListOptions) (domain.RunListResult, error) {
+	if s == nil {
+		return domain.RunListResult{}, api.ErrNotImplemented
+	}
+	if _, err := s.fetchAttemptItem(ctx, attemptID); err != nil {
+		return domain.RunListResult{}, err
+	}
+
+	keyCond := expression.Key("pk").Equal(expression.Value(attemptPartitionKey(attemptID))).
+		And(expression.Key("sk").BeginsWith("RUN#"))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return domain.RunListResult{}, fmt.Errorf("dynamo attempts: build run query: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 &s.tableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Limit:                     aws.Int32(runPageSize(opts.Limit)),
+		ScanIndexForward:          aws.Bool(true),
+	}
+	if token := strings.TrimSpace(opts.NextToken); token != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: attemptPartitionKey(attemptID)},
+			"sk": &types.AttributeValueMemberS{Value: runSortKey(token)},
+		}
+	}
+
+	out, err := s.client.Query(ctx, input)
+	if err != nil {
+		return domain.RunListResult{}, fmt.Errorf("dynamo attempts: list runs: %w", err)
+	}
+
+	runs := make([]attemptRunItem, 0, len(out.Items))
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &runs); err != nil {
+		return domain.RunListResult{}, fmt.Errorf("dynamo attempts: decode runs: %w", err)
+	}
+
+	result := domain.RunListResult{Runs: make([]domain.AttemptRun, 0, len(runs))}
+	for _, run := range runs {
+		result.Runs = append(result.Runs, toDomainAttemptRun(run))
+	}
+	if len(out.LastEvaluatedKey) > 0 {
+		if skAttr, ok := out.LastEvaluatedKey["sk"].(*types.AttributeValueMemberS); ok {
+			result.NextToken = strings.TrimPrefix(skAttr.Value, "RUN#")
+		}
+	}
+	return result, nil
+}
+
+// fetchAttemptItem resolves an attempt ID to its row through the attempt lookup index.
+func (s *DynamoAttemptStore) fetchAttemptItem(ctx context.Context, attemptID string) (attemptItem, error) {
+	if strings.TrimSpace(attemptID) == "" {
+		return attemptItem{}, api.ErrNotFound
+	}
+
+	gsi1pk, gsi1sk := gsi1ForAttemptRow(attemptID)
+	keyCond := expression.Key("gsi1pk").Equal(expression.Value(gsi1pk)).
+		And(expression.Key("gsi1sk").Equal(expression.Value(gsi1sk)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return attemptItem{}, fmt.Errorf("dynamo attempts: build lookup: %w", err)
+	}
+
+	out, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 &s.tableName,
+		IndexName:                 &s.attemptIndexName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Limit:                     aws.Int32(1),
+	})
+	if err != nil {
+		return attemptItem{}, fmt.Errorf("dynamo attempts: lookup attempt: %w", err)
+	}
+	if len(out.Items) == 0 {
+		return attemptItem{}, api.ErrNotFound
+	}
+
+	var item attemptItem
+	if err := attributevalue.UnmarshalMap(out.Items[0], &item); err != nil {
+		return attemptItem{}, fmt.Errorf("dynamo attempts: decode attempt: %w", err)
+	}
+	return item, nil
+}
+
+// updateAttempt applies update to the attempt row when condition holds and returns the
+// updated row.
+func (s *DynamoAttemptStore) updateAttempt(ctx context.Context, item attemptItem, update expression.UpdateBuilder, condition expression.ConditionBuilder) (attemptItem, error) {
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return attemptItem{}, fmt.Errorf("dynamo attempts: build update: %w", err)
+	}
+
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &s.tableName,
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: item.PK},
+			"sk": &types.AttributeValueMemberS{Value: item.SK},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return attemptItem{}, fmt.Errorf("dynamo attempts: update attempt: %w", err)
+	}
+
+	var updated attemptItem
+	if err := attributevalue.UnmarshalMap(out.Attributes, &updated); err != nil {
+		return attemptItem{}, fmt.Errorf("dynamo attempts: decode attempt: %w", err)
+	}
+	return updated, nil
+}
+
+func toDomainAttempt(item attemptItem) domain.Attempt {
+	return domain.Attempt{
+		ID:                item.AttemptID,
+		ProblemID:         item.ProblemID,
+		UserID:            item.UserID,
+		Language:          item.Language,
+		StartedAt:         item.StartedAt,
+		EndedAt:           item.EndedAt,
+		HintUsed:          item.HintUsed,
+		SolutionsUnlocked: item.SolutionsUnlocked,
+		PassCount:         item.PassCount,
+		FailCount:         item.FailCount,
+		LastRunID:         item.LastRunID,
+		Lifetime: domain.RunTotals{
+			Runs:      item.LifetimeRuns,
+			PassCount: item.LifetimePassCount,
+			FailCount: item.LifetimeFailCount,
+		},
+		DurationMS: item.DurationMS,
+	}
+}
+
+func newAttemptRunItem(attemptID string, run domain.AttemptRun) attemptRunItem {
+	results := make([]runResultItem, 0, len(run.Results))
+	for _, result := range run.Results {
+		results = append(results, newRunResultItem(result))
+	}
+	return attemptRunItem{
+		PK:        attemptPartitionKey(attemptID),
+		SK:        runSortKey(run.ID),
+		Entity:    entityAttemptRun,
+		AttemptID: attemptID,
+		RunID:     run.ID,
+		Which:     run.Which,
+		CodeHash:  run.CodeHash,
+		PassCount: run.PassCount,
+		FailCount: run.FailCount,
+		Results:   results,
+		CreatedAt: run.CreatedAt,
+		UpdatedAt: run.CreatedAt,
+	}
+}
+
+func newRunResultItem(result domain.RunResult) runResultItem {
+	item := runResultItem{
+		TestID:   result.TestID,
+		Status:   result.Status,
+		TimeMS:   result.TimeMS,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		Expected: string(result.Expected),
+		Actual:   string(result.Actual),
+		DiffText: result.DiffText,
+	}
+	// Differences hold decoded JSON values, so encoding them cannot fail.
+	if len(result.Diff) > 0 {
+		diff, _ := json.Marshal(result.Diff)
+		item.Diff = string(diff)
+	}
+	return item
+}
+
+func (item runResultItem) result() domain.RunResult {
+	result := domain.RunResult{
+		TestID:   item.TestID,
+		Status:   item.Status,
+		TimeMS:   item.TimeMS,
+		Stdout:   item.Stdout,
+		Stderr:   item.Stderr,
+		DiffText: item.DiffText,
+	}
+	if item.Expected != "" {
+		result.Expected = json.RawMessage(item.Expected)
+	}
+	if item.Actual != "" {
+		result.Actual = json.RawMessage(item.Actual)
+	}
+	if item.Diff != "" {
+		// A diff that no longer decodes is dropped; DiffText still describes it.
+		_ = json.Unmarshal([]byte(item.Diff), &result.Diff)
+	}
+	return result
+}
+
+func toDomainAttemptRun(item attemptRunItem) domain.AttemptRun {
+	results := make([]domain.RunResult, 0, len(item.Results))
+	for _, result := range item.Results {
+		results = append(results, result.result())
+	}
+	return domain.AttemptRun{
+		ID:        item.RunID,
+		CreatedAt: item.CreatedAt,
+		Which:     item.Which,
+		CodeHash:  item.CodeHash,
+		PassCount: item.PassCount,
+		FailCount: item.FailCount,
+		Results:   results,
+	}
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+)
+
+func TestAttemptItemsRoundTrip(t *testing.T) {
+	gsi1pk, gsi1sk := gsi1ForAttemptRow("att_1")
+	item := attemptItem{
+		PK:        userPartitionKey("user-123"),
+		SK:        attemptRowSortKey("att_1"),
+		Entity:    entityAttempt,
+		AttemptID: "att_1",
+		ProblemID: "prob_1",
+		UserID:    "user-123",
+		Language:  "javascript",
+		StartedAt: 1_700_000_000_000,
+		PassCount: 2,
+		GSI1PK:    gsi1pk,
+		GSI1SK:    gsi1sk,
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		t.Fatalf("marshal attempt: %v", err)
+	}
+	if _, ok := av["ended_at"]; ok {
+		t.Fatalf("expected ended_at to be absent until the attempt completes")
+	}
+	var decoded attemptItem
+	if err := attributevalue.UnmarshalMap(av, &decoded); err != nil {
+		t.Fatalf("unmarshal attempt: %v", err)
+	}
+	if attempt := toDomainAttempt(decoded); attempt.ID != "att_1" || attempt.UserID != "user-123" || attempt.PassCount != 2 || decoded.GSI1PK != "ATTEMPT#att_1" {
+		t.Fatalf("unexpected attempt %+v", attempt)
+	}
+
+	run := newAttemptRunItem("att_1", newAttemptRun(domain.AttemptRun{
+		Which:    "public",
+		CodeHash: domain.CodeHash("return 1"),
+		Results: []domain.RunResult{
+			{TestID: "public_1", Status: runStatusPass, TimeMS: 3},
+			{
+				TestID:   "public_2",
+				Status:   runStatusFail,
+				Expected: json.RawMessage(`[1,2]`),
+				Actual:   json.RawMessage(`null`),
+				Diff:     []jsonfmt.Difference{{Path: "$", Kind: jsonfmt.DiffTypeMismatch, Expected: []any{float64(1), float64(2)}}},
+				DiffText: "$: expected array [ 1, 2 ], got null null",
+			},
+		},
+	}, 1_700_000_000_000))
+	av, err = attributevalue.MarshalMap(run)
+	if err != nil {
+		t.Fatalf("marshal run: %v", err)
+	}
+	var decodedRun attemptRunItem
+	if err := attributevalue.UnmarshalMap(av, &decodedRun); err != nil {
+		t.Fatalf("unmarshal run: %v", err)
+	}
+	got := toDomainAttemptRun(decodedRun)
+	if decodedRun.SK != "RUN#"+got.ID || got.Which != "public" || got.PassCount != 1 || len(got.Results) != 2 || got.Results[0].TestID != "public_1" {
+		t.Fatalf("unexpected run %+v", got)
+	}
+	if failed := got.Results[1]; string(failed.Expected) != "[1,2]" || string(failed.Actual) != "null" || len(failed.Diff) != 1 || failed.Diff[0].Kind != jsonfmt.DiffTypeMismatch || failed.DiffText == "" {
+		t.Fatalf("failed result lost its values: %+v", failed)
+	}
+}
+
+func TestLargeRunFitsInOneItem(t *testing.T) {
+	// Output limits are configurable, so logs can be larger than any single value.
+	log := strings.Repeat("still looping\n", 4*maxRecordedValueBytes/14)
+	value := json.RawMessage(`"` + strings.Repeat("x", maxRecordedValueBytes-2) + `"`)
+	results := make([]domain.RunResult, 8)
+	for i := range results {
+		results[i] = domain.RunResult{
+			TestID:   fmt.Sprintf("public_%d", i+1),
+			Status:   runStatusFail,
+			Stdout:   log,
+			Stderr:   log,
+			Expected: value,
+			Actual:   value,
+			Diff:     []jsonfmt.Difference{{Path: "$", Kind: jsonfmt.DiffChanged, Expected: string(value), Actual: string(value)}},
+			DiffText: string(value),
+		}
+	}
+
+	run := newAttemptRun(domain.AttemptRun{Which: "all", Results: results}, 1_700_000_000_000)
+	av, err := attributevalue.MarshalMap(newAttemptRunItem("att_1", run))
+	if err != nil {
+		t.Fatalf("marshal run: %v", err)
+	}
+	if size := itemSize(av); size > 400_000 {
+		t.Fatalf("expected the run to fit in one item, got %d bytes", size)
+	}
+	if len(run.Results) != len(results) || results[0].Stdout != log {
+		t.Fatalf("expected every result to be kept without changing the caller's results")
+	}
+	for _, result := range run.Results {
+		if result.Actual != nil || result.DiffText != "" {
+			t.Fatalf("expected values larger than the result's share to be dropped")
+		}
+		if !strings.HasSuffix(result.Stdout, recordedTruncationMarker) || !strings.HasSuffix(result.Stderr, recordedTruncationMarker) {
+			t.Fatalf("expected the logs to be cut, got %d bytes of stdout and %d of stderr", len(result.Stdout), len(result.Stderr))
+		}
+	}
+
+	small := newAttemptRun(domain.AttemptRun{Results: []domain.RunResult{{TestID: "public_1", Stdout: log, Actual: value}}}, 1_700_000_000_000)
+	if small.Results[0].Stdout != log || string(small.Results[0].Actual) != string(value) {
+		t.Fatalf("expected a run within budget to be stored as is")
+	}
+}
+
+// itemSize approximates DynamoDB's item size: attribute names plus their values.
+func itemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, value := range item {
+		size += len(name) + attributeSize(value)
+	}
+	return size
+}
+
+func attributeSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return len(v.Value)
+	case *types.AttributeValueMemberM:
+		return 3 + itemSize(v.Value)
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, element := range v.Value {
+			size += 1 + attributeSize(element)
+		}
+		return size
+	default:
+		return 1
+	}
+}
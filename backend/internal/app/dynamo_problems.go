@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+const entityProblem = "PROBLEM"
+
+// DynamoProblemRepository persists generated problem packs in the shared single table under
+// pk = PROBLEM#<id>, sk = META, so every Lambda instance serves the same problems.
+type DynamoProblemRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoProblemRepository constructs a repository on an existing client.
+func NewDynamoProblemRepository(client *dynamodb.Client, tableName string) (*DynamoProblemRepository, error) {
+	if client == nil {
+		return nil, errors.New("dynamo problems: client is required")
+	}
+	if strings.TrimSpace(tableName) == "" {
+		return nil, errors.New("dynamo problems: table name is required")
+	}
+	return &DynamoProblemRepository{client: client, tableName: tableName}, nil
+}
+
+func problemPartitionKey(problemID string) string {
+	return "PROBLEM#" + problemID
+}
+
+func problemMetaSortKey() string {
+	return "META"
+}
+
+// problemItem is the META row of a problem. The pack and generation metadata are stored as
+// JSON documents; the fields used for querying and reporting are lifted to attributes.
+type problemItem struct {
+	PK         string `dynamodbav:"pk"`
+	SK         string `dynamodbav:"sk"`
+	Entity     string `dynamodbav:"entity"`
+	ProblemID  string `dynamodbav:"problem_id"`
+	Title      string `dynamodbav:"title,omitempty"`
+	Category   string `dynamodbav:"category,omitempty"`
+	Difficulty string `dynamodbav:"difficulty,omitempty"`
+	Generator  string `dynamodbav:"generator,omitempty"`
+	Provider   string `dynamodbav:"provider,omitempty"`
+	Model      string `dynamodbav:"model,omitempty"`
+	PromptHash string `dynamodbav:"prompt_hash,omitempty"`
+	CreatedBy  string `dynamodbav:"created_by,omitempty"`
+	CreatedAt  int64  `dynamodbav:"created_at"`
+	UpdatedAt  int64  `dynamodbav:"updated_at"`
+	Pack       string `dynamodbav:"pack"`
+	Generation string `dynamodbav:"generation"`
+}
+
+func newProblemItem(record domain.ProblemRecord) (problemItem, error) {
+	pack, err := json.Marshal(record.Pack)
+	if err != nil {
+		return problemItem{}, fmt.Errorf("dynamo problems: encode pack: %w", err)
+	}
+	generation, err := json.Marshal(record.Generation)
+	if err != nil {
+		return problemItem{}, fmt.Errorf("dynamo problems: encode generation metadata: %w", err)
+	}
+
+	return problemItem{
+		PK:         problemPartitionKey(record.ID),
+		SK:         problemMetaSortKey(),
+		Entity:     entityProblem,
+		ProblemID:  record.ID,
+		Title:      record.Pack.Problem.Title,
+		Category:   strings.ToLower(record.Category),
+		Difficulty: strings.ToLower(record.Difficulty),
+		Generator:  record.Generation.Generator,
+		Provider:   record.Generation.Provider,
+		Model:      record.Generation.Model,
+		PromptHash: record.PromptHash,
+		CreatedBy:  record.CreatedBy,
+		CreatedAt:  record.CreatedAt,
+		UpdatedAt:  record.CreatedAt,
+		Pack:       string(pack),
+		Generation: string(generation),
+	}, nil
+}
+
+func (item problemItem) record() (domain.ProblemRecord, error) {
+	record := domain.ProblemRecord{
+		ID:         item.ProblemID,
+		Category:   item.Category,
+		Difficulty: item.Difficulty,
+		PromptHash: item.PromptHash,
+		CreatedBy:  item.CreatedBy,
+		CreatedAt:  item.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(item.Pack), &record.Pack); err != nil {
+		return domain.ProblemRecord{}, fmt.Errorf("dynamo problems: decode pack: %w", err)
+	}
+	if item.Generation != "" {
+		if err := json.Unmarshal([]byte(item.Generation), &record.Generation); err != nil {
+			return domain.ProblemRecord{}, fmt.Errorf("dynamo problems: decode generation metadata: %w", err)
+		}
+	}
+	return record, nil
+}
+
+// Save stores the record under a new identifier. The write is conditional so an ID
+// collision never overwrites an existing problem.
+func (r *DynamoProblemRepository) Save(ctx context.Context, record domain.ProblemRecord) (string, error) {
+	if r == nil {
+		return "", api.ErrNotImplemented
+	}
+
+	record.ID = randomID()
+	item, err := newProblemItem(record)
+	if err != nil {
+		return "", err
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return "", fmt.Errorf("dynamo problems: encode item: %w", err)
+	}
+
+	if _, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &r.tableName,
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	}); err != nil {
+		return "", fmt.Errorf("dynamo problems: save problem: %w", err)
+	}
+	return record.ID, nil
+}
+
+// Get fetches a previously saved problem pack.
+func (r *DynamoProblemRepository) Get(ctx context.Context, id string) (domain.ProblemPack, error) {
+	record, err := r.GetRecord(ctx, id)
+	if err != nil {
+		return domain.ProblemPack{}, err
+	}
+	return record.Pack, nil
+}
+
+// GetRecord fetches a previously saved pack along with its generation metadata.
+func (r *DynamoProblemRepository) GetRecord(ctx context.Context, id string) (domain.ProblemRecord, error) {
+	if r == nil {
+		return domain.ProblemRecord{}, api.ErrNotImplemented
+	}
+	if strings.TrimSpace(id) == "" {
+		return domain.ProblemRecord{}, api.ErrNotFound
+	}
+
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.tableName,
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: problemPartitionKey(id)},
+			"sk": &types.AttributeValueMemberS{Value: problemMetaSortKey()},
+		},
+	})
+	if err != nil {
+		return domain.ProblemRecord{}, fmt.Errorf("dynamo problems: get problem: %w", err)
+	}
+	if len(out.Item) == 0 {
+		return domain.ProblemRecord{}, api.ErrNotFound
+	}
+
+	var item problemItem
+	if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
+		return domain.ProblemRecord{}, fmt.Errorf("dynamo problems: decode problem: %w", err)
+	}
+	return item.record()
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+
+	"improview/backend/internal/domain"
+)
+
+func TestProblemItemRoundTrip(t *testing.T) {
+	pack := defaultProblemPacks()["bfs:easy"]
+	record := domain.ProblemRecord{
+		ID:         "prob_1",
+		Category:   "BFS",
+		Difficulty: "Easy",
+		PromptHash: domain.PromptHash("bfs", "easy", ""),
+		CreatedBy:  "user-123",
+		CreatedAt:  1_700_000_000_000,
+		Pack:       pack,
+		Generation: domain.GenerationMetadata{Generator: "llm", Provider: "openai", Model: "gpt-4.1-mini", Attempts: 1},
+	}
+
+	item, err := newProblemItem(record)
+	if err != nil {
+		t.Fatalf("new item: %v", err)
+	}
+	if item.PK != "PROBLEM#prob_1" || item.SK != "META" || item.Category != "bfs" || item.Model != "gpt-4.1-mini" {
+		t.Fatalf("unexpected item keys %+v", item)
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		t.Fatalf("marshal item: %v", err)
+	}
+	var decoded problemItem
+	if err := attributevalue.UnmarshalMap(av, &decoded); err != nil {
+		t.Fatalf("unmarshal item: %v", err)
+	}
+	got, err := decoded.record()
+	if err != nil {
+		t.Fatalf("decode record: %v", err)
+	}
+
+	if got.ID != "prob_1" || got.CreatedBy != "user-123" || got.PromptHash != record.PromptHash || got.Generation.Provider != "openai" {
+		t.Fatalf("unexpected record %+v", got)
+	}
+	if got.Pack.Problem.Title != pack.Problem.Title || len(got.Pack.Tests.Hidden) != len(pack.Tests.Hidden) || got.Pack.Solutions[0].Code != pack.Solutions[0].Code {
+		t.Fatalf("pack did not survive the round trip: %+v", got.Pack)
+	}
+	if !outputsEqual(got.Pack.Tests.Public[0].Output, pack.Tests.Public[0].Output) {
+		t.Fatalf("expected test outputs to survive the round trip")
+	}
+}
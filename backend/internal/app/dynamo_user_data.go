@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -23,6 +24,7 @@ const (
 	entityProfile      = "PROFILE"
 	entitySavedProblem = "SAVED_PROBLEM"
 	entitySavedAttempt = "SAVED_ATTEMPT"
+	entityHandle       = "HANDLE"
 
 	defaultAttemptIndex      = "gsi1"
 	defaultUserActivityIndex = "gsi2"
@@ -42,25 +44,43 @@ func NewDynamoUserDataStoreFromEnv(ctx context.Context, tableName, attemptIndex,
 		return nil, errors.New("dynamo store: table name is required")
 	}
 
+	client, err := NewDynamoClientFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewDynamoUserDataStore(client, tableName, attemptIndex, userActivityIndex), nil
+}
+
+// NewDynamoUserDataStore constructs a store on an existing client.
+func NewDynamoUserDataStore(client *dynamodb.Client, tableName, attemptIndex, userActivityIndex string) *DynamoUserDataStore {
 	if attemptIndex == "" {
 		attemptIndex = defaultAttemptIndex
 	}
 	if userActivityIndex == "" {
 		userActivityIndex = defaultUserActivityIndex
 	}
-
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("dynamo store: load config: %w", err)
-	}
-
-	client := dynamodb.NewFromConfig(cfg)
 	return &DynamoUserDataStore{
 		client:            client,
 		tableName:         tableName,
 		attemptIndexName:  attemptIndex,
 		userActivityIndex: userActivityIndex,
-	}, nil
+	}
+}
+
+// NewDynamoClientFromEnv loads AWS configuration from the environment and returns a
+// DynamoDB client shared by the Dynamo-backed stores. DYNAMODB_ENDPOINT points the client
+// at a DynamoDB-compatible endpoint such as DynamoDB Local.
+func NewDynamoClientFromEnv(ctx context.Context) (*dynamodb.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dynamo store: load config: %w", err)
+	}
+	endpoint := strings.TrimSpace(os.Getenv("DYNAMODB_ENDPOINT"))
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}), nil
 }
 
 func userPartitionKey(userID string) string {
@@ -71,6 +91,14 @@ func profileSortKey() string {
 	return "PROFILE"
 }
 
+func handlePartitionKey(handle string) string {
+	return "HANDLE#" + handle
+}
+
+func handleSortKey() string {
+	return "HANDLE"
+}
+
 func savedProblemSortKey(savedProblemID string) string {
 	return "SAVED#" + savedProblemID
 }
@@ -108,6 +136,17 @@ type userProfileItem struct {
 	Preferences map[string]string `dynamodbav:"preferences,omitempty"`
 	CreatedAt   int64             `dynamodbav:"created_at"`
 	UpdatedAt   int64             `dynamodbav:"updated_at"`
+	Version     int64             `dynamodbav:"version"`
+}
+
+// handleItem reserves a handle for one user, so no two profiles can claim it.
+type handleItem struct {
+	PK        string `dynamodbav:"pk"`
+	SK        string `dynamodbav:"sk"`
+	Entity    string `dynamodbav:"entity"`
+	Handle    string `dynamodbav:"handle"`
+	UserID    string `dynamodbav:"user_id"`
+	CreatedAt int64  `dynamodbav:"created_at"`
 }
 
 type savedProblemItem struct {
@@ -125,6 +164,7 @@ type savedProblemItem struct {
 	HintUnlocked           bool     `dynamodbav:"hint_unlocked"`
 	CreatedAt              int64    `dynamodbav:"created_at"`
 	UpdatedAt              int64    `dynamodbav:"updated_at"`
+	Version                int64    `dynamodbav:"version"`
 	LastAttemptID          string   `dynamodbav:"last_attempt_id,omitempty"`
 	LastAttemptStatus      string   `dynamodbav:"last_attempt_status,omitempty"`
 	LastAttemptUpdatedAt   int64    `dynamodbav:"last_attempt_updated_at,omitempty"`
@@ -223,48 +263,54 @@ func (s *DynamoUserDataStore) GetProfile(ctx context.Context, userID string) (do
 	return toDomainProfile(item), nil
 }
 
-// UpsertProfile stores or updates the user's profile.
-func (s *DynamoUserDataStore) UpsertProfile(ctx context.Context, userID string, update domain.UserProfileUpdate, now time.Time) (domain.UserProfile, error) {
-	nowMillis := now.UnixMilli()
-	existing, err := s.GetProfile(ctx, userID)
-	if err != nil && !errors.Is(err, api.ErrNotFound) {
-		return domain.UserProfile{}, err
+// GetProfileByHandle reads the handle's reservation and returns the profile that holds it.
+func (s *DynamoUserDataStore) GetProfileByHandle(ctx context.Context, handle string) (domain.UserProfile, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &s.tableName,
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: handlePartitionKey(handle)},
+			"sk": &types.AttributeValueMemberS{Value: handleSortKey()},
+		},
+	})
+	if err != nil {
+		return domain.UserProfile{}, fmt.Errorf("dynamo store: get handle: %w", err)
 	}
-
-	if existing.UserID == "" {
-		existing = domain.UserProfile{
-			UserID:      userID,
-			Preferences: map[string]string{},
-			CreatedAt:   nowMillis,
-		}
+	if len(out.Item) == 0 {
+		return domain.UserProfile{}, api.ErrNotFound
 	}
 
-	if update.Handle != nil {
-		existing.Handle = strings.TrimSpace(*update.Handle)
-	}
-	if update.DisplayName != nil {
-		existing.DisplayName = strings.TrimSpace(*update.DisplayName)
+	var reservation handleItem
+	if err := attributevalue.UnmarshalMap(out.Item, &reservation); err != nil {
+		return domain.UserProfile{}, fmt.Errorf("dynamo store: decode handle: %w", err)
 	}
-	if update.Bio != nil {
-		existing.Bio = strings.TrimSpace(*update.Bio)
-	}
-	if update.AvatarURL != nil {
-		existing.AvatarURL = strings.TrimSpace(*update.AvatarURL)
+	profile, err := s.GetProfile(ctx, reservation.UserID)
+	if err != nil {
+		return domain.UserProfile{}, err
 	}
-	if update.Timezone != nil {
-		existing.Timezone = strings.TrimSpace(*update.Timezone)
+	if profile.Handle != handle {
+		return domain.UserProfile{}, api.ErrNotFound
 	}
-	if update.Preferences != nil {
-		existing.Preferences = update.Preferences
+	return profile, nil
+}
+
+// UpsertProfile stores or updates the user's profile. The write is conditional on the
+// version that was read, so a concurrent update fails with api.ErrConflict instead of being
+// overwritten. The profile's handle is reserved with a HANDLE# item in the same
+// transaction, and a replaced handle is released.
+func (s *DynamoUserDataStore) UpsertProfile(ctx context.Context, userID string, update domain.UserProfileUpdate, now time.Time) (domain.UserProfile, error) {
+	existing, err := s.GetProfile(ctx, userID)
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return domain.UserProfile{}, err
 	}
-	if existing.Preferences == nil {
-		existing.Preferences = map[string]string{}
+	if err := checkVersion(existing.Version, update.IfVersion); err != nil {
+		return domain.UserProfile{}, err
 	}
-
-	if existing.CreatedAt == 0 {
-		existing.CreatedAt = nowMillis
+	update, err = normalizeProfileUpdate(update)
+	if err != nil {
+		return domain.UserProfile{}, err
 	}
-	existing.UpdatedAt = nowMillis
+	previous, oldHandle := existing.Version, existing.Handle
+	existing = applyProfileUpdate(existing, userID, update, now.UnixMilli())
 
 	item := userProfileItem{
 		PK:          userPartitionKey(userID),
@@ -279,21 +325,92 @@ func (s *DynamoUserDataStore) UpsertProfile(ctx context.Context, userID string,
 		Preferences: existing.Preferences,
 		CreatedAt:   existing.CreatedAt,
 		UpdatedAt:   existing.UpdatedAt,
+		Version:     existing.Version,
 	}
 
 	av, err := attributevalue.MarshalMap(item)
 	if err != nil {
 		return domain.UserProfile{}, fmt.Errorf("dynamo store: encode profile: %w", err)
 	}
+	expr, err := expression.NewBuilder().WithCondition(versionCondition(previous)).Build()
+	if err != nil {
+		return domain.UserProfile{}, fmt.Errorf("dynamo store: build profile condition: %w", err)
+	}
+	profilePut := types.TransactWriteItem{Put: &types.Put{
+		TableName:                 &s.tableName,
+		Item:                      av,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}}
 
-	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &s.tableName,
-		Item:      av,
-	}); err != nil {
-		return domain.UserProfile{}, fmt.Errorf("dynamo store: save profile: %w", err)
+	// Reserving and releasing is conditional on the reservation being free or ours. Re-putting
+	// an unchanged handle also backfills reservations for profiles written before they existed.
+	owned, err := expression.NewBuilder().WithCondition(expression.AttributeNotExists(expression.Name("pk")).
+		Or(expression.Name("user_id").Equal(expression.Value(userID)))).Build()
+	if err != nil {
+		return domain.UserProfile{}, fmt.Errorf("dynamo store: build handle condition: %w", err)
+	}
+	items := []types.TransactWriteItem{profilePut}
+	if existing.Handle != "" {
+		reservation, err := attributevalue.MarshalMap(handleItem{
+			PK:        handlePartitionKey(existing.Handle),
+			SK:        handleSortKey(),
+			Entity:    entityHandle,
+			Handle:    existing.Handle,
+			UserID:    userID,
+			CreatedAt: existing.UpdatedAt,
+		})
+		if err != nil {
+			return domain.UserProfile{}, fmt.Errorf("dynamo store: encode handle: %w", err)
+		}
+		items = append(items, types.TransactWriteItem{Put: &types.Put{
+			TableName:                 &s.tableName,
+			Item:                      reservation,
+			ConditionExpression:       owned.Condition(),
+			ExpressionAttributeNames:  owned.Names(),
+			ExpressionAttributeValues: owned.Values(),
+		}})
+	}
+	if oldHandle != "" && oldHandle != existing.Handle {
+		items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+			TableName: &s.tableName,
+			Key: map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: handlePartitionKey(oldHandle)},
+				"sk": &types.AttributeValueMemberS{Value: handleSortKey()},
+			},
+			ConditionExpression:       owned.Condition(),
+			ExpressionAttributeNames:  owned.Names(),
+			ExpressionAttributeValues: owned.Values(),
+		}})
 	}
 
-	return existing, nil
+	for {
+		_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			return existing, nil
+		}
+
+		var canceled *types.TransactionCanceledException
+		if !errors.As(err, &canceled) {
+			return domain.UserProfile{}, fmt.Errorf("dynamo store: save profile: %w", err)
+		}
+		failed := func(i int) bool {
+			return i < len(canceled.CancellationReasons) && aws.ToString(canceled.CancellationReasons[i].Code) == "ConditionalCheckFailed"
+		}
+		switch {
+		case failed(0):
+			return domain.UserProfile{}, fmt.Errorf("%w: profile was modified concurrently", api.ErrConflict)
+		case existing.Handle != "" && failed(1):
+			return domain.UserProfile{}, errHandleTaken(existing.Handle)
+		case items[len(items)-1].Delete != nil && failed(len(items)-1):
+			// The old handle was never reserved by this profile and another user holds it
+			// now; there is nothing to release.
+			items = items[:len(items)-1]
+		default:
+			return domain.UserProfile{}, fmt.Errorf("%w: dynamo store: save profile: %v", api.ErrRetryable, err)
+		}
+	}
 }
 
 // ListSavedProblems returns saved problem summaries for the user.
@@ -311,10 +428,7 @@ func (s *DynamoUserDataStore) ListSavedProblems(ctx context.Context, userID stri
 		return domain.SavedProblemListResult{}, fmt.Errorf("dynamo store: build list expression: %w", err)
 	}
 
-	limit := opts.Limit
-	if limit <= 0 || limit > 200 {
-		limit = 50
-	}
+	limit := savedProblemPageSize(opts.Limit)
 
 	input := &dynamodb.QueryInput{
 		TableName:                 &s.tableName,
@@ -368,39 +482,9 @@ func (s *DynamoUserDataStore) ListSavedProblems(ctx context.Context, userID stri
 
 // CreateSavedProblem persists a new saved problem for the user.
 func (s *DynamoUserDataStore) CreateSavedProblem(ctx context.Context, userID string, input domain.SavedProblemCreateInput, now time.Time) (domain.SavedProblemSummary, error) {
-	problemID := strings.TrimSpace(input.ProblemID)
-	language := strings.TrimSpace(input.Language)
-	if problemID == "" || language == "" {
-		return domain.SavedProblemSummary{}, api.ErrBadRequest
-	}
-
-	status := input.Status
-	if status == "" {
-		status = domain.SavedProblemStatusInProgress
-	}
-
-	nowMillis := now.UnixMilli()
-	savedProblemID := randomID()
-	tags := normalizeTags(input.Tags)
-
-	gsi1pk, gsi1sk := gsi1ForProblem(userID, problemID, savedProblemID)
-	item := savedProblemItem{
-		PK:             userPartitionKey(userID),
-		SK:             savedProblemSortKey(savedProblemID),
-		Entity:         entitySavedProblem,
-		UserID:         userID,
-		SavedProblemID: savedProblemID,
-		ProblemID:      problemID,
-		Title:          strings.TrimSpace(input.Title),
-		Language:       language,
-		Status:         string(status),
-		Tags:           tags,
-		Notes:          strings.TrimSpace(input.Notes),
-		HintUnlocked:   input.HintUnlocked,
-		CreatedAt:      nowMillis,
-		UpdatedAt:      nowMillis,
-		GSI1PK:         gsi1pk,
-		GSI1SK:         gsi1sk,
+	item, err := newSavedProblemItem(userID, input, now.UnixMilli())
+	if err != nil {
+		return domain.SavedProblemSummary{}, err
 	}
 
 	if err := s.putSavedProblemItem(ctx, item); err != nil {
@@ -428,34 +512,72 @@ func (s *DynamoUserDataStore) GetSavedProblem(ctx context.Context, userID, saved
 	}, nil
 }
 
-// UpdateSavedProblem applies partial metadata updates.
+// UpdateSavedProblem applies partial metadata updates. Only the metadata attributes are
+// written, conditional on the version that was read, so a concurrent update fails with
+// api.ErrConflict and a concurrent AppendAttempt is preserved.
 func (s *DynamoUserDataStore) UpdateSavedProblem(ctx context.Context, userID, savedProblemID string, input domain.SavedProblemUpdateInput, now time.Time) (domain.SavedProblemSummary, error) {
 	item, err := s.fetchSavedProblemItem(ctx, userID, savedProblemID)
 	if err != nil {
 		return domain.SavedProblemSummary{}, err
 	}
-
-	if input.Status != nil {
-		item.Status = string(*input.Status)
-	}
-	if input.Notes != nil {
-		item.Notes = strings.TrimSpace(*input.Notes)
-	}
-	if input.HintUnlocked != nil {
-		item.HintUnlocked = *input.HintUnlocked
+	if err := checkVersion(item.Version, input.IfVersion); err != nil {
+		return domain.SavedProblemSummary{}, err
 	}
-	if input.Tags != nil {
-		item.Tags = normalizeTags(input.Tags)
+	previous := item.Version
+	item = applySavedProblemUpdate(item, input, now.UnixMilli())
+
+	update := expression.Set(expression.Name("status"), expression.Value(item.Status)).
+		Set(expression.Name("hint_unlocked"), expression.Value(item.HintUnlocked)).
+		Set(expression.Name("updated_at"), expression.Value(item.UpdatedAt)).
+		Set(expression.Name("version"), expression.Value(item.Version))
+	update = setOrRemove(update, "tags", item.Tags, len(item.Tags) > 0)
+	update = setOrRemove(update, "notes", item.Notes, item.Notes != "")
+
+	condition := expression.AttributeExists(expression.Name("pk")).And(versionCondition(previous))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return domain.SavedProblemSummary{}, fmt.Errorf("dynamo store: build saved problem update: %w", err)
 	}
 
-	item.UpdatedAt = now.UnixMilli()
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &s.tableName,
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: userPartitionKey(userID)},
+			"sk": &types.AttributeValueMemberS{Value: savedProblemSortKey(item.SavedProblemID)},
+		},
+		UpdateExpression:                    expr.Update(),
+		ConditionExpression:                 expr.Condition(),
+		ExpressionAttributeNames:            expr.Names(),
+		ExpressionAttributeValues:           expr.Values(),
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			if len(conditionFailed.Item) == 0 {
+				return domain.SavedProblemSummary{}, api.ErrNotFound
+			}
+			return domain.SavedProblemSummary{}, fmt.Errorf("%w: saved problem was modified concurrently", api.ErrConflict)
+		}
+		return domain.SavedProblemSummary{}, fmt.Errorf("dynamo store: update saved problem: %w", err)
+	}
 
-	if err := s.putSavedProblemItem(ctx, item); err != nil {
-		return domain.SavedProblemSummary{}, err
+	if err := attributevalue.UnmarshalMap(out.Attributes, &item); err != nil {
+		return domain.SavedProblemSummary{}, fmt.Errorf("dynamo store: decode saved problem: %w", err)
 	}
 	return toDomainSavedProblemSummary(item), nil
 }
 
+// versionCondition matches an item still at the given version. Version zero also matches
+// items written before versions were tracked, and missing items.
+func versionCondition(version int64) expression.ConditionBuilder {
+	if version == 0 {
+		return expression.AttributeNotExists(expression.Name("version"))
+	}
+	return expression.Name("version").Equal(expression.Value(version))
+}
+
 // DeleteSavedProblem removes the saved problem and all associated attempts.
 func (s *DynamoUserDataStore) DeleteSavedProblem(ctx context.Context, userID, savedProblemID string) error {
 	item, err := s.fetchSavedProblemItem(ctx, userID, savedProblemID)
@@ -524,95 +646,101 @@ func (s *DynamoUserDataStore) DeleteSavedProblem(ctx context.Context, userID, sa
 	return nil
 }
 
-// AppendAttempt persists a new attempt snapshot for the saved problem.
+// AppendAttempt persists a new attempt snapshot and records it as the saved problem's last
+// attempt in a single transaction. The parent update only moves last_attempt_updated_at
+// forward, so a concurrent older append cannot roll the last attempt back; it fails with
+// api.ErrRetryable instead.
 func (s *DynamoUserDataStore) AppendAttempt(ctx context.Context, userID, savedProblemID string, input domain.SavedProblemAttemptInput, now time.Time) (domain.SavedAttemptSnapshot, error) {
 	item, err := s.fetchSavedProblemItem(ctx, userID, savedProblemID)
 	if err != nil {
 		return domain.SavedAttemptSnapshot{}, err
 	}
-
-	attemptID := strings.TrimSpace(input.AttemptID)
-	if attemptID == "" {
-		attemptID = randomID()
+	if err := checkAttemptOrder(item, now.UnixMilli()); err != nil {
+		return domain.SavedAttemptSnapshot{}, err
 	}
 
-	status := input.Status
-	if status == "" {
-		status = domain.SavedAttemptStatusSubmitted
-	}
+	item, attemptItem := appendSavedAttempt(userID, item, input, now.UnixMilli())
 
-	updatedAt := now.UnixMilli()
+	attemptAV, err := attributevalue.MarshalMap(attemptItem)
+	if err != nil {
+		return domain.SavedAttemptSnapshot{}, fmt.Errorf("dynamo store: encode attempt: %w", err)
+	}
 
-	item.LastAttemptID = attemptID
-	item.LastAttemptStatus = string(status)
-	item.LastAttemptUpdatedAt = updatedAt
-	item.LastAttemptPassCount = input.PassCount
-	item.LastAttemptFailCount = input.FailCount
-	item.LastAttemptRuntimeMS = input.RuntimeMS
-	item.LastAttemptCode = input.Code
-	item.LastAttemptCodeS3Key = input.CodeS3Key
-	item.LastAttemptSubmittedAt = input.SubmittedAt
-	item.UpdatedAt = updatedAt
+	update := expression.Set(expression.Name("last_attempt_id"), expression.Value(item.LastAttemptID)).
+		Set(expression.Name("last_attempt_status"), expression.Value(item.LastAttemptStatus)).
+		Set(expression.Name("last_attempt_updated_at"), expression.Value(item.LastAttemptUpdatedAt)).
+		Set(expression.Name("last_attempt_pass_count"), expression.Value(item.LastAttemptPassCount)).
+		Set(expression.Name("last_attempt_fail_count"), expression.Value(item.LastAttemptFailCount)).
+		Set(expression.Name("last_attempt_runtime_ms"), expression.Value(item.LastAttemptRuntimeMS)).
+		Set(expression.Name("updated_at"), expression.Value(item.UpdatedAt))
+	update = setOrRemove(update, "last_attempt_code", item.LastAttemptCode, item.LastAttemptCode != "")
+	update = setOrRemove(update, "last_attempt_code_s3_key", item.LastAttemptCodeS3Key, item.LastAttemptCodeS3Key != "")
+	update = setOrRemove(update, "last_attempt_submitted_at", item.LastAttemptSubmittedAt, item.LastAttemptSubmittedAt != nil)
+
+	condition := expression.AttributeExists(expression.Name("pk")).And(expression.Or(
+		expression.AttributeNotExists(expression.Name("last_attempt_updated_at")),
+		expression.Name("last_attempt_updated_at").LessThanEqual(expression.Value(item.LastAttemptUpdatedAt)),
+	))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return domain.SavedAttemptSnapshot{}, fmt.Errorf("dynamo store: build attempt update: %w", err)
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:           &s.tableName,
+				Item:                attemptAV,
+				ConditionExpression: aws.String("attribute_not_exists(pk) AND attribute_not_exists(sk)"),
+			}},
+			{Update: &types.Update{
+				TableName: &s.tableName,
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: userPartitionKey(userID)},
+					"sk": &types.AttributeValueMemberS{Value: savedProblemSortKey(item.SavedProblemID)},
+				},
+				UpdateExpression:                    expr.Update(),
+				ConditionExpression:                 expr.Condition(),
+				ExpressionAttributeNames:            expr.Names(),
+				ExpressionAttributeValues:           expr.Values(),
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			}},
+		},
+	})
+	if err != nil {
+		return domain.SavedAttemptSnapshot{}, appendAttemptError(err)
+	}
 
-	ts := fmt.Sprintf("%013d", updatedAt)
-	gsi1pk, gsi1sk := gsi1ForAttempt(attemptID, item.SavedProblemID)
-	gsi2pk, gsi2sk := gsi2ForAttempt(userID, item.SavedProblemID, attemptID, updatedAt)
+	return toDomainAttemptSnapshot(attemptItem), nil
+}
 
-	attemptItem := savedAttemptItem{
-		PK:             savedProblemPartitionKey(item.SavedProblemID),
-		SK:             attemptSortKey(ts, attemptID),
-		Entity:         entitySavedAttempt,
-		UserID:         userID,
-		SavedProblemID: item.SavedProblemID,
-		AttemptID:      attemptID,
-		Status:         string(status),
-		UpdatedAt:      updatedAt,
-		PassCount:      input.PassCount,
-		FailCount:      input.FailCount,
-		RuntimeMS:      input.RuntimeMS,
-		Code:           input.Code,
-		CodeS3Key:      input.CodeS3Key,
-		SubmittedAt:    input.SubmittedAt,
-		GSI1PK:         gsi1pk,
-		GSI1SK:         gsi1sk,
-		GSI2PK:         gsi2pk,
-		GSI2SK:         gsi2sk,
+// appendAttemptError maps a failed append transaction: a duplicate attempt is a bad
+// request, a missing parent is not found, and a newer last attempt or a conflicting
+// transaction is retryable.
+func appendAttemptError(err error) error {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return fmt.Errorf("dynamo store: append attempt: %w", err)
 	}
 
-	attemptAV, err := attributevalue.MarshalMap(attemptItem)
-	if err != nil {
-		return domain.SavedAttemptSnapshot{}, fmt.Errorf("dynamo store: encode attempt: %w", err)
+	reasons := canceled.CancellationReasons
+	if len(reasons) > 0 && aws.ToString(reasons[0].Code) == "ConditionalCheckFailed" {
+		return api.ErrBadRequest
 	}
-
-	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:           &s.tableName,
-		Item:                attemptAV,
-		ConditionExpression: aws.String("attribute_not_exists(pk) AND attribute_not_exists(sk)"),
-	}); err != nil {
-		var condErr *types.ConditionalCheckFailedException
-		if errors.As(err, &condErr) {
-			return domain.SavedAttemptSnapshot{}, api.ErrBadRequest
+	if len(reasons) > 1 && aws.ToString(reasons[1].Code) == "ConditionalCheckFailed" {
+		if len(reasons[1].Item) == 0 {
+			return api.ErrNotFound
 		}
-		return domain.SavedAttemptSnapshot{}, fmt.Errorf("dynamo store: put attempt: %w", err)
+		return errStaleAttempt
 	}
+	return fmt.Errorf("%w: dynamo store: append attempt: %v", api.ErrRetryable, err)
+}
 
-	if err := s.putSavedProblemItem(ctx, item); err != nil {
-		return domain.SavedAttemptSnapshot{}, err
+func setOrRemove(update expression.UpdateBuilder, name string, value any, set bool) expression.UpdateBuilder {
+	if set {
+		return update.Set(expression.Name(name), expression.Value(value))
 	}
-
-	return domain.SavedAttemptSnapshot{
-		SavedAttemptSummary: domain.SavedAttemptSummary{
-			AttemptID: attemptID,
-			Status:    status,
-			UpdatedAt: updatedAt,
-			PassCount: input.PassCount,
-			FailCount: input.FailCount,
-		},
-		SubmittedAt: input.SubmittedAt,
-		RuntimeMS:   input.RuntimeMS,
-		Code:        input.Code,
-		CodeS3Key:   input.CodeS3Key,
-	}, nil
+	return update.Remove(expression.Name(name))
 }
 
 // ListAttempts returns attempt snapshots for a saved problem.
@@ -655,6 +783,201 @@ func (s *DynamoUserDataStore) listAttemptsInternal(ctx context.Context, parent s
 	return snapshots, nil
 }
 
+// applyProfileUpdate merges a partial update into the stored profile, creating it when
+// existing is empty.
+func applyProfileUpdate(existing domain.UserProfile, userID string, update domain.UserProfileUpdate, nowMillis int64) domain.UserProfile {
+	if existing.UserID == "" {
+		existing = domain.UserProfile{
+			UserID:      userID,
+			Preferences: map[string]string{},
+			CreatedAt:   nowMillis,
+		}
+	}
+
+	if update.Handle != nil {
+		existing.Handle = strings.TrimSpace(*update.Handle)
+	}
+	if update.DisplayName != nil {
+		existing.DisplayName = strings.TrimSpace(*update.DisplayName)
+	}
+	if update.Bio != nil {
+		existing.Bio = strings.TrimSpace(*update.Bio)
+	}
+	if update.AvatarURL != nil {
+		existing.AvatarURL = strings.TrimSpace(*update.AvatarURL)
+	}
+	if update.Timezone != nil {
+		existing.Timezone = strings.TrimSpace(*update.Timezone)
+	}
+	if update.Preferences != nil {
+		existing.Preferences = update.Preferences
+	}
+	if existing.Preferences == nil {
+		existing.Preferences = map[string]string{}
+	}
+
+	if existing.CreatedAt == 0 {
+		existing.CreatedAt = nowMillis
+	}
+	existing.UpdatedAt = nowMillis
+	existing.Version++
+	return existing
+}
+
+// normalizeProfileUpdate validates a requested handle and replaces it with its normalized
+// form. An empty handle clears the profile's handle.
+func normalizeProfileUpdate(update domain.UserProfileUpdate) (domain.UserProfileUpdate, error) {
+	if update.Handle == nil || strings.TrimSpace(*update.Handle) == "" {
+		return update, nil
+	}
+	handle, err := domain.NormalizeHandle(*update.Handle)
+	if err != nil {
+		return update, fmt.Errorf("%w: %v", api.ErrBadRequest, err)
+	}
+	update.Handle = &handle
+	return update, nil
+}
+
+func errHandleTaken(handle string) error {
+	return fmt.Errorf("%w: handle %q is taken", api.ErrConflict, handle)
+}
+
+// checkVersion reports api.ErrConflict when the caller expected a version other than the
+// stored one. An expected version of zero skips the check.
+func checkVersion(current, expected int64) error {
+	if expected != 0 && expected != current {
+		return fmt.Errorf("%w: expected version %d, found %d", api.ErrConflict, expected, current)
+	}
+	return nil
+}
+
+// newSavedProblemItem validates the input and builds the saved problem row.
+func newSavedProblemItem(userID string, input domain.SavedProblemCreateInput, nowMillis int64) (savedProblemItem, error) {
+	problemID := strings.TrimSpace(input.ProblemID)
+	language := strings.TrimSpace(input.Language)
+	if problemID == "" || language == "" {
+		return savedProblemItem{}, api.ErrBadRequest
+	}
+
+	status := input.Status
+	if status == "" {
+		status = domain.SavedProblemStatusInProgress
+	}
+
+	savedProblemID := randomID()
+	gsi1pk, gsi1sk := gsi1ForProblem(userID, problemID, savedProblemID)
+	return savedProblemItem{
+		PK:             userPartitionKey(userID),
+		SK:             savedProblemSortKey(savedProblemID),
+		Entity:         entitySavedProblem,
+		UserID:         userID,
+		SavedProblemID: savedProblemID,
+		ProblemID:      problemID,
+		Title:          strings.TrimSpace(input.Title),
+		Language:       language,
+		Status:         string(status),
+		Tags:           normalizeTags(input.Tags),
+		Notes:          strings.TrimSpace(input.Notes),
+		HintUnlocked:   input.HintUnlocked,
+		CreatedAt:      nowMillis,
+		UpdatedAt:      nowMillis,
+		Version:        1,
+		GSI1PK:         gsi1pk,
+		GSI1SK:         gsi1sk,
+	}, nil
+}
+
+// applySavedProblemUpdate applies the fields set in input.
+func applySavedProblemUpdate(item savedProblemItem, input domain.SavedProblemUpdateInput, nowMillis int64) savedProblemItem {
+	if input.Status != nil {
+		item.Status = string(*input.Status)
+	}
+	if input.Notes != nil {
+		item.Notes = strings.TrimSpace(*input.Notes)
+	}
+	if input.HintUnlocked != nil {
+		item.HintUnlocked = *input.HintUnlocked
+	}
+	if input.Tags != nil {
+		item.Tags = normalizeTags(input.Tags)
+	}
+	item.UpdatedAt = nowMillis
+	item.Version++
+	return item
+}
+
+// errStaleAttempt rejects an append older than the saved problem's last attempt, which
+// would otherwise move the last attempt backwards.
+var errStaleAttempt = fmt.Errorf("%w: a newer attempt was recorded concurrently", api.ErrRetryable)
+
+// checkAttemptOrder reports errStaleAttempt when appending at nowMillis would move the
+// parent's last attempt backwards.
+func checkAttemptOrder(parent savedProblemItem, nowMillis int64) error {
+	if parent.LastAttemptUpdatedAt > nowMillis {
+		return errStaleAttempt
+	}
+	return nil
+}
+
+// appendSavedAttempt builds the attempt snapshot row and records it as the parent's last
+// attempt.
+func appendSavedAttempt(userID string, parent savedProblemItem, input domain.SavedProblemAttemptInput, nowMillis int64) (savedProblemItem, savedAttemptItem) {
+	attemptID := strings.TrimSpace(input.AttemptID)
+	if attemptID == "" {
+		attemptID = randomID()
+	}
+
+	status := input.Status
+	if status == "" {
+		status = domain.SavedAttemptStatusSubmitted
+	}
+
+	parent.LastAttemptID = attemptID
+	parent.LastAttemptStatus = string(status)
+	parent.LastAttemptUpdatedAt = nowMillis
+	parent.LastAttemptPassCount = input.PassCount
+	parent.LastAttemptFailCount = input.FailCount
+	parent.LastAttemptRuntimeMS = input.RuntimeMS
+	parent.LastAttemptCode = input.Code
+	parent.LastAttemptCodeS3Key = input.CodeS3Key
+	parent.LastAttemptSubmittedAt = input.SubmittedAt
+	parent.UpdatedAt = nowMillis
+
+	ts := fmt.Sprintf("%013d", nowMillis)
+	gsi1pk, gsi1sk := gsi1ForAttempt(attemptID, parent.SavedProblemID)
+	gsi2pk, gsi2sk := gsi2ForAttempt(userID, parent.SavedProblemID, attemptID, nowMillis)
+
+	return parent, savedAttemptItem{
+		PK:             savedProblemPartitionKey(parent.SavedProblemID),
+		SK:             attemptSortKey(ts, attemptID),
+		Entity:         entitySavedAttempt,
+		UserID:         userID,
+		SavedProblemID: parent.SavedProblemID,
+		AttemptID:      attemptID,
+		Status:         string(status),
+		UpdatedAt:      nowMillis,
+		PassCount:      input.PassCount,
+		FailCount:      input.FailCount,
+		RuntimeMS:      input.RuntimeMS,
+		Code:           input.Code,
+		CodeS3Key:      input.CodeS3Key,
+		SubmittedAt:    input.SubmittedAt,
+		GSI1PK:         gsi1pk,
+		GSI1SK:         gsi1sk,
+		GSI2PK:         gsi2pk,
+		GSI2SK:         gsi2sk,
+	}
+}
+
+// savedProblemPageSize is the number of saved problems evaluated per page. The status
+// filter applies after the limit, so a filtered page may hold fewer items.
+func savedProblemPageSize(limit int32) int32 {
+	if limit <= 0 || limit > 200 {
+		return 50
+	}
+	return limit
+}
+
 func toDomainProfile(item userProfileItem) domain.UserProfile {
 	prefs := item.Preferences
 	if prefs == nil {
@@ -670,6 +993,7 @@ func toDomainProfile(item userProfileItem) domain.UserProfile {
 		Preferences: prefs,
 		CreatedAt:   item.CreatedAt,
 		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
 	}
 }
 
@@ -685,6 +1009,7 @@ func toDomainSavedProblemSummary(item savedProblemItem) domain.SavedProblemSumma
 		HintUnlocked: item.HintUnlocked,
 		CreatedAt:    item.CreatedAt,
 		UpdatedAt:    item.UpdatedAt,
+		Version:      item.Version,
 	}
 
 	if item.LastAttemptID != "" {
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"improview/backend/internal/api"
+)
+
+func TestAppendAttemptErrorMapping(t *testing.T) {
+	reason := func(code string, item map[string]types.AttributeValue) types.CancellationReason {
+		return types.CancellationReason{Code: aws.String(code), Item: item}
+	}
+	parent := map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "USER#user-1"}}
+
+	cases := []struct {
+		name    string
+		reasons []types.CancellationReason
+		want    error
+	}{
+		{"duplicate attempt", []types.CancellationReason{reason("ConditionalCheckFailed", nil), reason("None", nil)}, api.ErrBadRequest},
+		{"missing saved problem", []types.CancellationReason{reason("None", nil), reason("ConditionalCheckFailed", nil)}, api.ErrNotFound},
+		{"newer last attempt", []types.CancellationReason{reason("None", nil), reason("ConditionalCheckFailed", parent)}, api.ErrRetryable},
+		{"transaction conflict", []types.CancellationReason{reason("None", nil), reason("TransactionConflict", nil)}, api.ErrRetryable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := appendAttemptError(&types.TransactionCanceledException{CancellationReasons: tc.reasons})
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if err := appendAttemptError(errors.New("network down")); errors.Is(err, api.ErrRetryable) {
+		t.Fatalf("unrelated failures must not be retryable, got %v", err)
+	}
+}
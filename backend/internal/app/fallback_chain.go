@@ -0,0 +1,307 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+const (
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = 30 * time.Second
+
+	// FallbackStepStatic names the static generator in a fallback chain.
+	FallbackStepStatic = "static"
+	// FallbackStepRequested names a caller's LLM selection that the chain's LLM generator
+	// cannot resolve to a configured model; all such selections share one breaker.
+	FallbackStepRequested = "requested"
+)
+
+// selectionResolver is implemented by LLM generators that can map a caller's overrides to
+// the configured provider and model they would use.
+type selectionResolver interface {
+	resolveSelection(overrides api.LLMRequestOptions) (llmSelection, error)
+}
+
+// FallbackStep is one entry of a fallback chain: a generator and the LLM selection it is
+// called with. Overrides is nil for generators that ignore it, such as the static one.
+type FallbackStep struct {
+	Name      string
+	Generator api.ProblemGenerator
+	Overrides *api.LLMRequestOptions
+}
+
+// BreakerOptions configures the per-step circuit breakers.
+type BreakerOptions struct {
+	// Threshold is the number of consecutive failures that opens a breaker (default 3).
+	Threshold int
+	// Cooldown is how long an open breaker rejects calls before a trial call (default 30s).
+	Cooldown time.Duration
+}
+
+// FallbackChainGenerator tries its steps in order until one produces a pack, for example
+// primary model → cheaper model → second provider → static. Each step has its own circuit
+// breaker so a failing provider is skipped without being called until its cooldown elapses.
+// Requests rejected as invalid (api.ErrBadRequest) are not retried on later steps.
+type FallbackChainGenerator struct {
+	steps   []FallbackStep
+	llm     api.ProblemGenerator
+	options BreakerOptions
+	clock   api.Clock
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// NewFallbackChainGenerator builds a chain over steps. llm handles caller-specified LLM
+// overrides, which are tried before the configured steps; it may be nil.
+func NewFallbackChainGenerator(steps []FallbackStep, llm api.ProblemGenerator, options BreakerOptions, clock api.Clock) (*FallbackChainGenerator, error) {
+	if len(steps) == 0 {
+		return nil, errors.New("fallback chain: at least one step is required")
+	}
+	seen := make(map[string]struct{}, len(steps))
+	for _, step := range steps {
+		if strings.TrimSpace(step.Name) == "" || step.Generator == nil {
+			return nil, errors.New("fallback chain: every step needs a name and a generator")
+		}
+		if _, dup := seen[step.Name]; dup {
+			return nil, fmt.Errorf("fallback chain: duplicate step %q", step.Name)
+		}
+		seen[step.Name] = struct{}{}
+	}
+
+	if options.Threshold <= 0 {
+		options.Threshold = defaultBreakerThreshold
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = defaultBreakerCooldown
+	}
+	if clock == nil {
+		clock = api.RealClock{}
+	}
+
+	return &FallbackChainGenerator{
+		steps:    append([]FallbackStep(nil), steps...),
+		llm:      llm,
+		options:  options,
+		clock:    clock,
+		breakers: make(map[string]*circuitBreaker),
+	}, nil
+}
+
+// Generate walks the chain. The metadata of the returned pack names the step that produced
+// it and lists the steps that were skipped or failed before it.
+func (g *FallbackChainGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
+	if g == nil {
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
+	}
+
+	steps, err := g.stepsFor(req)
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+
+	var skipped []domain.SkippedStep
+	var lastErr error
+	for _, step := range steps {
+		breaker := g.breaker(step.Name)
+		if !breaker.allow() {
+			skipped = append(skipped, domain.SkippedStep{Step: step.Name, Reason: "circuit open"})
+			continue
+		}
+
+		stepReq := req
+		if step.Overrides != nil {
+			overrides := *step.Overrides
+			stepReq.LLM = &overrides
+		}
+
+		generated, err := step.Generator.Generate(ctx, stepReq)
+		if err == nil {
+			breaker.success()
+			generated.Metadata.Step = step.Name
+			generated.Metadata.Skipped = skipped
+			return generated, nil
+		}
+		if errors.Is(err, api.ErrBadRequest) || ctx.Err() != nil {
+			// Neither says anything about the provider's health.
+			breaker.release()
+			return domain.GeneratedProblem{}, err
+		}
+
+		breaker.failure()
+		skipped = append(skipped, domain.SkippedStep{Step: step.Name, Reason: err.Error()})
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return domain.GeneratedProblem{}, fmt.Errorf("%w: fallback chain: every generator is unavailable (circuit open)", api.ErrUpstream)
+	}
+	return domain.GeneratedProblem{}, lastErr
+}
+
+// stepsFor prepends the caller's own LLM selection, when given, to the configured steps.
+// The selection is named after the configured model it resolves to, so callers cannot add
+// breakers beyond the catalog; selections the LLM generator rejects fail here, before any
+// step runs.
+func (g *FallbackChainGenerator) stepsFor(req api.GenerateRequest) ([]FallbackStep, error) {
+	if g.llm == nil || req.LLM == nil || (strings.TrimSpace(req.LLM.Provider) == "" && strings.TrimSpace(req.LLM.Model) == "" && strings.TrimSpace(req.LLM.BaseURL) == "") {
+		return g.steps, nil
+	}
+
+	overrides := *req.LLM
+	requested := FallbackStep{Name: fallbackStepName(overrides), Generator: g.llm, Overrides: &overrides}
+	if !g.configured(requested.Name) {
+		requested.Name = FallbackStepRequested
+		if resolver, ok := resolverFor(g.llm); ok {
+			selection, err := resolver.resolveSelection(overrides)
+			if err != nil {
+				return nil, err
+			}
+			requested.Name = fallbackStepName(api.LLMRequestOptions{Provider: selection.Provider, Model: selection.Model})
+		}
+	}
+
+	steps := make([]FallbackStep, 0, len(g.steps)+1)
+	steps = append(steps, requested)
+	for _, step := range g.steps {
+		if step.Name != requested.Name {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// resolverFor returns the selection resolver behind generator, looking through the
+// verifier that wraps each step.
+func resolverFor(generator api.ProblemGenerator) (selectionResolver, bool) {
+	if verifier, ok := generator.(*VerifyingProblemGenerator); ok {
+		generator = verifier.Next
+	}
+	resolver, ok := generator.(selectionResolver)
+	return resolver, ok
+}
+
+func (g *FallbackChainGenerator) configured(name string) bool {
+	for _, step := range g.steps {
+		if step.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *FallbackChainGenerator) breaker(name string) *circuitBreaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	breaker, ok := g.breakers[name]
+	if !ok {
+		breaker = &circuitBreaker{threshold: g.options.Threshold, cooldown: g.options.Cooldown, now: g.clock.Now}
+		g.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// ParseFallbackChain reads a comma-separated chain such as
+// "openai/gpt-4.1,openai/gpt-4.1-mini,anthropic,static", as used by LLM_FALLBACK_CHAIN.
+// Each entry is "static", a provider name, or "provider/model".
+func ParseFallbackChain(raw string, static, llm api.ProblemGenerator) ([]FallbackStep, error) {
+	entries := uniqueStrings(splitCSV(raw))
+	steps := make([]FallbackStep, 0, len(entries))
+	for _, entry := range entries {
+		if strings.EqualFold(entry, FallbackStepStatic) {
+			steps = append(steps, FallbackStep{Name: FallbackStepStatic, Generator: static})
+			continue
+		}
+		if llm == nil {
+			return nil, fmt.Errorf("fallback chain: step %q needs an LLM generator", entry)
+		}
+
+		provider, model, _ := strings.Cut(entry, "/")
+		overrides := api.LLMRequestOptions{Provider: strings.TrimSpace(provider), Model: strings.TrimSpace(model)}
+		if overrides.Provider == "" {
+			return nil, fmt.Errorf("fallback chain: step %q has no provider", entry)
+		}
+		steps = append(steps, FallbackStep{Name: fallbackStepName(overrides), Generator: llm, Overrides: &overrides})
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("fallback chain: no steps configured")
+	}
+	return steps, nil
+}
+
+func fallbackStepName(overrides api.LLMRequestOptions) string {
+	name := strings.ToLower(strings.TrimSpace(overrides.Provider))
+	if name == "" {
+		name = strings.TrimSpace(overrides.BaseURL)
+	}
+	if model := strings.TrimSpace(overrides.Model); model != "" {
+		if name == "" {
+			return model
+		}
+		name += "/" + model
+	}
+	return name
+}
+
+// circuitBreaker opens after threshold consecutive failures. Once the cooldown elapses a
+// single trial call is let through: success closes the breaker, failure reopens it.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// release ends a call that neither succeeded nor failed, freeing the trial slot it may hold
+// without changing the failure count.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,342 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+// scriptedGenerator fails while fail is set and counts its calls.
+type scriptedGenerator struct {
+	name  string
+	fail  error
+	calls int
+	last  api.GenerateRequest
+}
+
+func (g *scriptedGenerator) Generate(_ context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
+	g.calls++
+	g.last = req
+	if g.fail != nil {
+		return domain.GeneratedProblem{}, g.fail
+	}
+	return domain.GeneratedProblem{Pack: domain.ProblemPack{Hint: g.name}, Metadata: domain.GenerationMetadata{Generator: g.name}}, nil
+}
+
+func upstreamFailure(name string) error {
+	return fmt.Errorf("%w: %s unavailable", api.ErrUpstream, name)
+}
+
+func TestFallbackChainFallsThroughOnFailure(t *testing.T) {
+	primary := &scriptedGenerator{name: "primary", fail: upstreamFailure("primary")}
+	static := &scriptedGenerator{name: "static"}
+
+	chain, err := NewFallbackChainGenerator([]FallbackStep{
+		{Name: "openai/gpt-4.1", Generator: primary, Overrides: &api.LLMRequestOptions{Provider: "openai", Model: "gpt-4.1"}},
+		{Name: FallbackStepStatic, Generator: static},
+	}, nil, BreakerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	generated, err := chain.Generate(context.Background(), api.GenerateRequest{Category: "graphs"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated.Pack.Hint != "static" || generated.Metadata.Step != FallbackStepStatic {
+		t.Fatalf("expected static step to produce the pack, got %+v", generated.Metadata)
+	}
+	if len(generated.Metadata.Skipped) != 1 || generated.Metadata.Skipped[0].Step != "openai/gpt-4.1" || !strings.Contains(generated.Metadata.Skipped[0].Reason, "primary unavailable") {
+		t.Fatalf("unexpected skipped steps %+v", generated.Metadata.Skipped)
+	}
+	if primary.last.LLM == nil || primary.last.LLM.Model != "gpt-4.1" || primary.last.Category != "graphs" {
+		t.Fatalf("expected step overrides on request, got %+v", primary.last)
+	}
+}
+
+func TestFallbackChainFallsThroughWhenVerificationFails(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	broken := newTestVerifier(t, sumPack("function sum(a, b) { throw new Error('boom'); }"))
+	working := newTestVerifier(t, sumPack("function sum(a, b) { return a + b; }"))
+
+	chain, err := NewFallbackChainGenerator([]FallbackStep{
+		{Name: "broken", Generator: broken},
+		{Name: "working", Generator: working},
+	}, nil, BreakerOptions{Threshold: 1, Cooldown: time.Minute}, clock)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	generated, err := chain.Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated.Metadata.Step != "working" || generated.Metadata.Verification == nil {
+		t.Fatalf("expected the verified pack from the second step, got %+v", generated.Metadata)
+	}
+	if len(generated.Metadata.Skipped) != 1 || !strings.Contains(generated.Metadata.Skipped[0].Reason, "problem verifier") {
+		t.Fatalf("expected the first step to be skipped for failing verification, got %+v", generated.Metadata.Skipped)
+	}
+
+	// The failed verification counted against the first step's breaker.
+	generated, err = chain.Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(generated.Metadata.Skipped) != 1 || generated.Metadata.Skipped[0].Reason != "circuit open" {
+		t.Fatalf("expected the first step's breaker to be open, got %+v", generated.Metadata.Skipped)
+	}
+}
+
+func TestFallbackChainStopsOnBadRequest(t *testing.T) {
+	primary := &scriptedGenerator{name: "primary", fail: fmt.Errorf("%w: category required", api.ErrBadRequest)}
+	static := &scriptedGenerator{name: "static"}
+
+	chain, err := NewFallbackChainGenerator([]FallbackStep{
+		{Name: "primary", Generator: primary},
+		{Name: FallbackStepStatic, Generator: static},
+	}, nil, BreakerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	if _, err := chain.Generate(context.Background(), api.GenerateRequest{}); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	if static.calls != 0 {
+		t.Fatalf("expected no fallback after bad request")
+	}
+}
+
+func TestFallbackChainCircuitBreaker(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	primary := &scriptedGenerator{name: "primary", fail: upstreamFailure("primary")}
+	static := &scriptedGenerator{name: "static"}
+
+	chain, err := NewFallbackChainGenerator([]FallbackStep{
+		{Name: "primary", Generator: primary},
+		{Name: FallbackStepStatic, Generator: static},
+	}, nil, BreakerOptions{Threshold: 2, Cooldown: time.Minute}, clock)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	generate := func() domain.GeneratedProblem {
+		t.Helper()
+		generated, err := chain.Generate(context.Background(), api.GenerateRequest{})
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		return generated
+	}
+
+	generate()
+	generate()
+	if primary.calls != 2 {
+		t.Fatalf("expected primary to be tried twice, got %d", primary.calls)
+	}
+
+	// Breaker is open: primary is skipped without being called.
+	generated := generate()
+	if primary.calls != 2 || generated.Metadata.Skipped[0].Reason != "circuit open" {
+		t.Fatalf("expected open breaker to skip primary, calls=%d skipped=%+v", primary.calls, generated.Metadata.Skipped)
+	}
+
+	// After the cooldown one trial call goes through; its failure reopens the breaker.
+	clock.now = clock.now.Add(time.Minute)
+	generate()
+	generate()
+	if primary.calls != 3 {
+		t.Fatalf("expected a single trial call after cooldown, got %d calls", primary.calls)
+	}
+
+	// A successful trial closes the breaker.
+	clock.now = clock.now.Add(time.Minute)
+	primary.fail = nil
+	if generated := generate(); generated.Metadata.Step != "primary" {
+		t.Fatalf("expected primary after recovery, got %+v", generated.Metadata)
+	}
+	if generated := generate(); generated.Metadata.Step != "primary" || primary.calls != 5 {
+		t.Fatalf("expected closed breaker, got step %s after %d calls", generated.Metadata.Step, primary.calls)
+	}
+}
+
+func TestFallbackChainReleasesTrialOnAbortedCall(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	primary := &scriptedGenerator{name: "primary", fail: upstreamFailure("primary")}
+	static := &scriptedGenerator{name: "static"}
+
+	chain, err := NewFallbackChainGenerator([]FallbackStep{
+		{Name: "primary", Generator: primary},
+		{Name: FallbackStepStatic, Generator: static},
+	}, nil, BreakerOptions{Threshold: 1, Cooldown: time.Minute}, clock)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+	if _, err := chain.Generate(context.Background(), api.GenerateRequest{}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	// Trial calls that end in a bad request or a cancelled context must not keep the
+	// breaker half-open forever.
+	clock.now = clock.now.Add(time.Minute)
+	primary.fail = fmt.Errorf("%w: category required", api.ErrBadRequest)
+	if _, err := chain.Generate(context.Background(), api.GenerateRequest{}); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	primary.fail = ctx.Err()
+	if _, err := chain.Generate(ctx, api.GenerateRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	primary.fail = nil
+	generated, err := chain.Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated.Metadata.Step != "primary" || primary.calls != 4 {
+		t.Fatalf("expected primary to get another trial call, got step %s after %d calls", generated.Metadata.Step, primary.calls)
+	}
+}
+
+func TestFallbackChainReportsLastErrorWhenExhausted(t *testing.T) {
+	chain, err := NewFallbackChainGenerator([]FallbackStep{
+		{Name: "a", Generator: &scriptedGenerator{fail: upstreamFailure("a")}},
+		{Name: "b", Generator: &scriptedGenerator{fail: upstreamFailure("b")}},
+	}, nil, BreakerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	_, err = chain.Generate(context.Background(), api.GenerateRequest{})
+	if !errors.Is(err, api.ErrUpstream) || !strings.Contains(err.Error(), "b unavailable") {
+		t.Fatalf("expected last upstream error, got %v", err)
+	}
+}
+
+func TestFallbackChainTriesCallerSelectionFirst(t *testing.T) {
+	llm := &scriptedGenerator{name: "llm"}
+	static := &scriptedGenerator{name: "static"}
+
+	steps, err := ParseFallbackChain("openai/gpt-4.1, anthropic, static", static, llm)
+	if err != nil {
+		t.Fatalf("parse chain: %v", err)
+	}
+	if len(steps) != 3 || steps[0].Name != "openai/gpt-4.1" || steps[1].Name != "anthropic" || steps[2].Name != FallbackStepStatic {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+
+	chain, err := NewFallbackChainGenerator(steps, llm, BreakerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	generated, err := chain.Generate(context.Background(), api.GenerateRequest{LLM: &api.LLMRequestOptions{Provider: "Anthropic"}})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated.Metadata.Step != "anthropic" || llm.calls != 1 || llm.last.LLM.Provider != "Anthropic" {
+		t.Fatalf("expected caller selection first, got step %q after %d calls", generated.Metadata.Step, llm.calls)
+	}
+}
+
+func TestFallbackChainBoundsBreakersForCallerSelections(t *testing.T) {
+	openai, err := NewLLMProblemGenerator(LLMOptions{APIKey: "key", Model: "gpt-4.1-mini"})
+	if err != nil {
+		t.Fatalf("create generator: %v", err)
+	}
+	router, err := NewLLMRouter(openai)
+	if err != nil {
+		t.Fatalf("create router: %v", err)
+	}
+	static := &scriptedGenerator{name: "static"}
+	chain, err := NewFallbackChainGenerator([]FallbackStep{{Name: FallbackStepStatic, Generator: static}}, router, BreakerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		req := api.GenerateRequest{LLM: &api.LLMRequestOptions{Provider: "openai", Model: fmt.Sprintf("gpt-unknown-%d", i)}}
+		if _, err := chain.Generate(context.Background(), req); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected unknown model to be rejected, got %v", err)
+		}
+	}
+	if len(chain.breakers) != 0 || static.calls != 0 {
+		t.Fatalf("expected rejected selections to create no breakers, got %d", len(chain.breakers))
+	}
+
+	steps, err := chain.stepsFor(api.GenerateRequest{LLM: &api.LLMRequestOptions{Provider: "OpenAI"}})
+	if err != nil {
+		t.Fatalf("steps: %v", err)
+	}
+	if steps[0].Name != "openai/gpt-4.1-mini" {
+		t.Fatalf("expected the selection to be named after its configured model, got %q", steps[0].Name)
+	}
+
+	// Without a catalog to check against, every unconfigured selection shares one breaker.
+	llm := &scriptedGenerator{name: "llm"}
+	chain, err = NewFallbackChainGenerator([]FallbackStep{{Name: FallbackStepStatic, Generator: static}}, llm, BreakerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		req := api.GenerateRequest{LLM: &api.LLMRequestOptions{Provider: "openai", Model: fmt.Sprintf("gpt-%d", i)}}
+		generated, err := chain.Generate(context.Background(), req)
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		if generated.Metadata.Step != FallbackStepRequested {
+			t.Fatalf("expected the shared requested step, got %q", generated.Metadata.Step)
+		}
+	}
+	if len(chain.breakers) != 1 {
+		t.Fatalf("expected one shared breaker, got %d", len(chain.breakers))
+	}
+}
+
+func TestParseFallbackChainRejectsInvalidEntries(t *testing.T) {
+	static := &scriptedGenerator{name: "static"}
+
+	if _, err := ParseFallbackChain("openai", static, nil); err == nil {
+		t.Fatalf("expected error for llm step without llm generator")
+	}
+	if _, err := ParseFallbackChain("/gpt-4.1", static, static); err == nil {
+		t.Fatalf("expected error for step without provider")
+	}
+	if _, err := ParseFallbackChain(" , ", static, static); err == nil {
+		t.Fatalf("expected error for empty chain")
+	}
+}
+
+func TestNewServicesValidatesFallbackChain(t *testing.T) {
+	options := ServicesOptions{
+		LLM:           LLMOptions{APIKey: "key", Model: "gpt-4.1-mini"},
+		FallbackChain: "openai/gpt-unknown,static",
+	}
+	if _, err := newServices(nil, options); err == nil || !strings.Contains(err.Error(), "gpt-unknown") {
+		t.Fatalf("expected unknown model in chain to be rejected, got %v", err)
+	}
+
+	options.FallbackChain = "openai/gpt-4.1-mini,static"
+	if _, err := newServices(nil, options); err != nil {
+		t.Fatalf("new services: %v", err)
+	}
+
+	if _, err := newServices(nil, ServicesOptions{FallbackChain: "static"}); err == nil {
+		t.Fatalf("expected error for chain without llm")
+	}
+}
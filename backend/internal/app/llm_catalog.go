@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+// LLMProviderConfig allows a single provider endpoint and the models that may be requested from it.
+type LLMProviderConfig struct {
+	Name    string   `json:"name"`
+	BaseURL string   `json:"baseUrl"`
+	Models  []string `json:"models"`
+}
+
+// LLMCatalog is the server-side allowlist of providers, base URLs and models. Per-request
+// overrides are only honoured when they resolve to an entry in the catalog, so the server's
+// API key is never sent to a caller-chosen host. The first provider and the first model of
+// each provider are the defaults.
+type LLMCatalog struct {
+	providers []LLMProviderConfig
+}
+
+// llmSelection is the resolved provider endpoint and model for one request.
+type llmSelection struct {
+	Provider string
+	BaseURL  string
+	Model    string
+}
+
+// NewLLMCatalog validates and normalises the supplied providers.
+func NewLLMCatalog(providers []LLMProviderConfig) (*LLMCatalog, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("llm catalog: at least one provider is required")
+	}
+
+	seen := make(map[string]struct{}, len(providers))
+	normalised := make([]LLMProviderConfig, 0, len(providers))
+	for _, provider := range providers {
+		name := strings.TrimSpace(provider.Name)
+		if name == "" {
+			return nil, errors.New("llm catalog: provider name is required")
+		}
+		key := strings.ToLower(name)
+		if _, dup := seen[key]; dup {
+			return nil, fmt.Errorf("llm catalog: duplicate provider %q", name)
+		}
+		seen[key] = struct{}{}
+
+		baseURL, err := normaliseBaseURL(provider.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("llm catalog: provider %q: %w", name, err)
+		}
+
+		models := uniqueStrings(splitCSV(strings.Join(provider.Models, ",")))
+		if len(models) == 0 {
+			return nil, fmt.Errorf("llm catalog: provider %q must allow at least one model", name)
+		}
+
+		normalised = append(normalised, LLMProviderConfig{Name: name, BaseURL: baseURL, Models: models})
+	}
+
+	return &LLMCatalog{providers: normalised}, nil
+}
+
+// ParseLLMCatalog decodes a JSON array of providers, as used by the LLM_CATALOG variable.
+func ParseLLMCatalog(raw string) (*LLMCatalog, error) {
+	var providers []LLMProviderConfig
+	if err := json.Unmarshal([]byte(raw), &providers); err != nil {
+		return nil, fmt.Errorf("llm catalog: decode: %w", err)
+	}
+	return NewLLMCatalog(providers)
+}
+
+// ListModels reports the allowed providers and models.
+func (c *LLMCatalog) ListModels(context.Context) ([]domain.LLMProvider, error) {
+	if c == nil {
+		return []domain.LLMProvider{}, nil
+	}
+
+	results := make([]domain.LLMProvider, len(c.providers))
+	for i, provider := range c.providers {
+		results[i] = domain.LLMProvider{
+			Name:         provider.Name,
+			BaseURL:      provider.BaseURL,
+			Models:       append([]string(nil), provider.Models...),
+			DefaultModel: provider.Models[0],
+			Default:      i == 0,
+		}
+	}
+	return results, nil
+}
+
+// resolve maps optional request overrides onto an allowed provider and model.
+func (c *LLMCatalog) resolve(overrides *api.LLMRequestOptions) (llmSelection, error) {
+	if c == nil || len(c.providers) == 0 {
+		return llmSelection{}, errors.New("llm catalog: no providers configured")
+	}
+
+	var requested api.LLMRequestOptions
+	if overrides != nil {
+		requested = *overrides
+	}
+	providerName := strings.TrimSpace(requested.Provider)
+	model := strings.TrimSpace(requested.Model)
+
+	var baseURL string
+	if raw := strings.TrimSpace(requested.BaseURL); raw != "" {
+		normalised, err := normaliseBaseURL(raw)
+		if err != nil {
+			return llmSelection{}, fmt.Errorf("%w: llm baseUrl %q is not allowed", api.ErrBadRequest, raw)
+		}
+		baseURL = normalised
+	}
+
+	provider, err := c.matchProvider(providerName, baseURL)
+	if err != nil {
+		return llmSelection{}, err
+	}
+
+	if model == "" {
+		model = provider.Models[0]
+	} else if !containsString(provider.Models, model) {
+		return llmSelection{}, fmt.Errorf("%w: llm model %q is not allowed for provider %q (allowed: %s)", api.ErrBadRequest, model, provider.Name, strings.Join(provider.Models, ", "))
+	}
+
+	return llmSelection{Provider: provider.Name, BaseURL: provider.BaseURL, Model: model}, nil
+}
+
+func (c *LLMCatalog) matchProvider(name, baseURL string) (LLMProviderConfig, error) {
+	switch {
+	case name == "" && baseURL == "":
+		return c.providers[0], nil
+	case name != "":
+		for _, provider := range c.providers {
+			if !strings.EqualFold(provider.Name, name) {
+				continue
+			}
+			if baseURL != "" && baseURL != provider.BaseURL {
+				return LLMProviderConfig{}, fmt.Errorf("%w: llm baseUrl %q is not allowed for provider %q", api.ErrBadRequest, baseURL, provider.Name)
+			}
+			return provider, nil
+		}
+		return LLMProviderConfig{}, fmt.Errorf("%w: llm provider %q is not allowed", api.ErrBadRequest, name)
+	default:
+		for _, provider := range c.providers {
+			if provider.BaseURL == baseURL {
+				return provider, nil
+			}
+		}
+		return LLMProviderConfig{}, fmt.Errorf("%w: llm baseUrl %q is not allowed", api.ErrBadRequest, baseURL)
+	}
+}
+
+// normaliseBaseURL requires an absolute http(s) URL without query or fragment and trims the
+// trailing slash so equivalent spellings compare equal.
+func normaliseBaseURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", raw, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", fmt.Errorf("base URL %q must use http or https", raw)
+	}
+	if parsed.Host == "" || parsed.User != nil || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q", raw)
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *LLMCatalog) hasProvider(name string) bool {
+	if c == nil {
+		return false
+	}
+	for _, provider := range c.providers {
+		if strings.EqualFold(provider.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *LLMCatalog) hasBaseURL(baseURL string) bool {
+	if c == nil {
+		return false
+	}
+	for _, provider := range c.providers {
+		if provider.BaseURL == baseURL {
+			return true
+		}
+	}
+	return false
+}
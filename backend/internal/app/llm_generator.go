@@ -15,6 +15,7 @@ import (
 	"improview/backend/internal/api"
 	"improview/backend/internal/domain"
 	"improview/backend/internal/jsonfmt"
+	"improview/backend/internal/sandbox"
 )
 
 var jsonValueSchema = map[string]any{
@@ -63,6 +64,7 @@ var problemPackJSONSchema = map[string]any{
 		"hint",
 		"solutions",
 		"tests",
+		"comparator",
 	},
 	"properties": map[string]any{
 		"problem": map[string]any{
@@ -138,8 +140,8 @@ var problemPackJSONSchema = map[string]any{
 		},
 		"time_estimate_minutes": map[string]any{
 			"type":    "integer",
-			"minimum": 1,
-			"maximum": 120,
+			"minimum": domain.MinTimeEstimateMins,
+			"maximum": domain.MaxTimeEstimateMins,
 		},
 		"hint": map[string]any{
 			"type": "string",
@@ -184,6 +186,23 @@ var problemPackJSONSchema = map[string]any{
 				},
 			},
 		},
+		"comparator": map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"mode", "epsilon", "checker"},
+			"properties": map[string]any{
+				"mode": map[string]any{
+					"type": "string",
+					"enum": domain.ComparatorModes,
+				},
+				"epsilon": map[string]any{
+					"type": []string{"number", "null"},
+				},
+				"checker": map[string]any{
+					"type": []string{"string", "null"},
+				},
+			},
+		},
 	},
 	"$defs": map[string]any{
 		"json_value": jsonValueSchema,
@@ -204,11 +223,10 @@ func newProblemPackResponseFormat() responseFormat {
 // LLMProblemGenerator talks to an LLM provider to create fresh problem packs.
 type LLMProblemGenerator struct {
 	client      *http.Client
-	baseURL     string
-	model       string
+	catalog     *LLMCatalog
 	apiKey      string
 	temperature float64
-	provider    string
+	retry       llmRetryPolicy
 }
 
 // NewLLMProblemGenerator constructs an LLM-backed problem generator instance.
@@ -218,94 +236,131 @@ func NewLLMProblemGenerator(opts LLMOptions) (*LLMProblemGenerator, error) {
 		return nil, errors.New("llm generator: missing API key")
 	}
 
-	base := strings.TrimRight(defaultString(opts.BaseURL, defaultLLMBaseURL), "/")
-	if base == "" {
-		return nil, errors.New("llm generator: missing base URL")
+	catalog, err := catalogFromOptions(opts, defaultLLMProvider, defaultLLMBaseURL, defaultLLMModel)
+	if err != nil {
+		return nil, fmt.Errorf("llm generator: %w", err)
+	}
+
+	return &LLMProblemGenerator{
+		client:      httpClientFromOptions(opts),
+		catalog:     catalog,
+		apiKey:      apiKey,
+		temperature: temperatureFromOptions(opts),
+		retry:       newLLMRetryPolicy(opts),
+	}, nil
+}
+
+// catalogFromOptions returns opts.Catalog or, when unset, a single-provider catalog built
+// from the options and the supplied defaults.
+func catalogFromOptions(opts LLMOptions, provider, baseURL, model string) (*LLMCatalog, error) {
+	if opts.Catalog != nil {
+		return opts.Catalog, nil
 	}
 
-	model := strings.TrimSpace(defaultString(opts.Model, defaultLLMModel))
-	if model == "" {
-		return nil, errors.New("llm generator: missing model")
+	base := strings.TrimRight(defaultString(opts.BaseURL, baseURL), "/")
+	if base == "" {
+		return nil, errors.New("missing base URL")
 	}
+	selected := strings.TrimSpace(defaultString(opts.Model, model))
+	if selected == "" {
+		return nil, errors.New("missing model")
+	}
+
+	return NewLLMCatalog([]LLMProviderConfig{{
+		Name:    defaultString(opts.Provider, provider),
+		BaseURL: base,
+		Models:  append([]string{selected}, opts.AllowedModels...),
+	}})
+}
 
+func httpClientFromOptions(opts LLMOptions) *http.Client {
 	timeout := opts.Timeout
 	if timeout <= 0 {
 		timeout = defaultLLMTimeout
 	}
 
-	temperature := opts.Temperature
-	if temperature == 0 {
-		temperature = 0.2
-	}
-
 	client := opts.HTTPClient
 	if client == nil {
 		client = &http.Client{Timeout: timeout}
 	} else if client.Timeout == 0 {
 		client.Timeout = timeout
 	}
+	return client
+}
 
-	return &LLMProblemGenerator{
-		client:      client,
-		baseURL:     base,
-		model:       model,
-		apiKey:      apiKey,
-		temperature: temperature,
-		provider:    strings.TrimSpace(opts.Provider),
-	}, nil
+func temperatureFromOptions(opts LLMOptions) float64 {
+	if opts.Temperature == 0 {
+		return 0.2
+	}
+	return opts.Temperature
+}
+
+// Catalog returns the providers and models this generator may be asked to use.
+func (g *LLMProblemGenerator) Catalog() *LLMCatalog {
+	if g == nil {
+		return nil
+	}
+	return g.catalog
 }
 
 // Generate issues a request to the LLM and maps the JSON response into a ProblemPack.
-func (g *LLMProblemGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.ProblemPack, error) {
+// Per-request provider, base URL and model overrides must resolve to a catalog entry.
+// Replies that fail to parse or validate are sent back to the model for repair.
+func (g *LLMProblemGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
 	if g == nil {
-		return domain.ProblemPack{}, api.ErrNotImplemented
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
 	}
 
-	baseURL := g.baseURL
-	model := g.model
-	provider := g.provider
-	if req.LLM != nil {
-		if trimmed := strings.TrimSpace(req.LLM.BaseURL); trimmed != "" {
-			baseURL = strings.TrimRight(trimmed, "/")
-		}
-		if trimmed := strings.TrimSpace(req.LLM.Model); trimmed != "" {
-			model = trimmed
-		}
-		if trimmed := strings.TrimSpace(req.LLM.Provider); trimmed != "" {
-			provider = trimmed
-		}
+	selection, err := g.catalog.resolve(req.LLM)
+	if err != nil {
+		return domain.GeneratedProblem{}, err
 	}
 
 	category := strings.TrimSpace(req.Category)
 	difficulty := strings.TrimSpace(req.Difficulty)
 	if category == "" || difficulty == "" {
-		return domain.ProblemPack{}, api.ErrBadRequest
+		return domain.GeneratedProblem{}, api.ErrBadRequest
 	}
 
+	messages := []chatMessage{
+		{Role: "system", Content: problemSystemPrompt(category, difficulty, selection.Provider)},
+		{Role: "user", Content: problemUserPrompt(category, difficulty, req.CustomPrompt, req.Language, req.Provider, selection.Provider)},
+	}
+
+	metadata := domain.GenerationMetadata{
+		Generator: string(GeneratorModeLLM),
+		Provider:  selection.Provider,
+		Model:     selection.Model,
+	}
+	pack, err := g.retry.generate(ctx, &metadata, messages, func(ctx context.Context, messages []chatMessage) (string, error) {
+		return g.complete(ctx, selection, messages)
+	})
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+
+	return domain.GeneratedProblem{Pack: pack, Metadata: metadata}, nil
+}
+
+// complete performs a single chat completion call and returns the reply text.
+func (g *LLMProblemGenerator) complete(ctx context.Context, selection llmSelection, messages []chatMessage) (string, error) {
 	payload := chatCompletionRequest{
-		Model:          model,
+		Model:          selection.Model,
 		ResponseFormat: newProblemPackResponseFormat(),
 		Temperature:    g.temperature,
-		Messages: []chatMessage{
-			{Role: "system", Content: g.systemPrompt(category, difficulty, provider)},
-			{Role: "user", Content: g.userPrompt(category, difficulty, req.CustomPrompt, req.Provider, provider)},
-		},
+		Messages:       messages,
 	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return domain.ProblemPack{}, fmt.Errorf("llm generator: marshal request: %w", err)
-	}
-
-	if baseURL == "" {
-		return domain.ProblemPack{}, errors.New("llm generator: missing base URL")
+		return "", fmt.Errorf("llm generator: marshal request: %w", err)
 	}
 
-	endpoint := baseURL + "/chat/completions"
+	endpoint := selection.BaseURL + "/chat/completions"
 	g.logf("POST %s payload=%s", endpoint, jsonfmt.FormatForLog(body, jsonfmt.DefaultLogLimit))
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return domain.ProblemPack{}, fmt.Errorf("llm generator: create request: %w", err)
+		return "", fmt.Errorf("llm generator: create request: %w", err)
 	}
 	httpReq.Header.Set("Authorization", "Bearer "+g.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -313,40 +368,37 @@ func (g *LLMProblemGenerator) Generate(ctx context.Context, req api.GenerateRequ
 
 	resp, err := g.client.Do(httpReq)
 	if err != nil {
-		return domain.ProblemPack{}, fmt.Errorf("llm generator: do request: %w", err)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", &upstreamError{Message: fmt.Sprintf("llm generator: do request: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return domain.ProblemPack{}, fmt.Errorf("llm generator: read response: %w", err)
+		return "", &upstreamError{Status: resp.StatusCode, Message: fmt.Sprintf("llm generator: read response: %v", err)}
 	}
 
 	g.logf("response status=%d body=%s", resp.StatusCode, jsonfmt.FormatForLog(respBody, jsonfmt.DefaultLogLimit))
 
 	if resp.StatusCode >= 400 {
-		return domain.ProblemPack{}, g.wrapHTTPError(resp.StatusCode, respBody)
+		return "", &upstreamError{
+			Status:     resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header),
+			Message:    g.wrapHTTPError(resp.StatusCode, respBody).Error(),
+		}
 	}
 
 	var completion chatCompletionResponse
 	if err := json.Unmarshal(respBody, &completion); err != nil {
-		return domain.ProblemPack{}, fmt.Errorf("llm generator: decode response: %w", err)
-	}
-
-	content := strings.TrimSpace(completion.Content())
-	if content == "" {
-		return domain.ProblemPack{}, errors.New("llm generator: empty completion content")
-	}
-
-	var pack domain.ProblemPack
-	if err := json.Unmarshal([]byte(content), &pack); err != nil {
-		return domain.ProblemPack{}, fmt.Errorf("llm generator: parse problem pack: %w", err)
+		return "", &upstreamError{Status: resp.StatusCode, Message: fmt.Sprintf("llm generator: decode response: %v", err)}
 	}
 
-	return pack, nil
+	return completion.Content(), nil
 }
 
-func (g *LLMProblemGenerator) systemPrompt(category, difficulty, provider string) string {
+func problemSystemPrompt(category, difficulty, provider string) string {
 	var providerLine string
 	if provider != "" {
 		providerLine = fmt.Sprintf("Provider: %s\n", provider)
@@ -364,14 +416,24 @@ Provide:
 - hint: short, actionable
 - tests: public[] and hidden[] with deterministic inputs and expected outputs
 - solutions: 1-2 idiomatic approaches with Big-O
+- comparator: how returned values are graded against test outputs
+  - exact: the output must match exactly (the default; use it whenever one answer is correct)
+  - unordered: the order of the top-level array does not matter (e.g. "return all pairs")
+  - unordered-nested: the order of arrays at every depth does not matter (e.g. groups of anagrams)
+  - float-epsilon: numbers may differ by epsilon (e.g. 1e-6); use for floating-point results
+  - any-of: several outputs are valid and each test output is an array listing all of them
+  - custom-checker: checker is JavaScript source defining function check(input, expected, actual) that returns true when actual is acceptable; use only when valid outputs cannot be enumerated
+  - set epsilon only for float-epsilon and checker only for custom-checker; otherwise null
 Rules:
 - Keep tests minimal but comprehensive; avoid randomness.
+- Every example and test input must have exactly one entry per api.params element.
+- public and hidden tests must be non-empty and must not repeat an input.
 - No external libs; pure functions only.
 - Ensure tests align with the signature exactly.
 - Prefer BFS/DFS/Two-Pointers/etc as per category.`, providerLine, category, difficulty)
 }
 
-func (g *LLMProblemGenerator) userPrompt(category, difficulty, customPrompt, providerOverride, defaultProvider string) string {
+func problemUserPrompt(category, difficulty, customPrompt, language, providerOverride, defaultProvider string) string {
 	lines := []string{
 		fmt.Sprintf("Generate a fresh problem pack for category \"%s\" at \"%s\" difficulty.", category, difficulty),
 	}
@@ -388,6 +450,11 @@ func (g *LLMProblemGenerator) userPrompt(category, difficulty, customPrompt, pro
 		lines = append(lines, "Additional guidance: "+extra)
 	}
 
+	if sandbox.NormalizeLanguage(language) == sandbox.LanguageGo {
+		lines = append(lines, "The candidate will solve this in Go, so the Go signature is derived from api.params[].type and api.returns.type. "+
+			"Write each as number (integers only), float, string, boolean, T[] or Record<string, T>, and keep every input and output consistent with those types (no null or mixed-type arrays).")
+	}
+
 	lines = append(lines, "Respond with JSON only — no explanations outside the JSON envelope.")
 	return strings.Join(lines, "\n\n")
 }
@@ -397,11 +464,15 @@ func (g *LLMProblemGenerator) wrapHTTPError(status int, body []byte) error {
 	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
 		return fmt.Errorf("llm generator: upstream %d %s: %s", status, apiErr.Error.Type, apiErr.Error.Message)
 	}
+	return fmt.Errorf("llm generator: upstream returned %d: %s", status, bodySnippet(body))
+}
+
+func bodySnippet(body []byte) string {
 	snippet := string(body)
 	if len(snippet) > 256 {
 		snippet = snippet[:256]
 	}
-	return fmt.Errorf("llm generator: upstream returned %d: %s", status, snippet)
+	return snippet
 }
 
 func (g *LLMProblemGenerator) logf(format string, args ...any) {
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -120,10 +122,14 @@ func TestLLMProblemGeneratorGenerate(t *testing.T) {
 		CustomPrompt: "Focus on connected components.",
 		Provider:     "Local Provider",
 	}
-	pack, err := generator.Generate(context.Background(), req)
+	generated, err := generator.Generate(context.Background(), req)
 	if err != nil {
 		t.Fatalf("generate problem pack: %v", err)
 	}
+	pack := generated.Pack
+	if generated.Metadata.Generator != "llm" || generated.Metadata.Model != "gpt-test" || generated.Metadata.Attempts != 1 {
+		t.Fatalf("unexpected metadata %+v", generated.Metadata)
+	}
 
 	if capturedAuth != "Bearer test-key" {
 		t.Fatalf("expected bearer auth header, got %q", capturedAuth)
@@ -193,9 +199,8 @@ func TestLLMProblemGeneratorHonoursRequestOverrides(t *testing.T) {
 
 	var capture captured
 
-	samplePack := domain.ProblemPack{
-		Problem: domain.ProblemMetadata{Title: "Override Pack"},
-	}
+	samplePack := validLLMPack()
+	samplePack.Problem.Title = "Override Pack"
 	sampleJSON, err := json.Marshal(samplePack)
 	if err != nil {
 		t.Fatalf("marshal sample pack: %v", err)
@@ -235,11 +240,17 @@ func TestLLMProblemGeneratorHonoursRequestOverrides(t *testing.T) {
 		}),
 	}
 
+	catalog, err := NewLLMCatalog([]LLMProviderConfig{
+		{Name: "Default Provider", BaseURL: "http://llm.local", Models: []string{"gpt-test"}},
+		{Name: "Override Provider", BaseURL: "http://override.local/v1", Models: []string{"gpt-override", "gpt-other"}},
+	})
+	if err != nil {
+		t.Fatalf("create catalog: %v", err)
+	}
+
 	generator, err := NewLLMProblemGenerator(LLMOptions{
 		APIKey:     "test-key",
-		BaseURL:    "http://llm.local",
-		Model:      "gpt-test",
-		Provider:   "Default Provider",
+		Catalog:    catalog,
 		HTTPClient: client,
 	})
 	if err != nil {
@@ -275,6 +286,91 @@ func TestLLMProblemGeneratorHonoursRequestOverrides(t *testing.T) {
 	}
 }
 
+func TestLLMProblemGeneratorRejectsOverridesOutsideCatalog(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			t.Fatalf("unexpected HTTP call to %s", req.URL)
+			return nil, nil
+		}),
+	}
+
+	generator, err := NewLLMProblemGenerator(LLMOptions{
+		APIKey:        "test-key",
+		BaseURL:       "https://api.openai.com/v1",
+		Model:         "gpt-4.1-mini",
+		AllowedModels: []string{"gpt-4.1"},
+		HTTPClient:    client,
+	})
+	if err != nil {
+		t.Fatalf("create llm generator: %v", err)
+	}
+
+	cases := map[string]api.LLMRequestOptions{
+		"foreign base url":         {BaseURL: "https://attacker.example/v1"},
+		"lookalike base url":       {BaseURL: "https://api.openai.com.attacker.example/v1"},
+		"non-http base url":        {BaseURL: "file:///etc/passwd"},
+		"unlisted model":           {Model: "gpt-4o"},
+		"unknown provider":         {Provider: "anthropic"},
+		"provider/base url clash":  {Provider: "openai", BaseURL: "https://attacker.example/v1"},
+		"base url with userinfo":   {BaseURL: "https://user@api.openai.com/v1"},
+		"base url with query args": {BaseURL: "https://api.openai.com/v1?x=1"},
+	}
+
+	for name, overrides := range cases {
+		overrides := overrides
+		t.Run(name, func(t *testing.T) {
+			_, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy", LLM: &overrides})
+			if !errors.Is(err, api.ErrBadRequest) {
+				t.Fatalf("expected bad request, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLLMCatalogResolvesAllowedOverrides(t *testing.T) {
+	catalog, err := ParseLLMCatalog(`[
+		{"name": "openai", "baseUrl": "https://api.openai.com/v1/", "models": ["gpt-4.1-mini", "gpt-4.1"]},
+		{"name": "gateway", "baseUrl": "https://llm.internal.example/v1", "models": ["mixtral"]}
+	]`)
+	if err != nil {
+		t.Fatalf("parse catalog: %v", err)
+	}
+
+	cases := []struct {
+		overrides *api.LLMRequestOptions
+		want      llmSelection
+	}{
+		{overrides: nil, want: llmSelection{Provider: "openai", BaseURL: "https://api.openai.com/v1", Model: "gpt-4.1-mini"}},
+		{overrides: &api.LLMRequestOptions{Model: "gpt-4.1"}, want: llmSelection{Provider: "openai", BaseURL: "https://api.openai.com/v1", Model: "gpt-4.1"}},
+		{overrides: &api.LLMRequestOptions{Provider: "Gateway"}, want: llmSelection{Provider: "gateway", BaseURL: "https://llm.internal.example/v1", Model: "mixtral"}},
+		{overrides: &api.LLMRequestOptions{BaseURL: "https://LLM.internal.example/v1/"}, want: llmSelection{Provider: "gateway", BaseURL: "https://llm.internal.example/v1", Model: "mixtral"}},
+	}
+	for _, tc := range cases {
+		got, err := catalog.resolve(tc.overrides)
+		if err != nil {
+			t.Fatalf("resolve %+v: %v", tc.overrides, err)
+		}
+		if got != tc.want {
+			t.Fatalf("resolve %+v: expected %+v, got %+v", tc.overrides, tc.want, got)
+		}
+	}
+
+	providers, err := catalog.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list models: %v", err)
+	}
+	if len(providers) != 2 || !providers[0].Default || providers[1].Default || providers[1].DefaultModel != "mixtral" {
+		t.Fatalf("unexpected providers %+v", providers)
+	}
+
+	if _, err := ParseLLMCatalog(`[{"name": "bad", "baseUrl": "ftp://example.com", "models": ["m"]}]`); err == nil {
+		t.Fatalf("expected error for non-http base url")
+	}
+	if _, err := ParseLLMCatalog(`[{"name": "empty", "baseUrl": "https://example.com", "models": []}]`); err == nil {
+		t.Fatalf("expected error for provider without models")
+	}
+}
+
 func TestLLMProblemGeneratorHandlesUpstreamErrors(t *testing.T) {
 	errorPayload := []byte(`{"error":{"type":"insufficient_quota","message":"You have used your credit allowance."}}`)
 
@@ -294,6 +390,7 @@ func TestLLMProblemGeneratorHandlesUpstreamErrors(t *testing.T) {
 		BaseURL:    "http://llm.local",
 		Model:      "gpt-test",
 		HTTPClient: client,
+		MaxRetries: -1,
 	})
 	if err != nil {
 		t.Fatalf("create llm generator: %v", err)
@@ -303,6 +400,9 @@ func TestLLMProblemGeneratorHandlesUpstreamErrors(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected error from upstream failure")
 	}
+	if !errors.Is(err, api.ErrUpstream) {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
 	if !strings.Contains(err.Error(), "insufficient_quota") {
 		t.Fatalf("expected quota message in error, got %v", err)
 	}
@@ -331,3 +431,33 @@ func TestLLMProblemGeneratorValidatesRequest(t *testing.T) {
 		t.Fatalf("expected bad request error for missing category, got %v", err)
 	}
 }
+
+func TestProblemUserPromptAsksForGoFriendlyTypes(t *testing.T) {
+	if prompt := problemUserPrompt("arrays", "easy", "", "", "", ""); strings.Contains(prompt, "Go") {
+		t.Fatalf("expected no language guidance by default: %s", prompt)
+	}
+	if prompt := problemUserPrompt("arrays", "easy", "", "golang", "", ""); !strings.Contains(prompt, "solve this in Go") || !strings.Contains(prompt, "number (integers only), float") {
+		t.Fatalf("expected Go type guidance: %s", prompt)
+	}
+}
+
+func TestProblemPackSchemaLetsGeneratorsDeclareAComparator(t *testing.T) {
+	required, _ := problemPackJSONSchema["required"].([]string)
+	if !slices.Contains(required, "comparator") {
+		t.Fatalf("expected comparator to be required by the strict schema, got %v", required)
+	}
+
+	reply := strings.Replace(mustJSON(t, validLLMPack()), `"tests":`, `"comparator":{"mode":"unordered","epsilon":null,"checker":null},"tests":`, 1)
+	pack, err := parseProblemPack(reply)
+	if err != nil {
+		t.Fatalf("parse pack with comparator: %v", err)
+	}
+	if pack.Comparator == nil || pack.Comparator.Mode != domain.ComparatorUnordered || pack.Comparator.Epsilon != 0 || pack.Comparator.Checker != "" {
+		t.Fatalf("unexpected comparator %+v", pack.Comparator)
+	}
+
+	invalid := strings.Replace(reply, `"unordered"`, `"sorted"`, 1)
+	if _, err := parseProblemPack(invalid); err == nil || !strings.Contains(err.Error(), `comparator.mode "sorted"`) {
+		t.Fatalf("expected unknown comparator mode to be rejected, got %v", err)
+	}
+}
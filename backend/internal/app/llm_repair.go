@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+const (
+	defaultLLMMaxAttempts   = 3
+	defaultLLMMaxRetries    = 3
+	defaultLLMRetryBaseWait = 500 * time.Millisecond
+	defaultLLMRetryMaxWait  = 8 * time.Second
+)
+
+// upstreamError describes a failed call to an LLM provider. It unwraps to api.ErrUpstream.
+type upstreamError struct {
+	Status     int
+	RetryAfter time.Duration
+	Message    string
+}
+
+func (e *upstreamError) Error() string { return e.Message }
+
+func (e *upstreamError) Unwrap() error { return api.ErrUpstream }
+
+// retryable reports whether the call may succeed if repeated: rate limits, server errors
+// and transport failures (status 0).
+func (e *upstreamError) retryable() bool {
+	return e.Status == 0 || e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// llmCompleteFunc sends the conversation to a provider and returns the text of the reply.
+type llmCompleteFunc func(ctx context.Context, messages []chatMessage) (string, error)
+
+// llmRetryPolicy drives the self-repair loop and the backoff for transient upstream errors.
+type llmRetryPolicy struct {
+	MaxAttempts int
+	MaxRetries  int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	sleep  func(context.Context, time.Duration) error
+	jitter func(time.Duration) time.Duration
+}
+
+func newLLMRetryPolicy(opts LLMOptions) llmRetryPolicy {
+	policy := llmRetryPolicy{
+		MaxAttempts: opts.MaxAttempts,
+		MaxRetries:  opts.MaxRetries,
+		BaseDelay:   opts.RetryBaseDelay,
+		MaxDelay:    defaultLLMRetryMaxWait,
+		sleep:       sleepContext,
+		jitter:      equalJitter,
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultLLMMaxAttempts
+	}
+	if policy.MaxRetries < 0 {
+		policy.MaxRetries = 0
+	} else if policy.MaxRetries == 0 {
+		policy.MaxRetries = defaultLLMMaxRetries
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultLLMRetryBaseWait
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+	return policy
+}
+
+// generate asks for a pack and, while the reply fails to parse or validate, feeds the exact
+// issues back as a follow-up turn. Every upstream call is recorded in meta.
+func (p llmRetryPolicy) generate(ctx context.Context, meta *domain.GenerationMetadata, messages []chatMessage, complete llmCompleteFunc) (domain.ProblemPack, error) {
+	start := time.Now()
+	defer func() { meta.LatencyMS = time.Since(start).Milliseconds() }()
+
+	conversation := append([]chatMessage(nil), messages...)
+	kind := domain.GenerationKindGenerate
+	var lastErr error
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		content, latency, err := p.completeWithRetry(ctx, meta, attempt, kind, conversation, complete)
+		if err != nil {
+			return domain.ProblemPack{}, err
+		}
+
+		pack, err := parseProblemPack(content)
+		record := domain.GenerationAttempt{Attempt: attempt, Kind: kind, HTTPStatus: http.StatusOK, LatencyMS: latency.Milliseconds()}
+		if err == nil {
+			meta.RecordAttempt(record)
+			return pack, nil
+		}
+		record.Error = err.Error()
+		meta.RecordAttempt(record)
+		lastErr = err
+
+		conversation = append(conversation,
+			chatMessage{Role: "assistant", Content: content},
+			chatMessage{Role: "user", Content: repairPrompt(err)},
+		)
+		kind = domain.GenerationKindRepair
+	}
+
+	return domain.ProblemPack{}, fmt.Errorf("%w: llm generator: no valid problem pack after %d attempts: %v", api.ErrUpstream, p.MaxAttempts, lastErr)
+}
+
+// completeWithRetry repeats a call on retryable upstream errors with exponential backoff and
+// jitter, honouring Retry-After when the provider sends one.
+func (p llmRetryPolicy) completeWithRetry(ctx context.Context, meta *domain.GenerationMetadata, attempt int, kind string, messages []chatMessage, complete llmCompleteFunc) (string, time.Duration, error) {
+	for retry := 0; ; retry++ {
+		callStart := time.Now()
+		content, err := complete(ctx, messages)
+		latency := time.Since(callStart)
+		if err == nil {
+			return content, latency, nil
+		}
+
+		var upstream *upstreamError
+		if !errors.As(err, &upstream) {
+			return "", latency, err
+		}
+		meta.RecordAttempt(domain.GenerationAttempt{
+			Attempt:    attempt,
+			Kind:       kind,
+			HTTPStatus: upstream.Status,
+			Error:      upstream.Error(),
+			LatencyMS:  latency.Milliseconds(),
+		})
+		if !upstream.retryable() || retry >= p.MaxRetries || ctx.Err() != nil {
+			return "", latency, err
+		}
+
+		if err := p.sleep(ctx, p.backoff(retry, upstream.RetryAfter)); err != nil {
+			return "", latency, err
+		}
+		kind = domain.GenerationKindRetry
+	}
+}
+
+func (p llmRetryPolicy) backoff(retry int, retryAfter time.Duration) time.Duration {
+	delay := p.BaseDelay << retry
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	delay = p.jitter(delay)
+	if retryAfter > delay {
+		delay = min(retryAfter, p.MaxDelay)
+	}
+	return delay
+}
+
+// equalJitter keeps half of d and randomises the rest, picking a delay in [d/2, d] so
+// concurrent callers spread out without retrying much sooner than the backoff asks.
+func equalJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(header http.Header) time.Duration {
+	raw := strings.TrimSpace(header.Get("Retry-After"))
+	if raw == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// parseProblemPack decodes and validates the model's reply.
+func parseProblemPack(content string) (domain.ProblemPack, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return domain.ProblemPack{}, errors.New("empty completion content")
+	}
+
+	var pack domain.ProblemPack
+	if err := json.Unmarshal([]byte(content), &pack); err != nil {
+		return domain.ProblemPack{}, fmt.Errorf("parse problem pack: %w", err)
+	}
+	if err := domain.ValidateProblemPack(pack); err != nil {
+		return domain.ProblemPack{}, err
+	}
+	return pack, nil
+}
+
+func repairPrompt(err error) string {
+	var issues []string
+	var validationErr *domain.PackValidationError
+	if errors.As(err, &validationErr) {
+		issues = validationErr.Issues
+	} else {
+		issues = []string{err.Error()}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Your previous response was not a valid problem pack. Fix these issues:\n")
+	for _, issue := range issues {
+		builder.WriteString("- ")
+		builder.WriteString(issue)
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\nReturn the complete corrected problem pack as JSON matching the schema — no explanations outside the JSON envelope.")
+	return builder.String()
+}
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+func validLLMPack() domain.ProblemPack {
+	return domain.ProblemPack{
+		Problem: domain.ProblemMetadata{
+			Title:     "Count Islands",
+			Statement: "Count connected groups of 1s in a grid.",
+			Examples:  []domain.Example{{Input: []any{[][]int{{1, 0}, {0, 1}}}, Output: 2}},
+		},
+		API: domain.APISignature{
+			FunctionName: "countIslands",
+			Signature:    "function countIslands(grid)",
+			Params:       []domain.APIParam{{Name: "grid", Type: "number[][]", Desc: "Binary grid"}},
+			Returns:      domain.APIParamReturn{Type: "number", Desc: "Island count"},
+		},
+		TimeEstimateMins: 25,
+		Hint:             "Flood fill from every unvisited land cell.",
+		Solutions: []domain.SolutionOutline{
+			{Approach: "DFS", Complexity: domain.Complexity{Time: "O(n*m)", Space: "O(n*m)"}, Code: "function countIslands(grid) { return 0; }"},
+		},
+		Tests: domain.TestSuite{
+			Public: []domain.Example{{Input: []any{[][]int{{1}}}, Output: 1}},
+			Hidden: []domain.Example{{Input: []any{[][]int{{0}}}, Output: 0}},
+		},
+	}
+}
+
+// scriptedLLM replies to successive chat completion calls with the given responses and
+// records each request it receives.
+type scriptedLLM struct {
+	t         *testing.T
+	responses []scriptedResponse
+	requests  []chatCompletionRequest
+}
+
+type scriptedResponse struct {
+	status     int
+	content    string
+	retryAfter string
+}
+
+func (s *scriptedLLM) client() *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var decoded chatCompletionRequest
+		if err := json.NewDecoder(req.Body).Decode(&decoded); err != nil {
+			s.t.Fatalf("decode request: %v", err)
+		}
+		s.requests = append(s.requests, decoded)
+
+		if len(s.responses) == 0 {
+			s.t.Fatalf("unexpected extra call %d", len(s.requests))
+		}
+		next := s.responses[0]
+		s.responses = s.responses[1:]
+
+		header := http.Header{"Content-Type": []string{"application/json"}}
+		if next.retryAfter != "" {
+			header.Set("Retry-After", next.retryAfter)
+		}
+		var body []byte
+		if next.status >= 400 {
+			body = []byte(`{"error":{"type":"server_error","message":"try again"}}`)
+		} else {
+			encoded, err := json.Marshal(chatCompletionResponse{Choices: []struct {
+				Message openAIMessage `json:"message"`
+			}{{Message: openAIMessage{Content: next.content}}}})
+			if err != nil {
+				s.t.Fatalf("encode response: %v", err)
+			}
+			body = encoded
+		}
+		return &http.Response{StatusCode: next.status, Header: header, Body: io.NopCloser(bytes.NewReader(body))}, nil
+	})}
+}
+
+func newScriptedGenerator(t *testing.T, script *scriptedLLM, opts LLMOptions) (*LLMProblemGenerator, *[]time.Duration) {
+	t.Helper()
+
+	opts.APIKey = "test-key"
+	opts.BaseURL = "http://llm.local"
+	opts.Model = "gpt-test"
+	opts.HTTPClient = script.client()
+	generator, err := NewLLMProblemGenerator(opts)
+	if err != nil {
+		t.Fatalf("create llm generator: %v", err)
+	}
+
+	var sleeps []time.Duration
+	generator.retry.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	generator.retry.jitter = func(d time.Duration) time.Duration { return d }
+	return generator, &sleeps
+}
+
+func mustJSON(t *testing.T, value any) string {
+	t.Helper()
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(encoded)
+}
+
+func TestLLMProblemGeneratorRepairsInvalidPacks(t *testing.T) {
+	broken := validLLMPack()
+	broken.TimeEstimateMins = 500
+	broken.Tests.Hidden = nil
+
+	script := &scriptedLLM{t: t, responses: []scriptedResponse{
+		{status: http.StatusOK, content: "not json"},
+		{status: http.StatusOK, content: mustJSON(t, broken)},
+		{status: http.StatusOK, content: mustJSON(t, validLLMPack())},
+	}}
+	generator, _ := newScriptedGenerator(t, script, LLMOptions{MaxAttempts: 3})
+
+	generated, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated.Pack.Problem.Title != "Count Islands" {
+		t.Fatalf("unexpected pack %q", generated.Pack.Problem.Title)
+	}
+
+	meta := generated.Metadata
+	if meta.Attempts != 3 || len(meta.History) != 3 || len(meta.Errors) != 2 {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if meta.History[0].Kind != domain.GenerationKindGenerate || meta.History[1].Kind != domain.GenerationKindRepair {
+		t.Fatalf("unexpected attempt kinds %+v", meta.History)
+	}
+	if !strings.Contains(meta.Errors[0], "parse problem pack") {
+		t.Fatalf("expected parse error first, got %q", meta.Errors[0])
+	}
+
+	// The final request carries the whole conversation, including the exact issues found.
+	last := script.requests[2].Messages
+	if len(last) != 6 {
+		t.Fatalf("expected 6 messages in final request, got %d", len(last))
+	}
+	if last[4].Role != "assistant" || last[4].Content != mustJSON(t, broken) {
+		t.Fatalf("expected previous reply echoed as assistant turn, got %+v", last[4])
+	}
+	repair := last[5].Content
+	if !strings.Contains(repair, "time_estimate_minutes 500 is outside [10,120]") || !strings.Contains(repair, "tests.hidden is empty") {
+		t.Fatalf("expected repair prompt to list validation issues, got %q", repair)
+	}
+}
+
+func TestLLMProblemGeneratorGivesUpAfterMaxAttempts(t *testing.T) {
+	script := &scriptedLLM{t: t, responses: []scriptedResponse{
+		{status: http.StatusOK, content: "{}"},
+		{status: http.StatusOK, content: "{}"},
+	}}
+	generator, _ := newScriptedGenerator(t, script, LLMOptions{MaxAttempts: 2})
+
+	_, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy"})
+	if !errors.Is(err, api.ErrUpstream) {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Fatalf("expected attempt count in error, got %v", err)
+	}
+}
+
+func TestLLMProblemGeneratorRetriesTransientErrors(t *testing.T) {
+	script := &scriptedLLM{t: t, responses: []scriptedResponse{
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusTooManyRequests, retryAfter: "3"},
+		{status: http.StatusOK, content: mustJSON(t, validLLMPack())},
+	}}
+	generator, sleeps := newScriptedGenerator(t, script, LLMOptions{MaxRetries: 3, RetryBaseDelay: 100 * time.Millisecond})
+
+	generated, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if want := []time.Duration{100 * time.Millisecond, 3 * time.Second}; len(*sleeps) != 2 || (*sleeps)[0] != want[0] || (*sleeps)[1] != want[1] {
+		t.Fatalf("expected backoff %v, got %v", want, *sleeps)
+	}
+
+	meta := generated.Metadata
+	if meta.Attempts != 1 || len(meta.History) != 3 {
+		t.Fatalf("expected one attempt with two retries, got %+v", meta)
+	}
+	if meta.History[0].HTTPStatus != http.StatusServiceUnavailable || meta.History[1].Kind != domain.GenerationKindRetry || meta.History[2].Error != "" {
+		t.Fatalf("unexpected history %+v", meta.History)
+	}
+}
+
+func TestLLMProblemGeneratorDoesNotRetryClientErrors(t *testing.T) {
+	script := &scriptedLLM{t: t, responses: []scriptedResponse{{status: http.StatusUnauthorized}}}
+	generator, sleeps := newScriptedGenerator(t, script, LLMOptions{})
+
+	_, err := generator.Generate(context.Background(), api.GenerateRequest{Category: "graphs", Difficulty: "easy"})
+	if !errors.Is(err, api.ErrUpstream) {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+	if len(*sleeps) != 0 || len(script.requests) != 1 {
+		t.Fatalf("expected no retries, got %d sleeps and %d requests", len(*sleeps), len(script.requests))
+	}
+}
+
+func TestLLMRetryPolicyBackoffIsCapped(t *testing.T) {
+	policy := newLLMRetryPolicy(LLMOptions{RetryBaseDelay: time.Second})
+	policy.jitter = func(d time.Duration) time.Duration { return d }
+
+	if got := policy.backoff(10, 0); got != defaultLLMRetryMaxWait {
+		t.Fatalf("expected capped delay %s, got %s", defaultLLMRetryMaxWait, got)
+	}
+	if got := policy.backoff(0, time.Minute); got != defaultLLMRetryMaxWait {
+		t.Fatalf("expected Retry-After to be capped, got %s", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := equalJitter(time.Second); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("jitter out of range: %s", got)
+		}
+	}
+}
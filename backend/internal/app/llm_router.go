@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+// CatalogedGenerator is an LLM-backed generator that reports the providers it serves.
+type CatalogedGenerator interface {
+	api.ProblemGenerator
+	Catalog() *LLMCatalog
+}
+
+// LLMRouter dispatches generation requests to the LLM generator that serves the requested
+// provider. The provider is taken from GenerateRequest.LLM.Provider, then from
+// GenerateRequest.Provider when it names a configured provider, then from the base URL
+// override; otherwise the first generator is used.
+type LLMRouter struct {
+	generators []CatalogedGenerator
+}
+
+// NewLLMRouter routes across generators whose catalogs must not share provider names.
+func NewLLMRouter(generators ...CatalogedGenerator) (*LLMRouter, error) {
+	if len(generators) == 0 {
+		return nil, errors.New("llm router: at least one generator is required")
+	}
+
+	seen := make(map[string]struct{})
+	for _, generator := range generators {
+		catalog := generator.Catalog()
+		if catalog == nil {
+			return nil, errors.New("llm router: generator has no catalog")
+		}
+		for _, provider := range catalog.providers {
+			key := strings.ToLower(provider.Name)
+			if _, dup := seen[key]; dup {
+				return nil, fmt.Errorf("llm router: provider %q is configured twice", provider.Name)
+			}
+			seen[key] = struct{}{}
+		}
+	}
+
+	return &LLMRouter{generators: generators}, nil
+}
+
+// Generate forwards the request to the generator serving the selected provider.
+func (r *LLMRouter) Generate(ctx context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
+	if r == nil || len(r.generators) == 0 {
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
+	}
+
+	var overrides api.LLMRequestOptions
+	if req.LLM != nil {
+		overrides = *req.LLM
+	}
+	if strings.TrimSpace(overrides.Provider) == "" && r.serves(req.Provider) {
+		overrides.Provider = strings.TrimSpace(req.Provider)
+		req.LLM = &overrides
+	}
+
+	return r.route(overrides).Generate(ctx, req)
+}
+
+// ListModels merges the catalogs of every generator. Only the first provider is the default.
+func (r *LLMRouter) ListModels(ctx context.Context) ([]domain.LLMProvider, error) {
+	results := []domain.LLMProvider{}
+	if r == nil {
+		return results, nil
+	}
+	for _, generator := range r.generators {
+		providers, err := generator.Catalog().ListModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, provider := range providers {
+			provider.Default = len(results) == 0
+			results = append(results, provider)
+		}
+	}
+	return results, nil
+}
+
+// checkSelection reports whether overrides resolve to an allowed provider and model.
+func (r *LLMRouter) checkSelection(overrides api.LLMRequestOptions) error {
+	_, err := r.resolveSelection(overrides)
+	return err
+}
+
+// resolveSelection maps overrides to the provider and model they would be generated with.
+func (r *LLMRouter) resolveSelection(overrides api.LLMRequestOptions) (llmSelection, error) {
+	return r.route(overrides).Catalog().resolve(&overrides)
+}
+
+func (r *LLMRouter) route(overrides api.LLMRequestOptions) CatalogedGenerator {
+	if name := strings.TrimSpace(overrides.Provider); name != "" {
+		for _, generator := range r.generators {
+			if generator.Catalog().hasProvider(name) {
+				return generator
+			}
+		}
+		// Let the default generator reject the unknown provider.
+		return r.generators[0]
+	}
+	if raw := strings.TrimSpace(overrides.BaseURL); raw != "" {
+		if baseURL, err := normaliseBaseURL(raw); err == nil {
+			for _, generator := range r.generators {
+				if generator.Catalog().hasBaseURL(baseURL) {
+					return generator
+				}
+			}
+		}
+	}
+	return r.generators[0]
+}
+
+func (r *LLMRouter) serves(provider string) bool {
+	name := strings.TrimSpace(provider)
+	if name == "" {
+		return false
+	}
+	for _, generator := range r.generators {
+		if generator.Catalog().hasProvider(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+type catalogedStub struct {
+	name    string
+	catalog *LLMCatalog
+	seen    *api.GenerateRequest
+}
+
+func (s *catalogedStub) Generate(_ context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
+	s.seen = &req
+	return domain.GeneratedProblem{Metadata: domain.GenerationMetadata{Generator: s.name}}, nil
+}
+
+func (s *catalogedStub) Catalog() *LLMCatalog { return s.catalog }
+
+func newCatalogedStub(t *testing.T, name string, providers ...LLMProviderConfig) *catalogedStub {
+	t.Helper()
+	catalog, err := NewLLMCatalog(providers)
+	if err != nil {
+		t.Fatalf("create catalog: %v", err)
+	}
+	return &catalogedStub{name: name, catalog: catalog}
+}
+
+func TestLLMRouterSelectsGeneratorByProvider(t *testing.T) {
+	openai := newCatalogedStub(t, "openai", LLMProviderConfig{Name: "openai", BaseURL: "https://api.openai.com/v1", Models: []string{"gpt-4.1-mini"}})
+	anthropic := newCatalogedStub(t, "anthropic", LLMProviderConfig{Name: "anthropic", BaseURL: "https://api.anthropic.com/v1", Models: []string{"claude-test"}})
+
+	router, err := NewLLMRouter(openai, anthropic)
+	if err != nil {
+		t.Fatalf("create router: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		req  api.GenerateRequest
+		want string
+	}{
+		{name: "default", req: api.GenerateRequest{}, want: "openai"},
+		{name: "llm provider", req: api.GenerateRequest{LLM: &api.LLMRequestOptions{Provider: "Anthropic"}}, want: "anthropic"},
+		{name: "request provider", req: api.GenerateRequest{Provider: "anthropic"}, want: "anthropic"},
+		{name: "llm provider wins", req: api.GenerateRequest{Provider: "anthropic", LLM: &api.LLMRequestOptions{Provider: "openai"}}, want: "openai"},
+		{name: "base url", req: api.GenerateRequest{LLM: &api.LLMRequestOptions{BaseURL: "https://api.anthropic.com/v1/"}}, want: "anthropic"},
+		{name: "unknown hint", req: api.GenerateRequest{Provider: "Local Provider"}, want: "openai"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			generated, err := router.Generate(context.Background(), tc.req)
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			if generated.Metadata.Generator != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, generated.Metadata.Generator)
+			}
+		})
+	}
+
+	if _, err := router.Generate(context.Background(), api.GenerateRequest{Provider: "anthropic"}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if anthropic.seen.LLM == nil || anthropic.seen.LLM.Provider != "anthropic" {
+		t.Fatalf("expected request provider to become the llm override, got %+v", anthropic.seen.LLM)
+	}
+
+	providers, err := router.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list models: %v", err)
+	}
+	if len(providers) != 2 || !providers[0].Default || providers[1].Default || providers[1].Name != "anthropic" {
+		t.Fatalf("unexpected providers %+v", providers)
+	}
+}
+
+func TestLLMRouterRejectsDuplicateProviders(t *testing.T) {
+	first := newCatalogedStub(t, "a", LLMProviderConfig{Name: "shared", BaseURL: "https://a.example", Models: []string{"m"}})
+	second := newCatalogedStub(t, "b", LLMProviderConfig{Name: "Shared", BaseURL: "https://b.example", Models: []string{"m"}})
+
+	if _, err := NewLLMRouter(first, second); err == nil {
+		t.Fatalf("expected duplicate provider error")
+	}
+	if _, err := NewLLMRouter(); err == nil {
+		t.Fatalf("expected error for empty router")
+	}
+}
@@ -0,0 +1,13 @@
+package app
+
+import (
+	"os"
+	"testing"
+
+	"improview/backend/internal/sandbox"
+)
+
+func TestMain(m *testing.M) {
+	sandbox.Init()
+	os.Exit(m.Run())
+}
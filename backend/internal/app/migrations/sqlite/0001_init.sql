@@ -0,0 +1,109 @@
+-- Initial schema. Rows mirror the DynamoDB items so every backend shares the same
+-- ordering rules: saved problems page by descending id, attempt snapshots sort by
+-- sort_key (zero-padded millisecond timestamp, then attempt id) and runs by id.
+
+CREATE TABLE problems (
+    id          TEXT PRIMARY KEY,
+    title       TEXT NOT NULL DEFAULT '',
+    category    TEXT NOT NULL DEFAULT '',
+    difficulty  TEXT NOT NULL DEFAULT '',
+    generator   TEXT NOT NULL DEFAULT '',
+    provider    TEXT NOT NULL DEFAULT '',
+    model       TEXT NOT NULL DEFAULT '',
+    prompt_hash TEXT NOT NULL DEFAULT '',
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  INTEGER NOT NULL,
+    pack        TEXT NOT NULL,
+    generation  TEXT NOT NULL
+);
+
+CREATE INDEX problems_prompt_hash ON problems (prompt_hash);
+
+CREATE TABLE attempts (
+    id                  TEXT PRIMARY KEY,
+    problem_id          TEXT NOT NULL,
+    user_id             TEXT NOT NULL,
+    lang                TEXT NOT NULL DEFAULT '',
+    started_at          INTEGER NOT NULL,
+    ended_at            INTEGER NOT NULL DEFAULT 0,
+    duration_ms         INTEGER NOT NULL DEFAULT 0,
+    hint_used           INTEGER NOT NULL DEFAULT 0,
+    solutions_unlocked  INTEGER NOT NULL DEFAULT 0,
+    pass_count          INTEGER NOT NULL DEFAULT 0,
+    fail_count          INTEGER NOT NULL DEFAULT 0,
+    last_run_id         TEXT NOT NULL DEFAULT '',
+    lifetime_runs       INTEGER NOT NULL DEFAULT 0,
+    lifetime_pass_count INTEGER NOT NULL DEFAULT 0,
+    lifetime_fail_count INTEGER NOT NULL DEFAULT 0,
+    updated_at          INTEGER NOT NULL
+);
+
+CREATE INDEX attempts_user ON attempts (user_id, started_at);
+
+CREATE TABLE attempt_runs (
+    attempt_id TEXT NOT NULL REFERENCES attempts (id) ON DELETE CASCADE,
+    id         TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    which      TEXT NOT NULL DEFAULT '',
+    code_hash  TEXT NOT NULL DEFAULT '',
+    pass_count INTEGER NOT NULL DEFAULT 0,
+    fail_count INTEGER NOT NULL DEFAULT 0,
+    results    TEXT NOT NULL,
+    PRIMARY KEY (attempt_id, id)
+);
+
+CREATE TABLE user_profiles (
+    user_id      TEXT PRIMARY KEY,
+    handle       TEXT NOT NULL DEFAULT '',
+    display_name TEXT NOT NULL DEFAULT '',
+    bio          TEXT NOT NULL DEFAULT '',
+    avatar_url   TEXT NOT NULL DEFAULT '',
+    timezone     TEXT NOT NULL DEFAULT '',
+    preferences  TEXT NOT NULL DEFAULT '{}',
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL
+);
+
+CREATE TABLE saved_problems (
+    id                        TEXT PRIMARY KEY,
+    user_id                   TEXT NOT NULL,
+    problem_id                TEXT NOT NULL,
+    title                     TEXT NOT NULL DEFAULT '',
+    language                  TEXT NOT NULL,
+    status                    TEXT NOT NULL,
+    tags                      TEXT NOT NULL DEFAULT '[]',
+    notes                     TEXT NOT NULL DEFAULT '',
+    hint_unlocked             INTEGER NOT NULL DEFAULT 0,
+    created_at                INTEGER NOT NULL,
+    updated_at                INTEGER NOT NULL,
+    last_attempt_id           TEXT NOT NULL DEFAULT '',
+    last_attempt_status       TEXT NOT NULL DEFAULT '',
+    last_attempt_updated_at   INTEGER NOT NULL DEFAULT 0,
+    last_attempt_pass_count   INTEGER NOT NULL DEFAULT 0,
+    last_attempt_fail_count   INTEGER NOT NULL DEFAULT 0,
+    last_attempt_runtime_ms   INTEGER NOT NULL DEFAULT 0,
+    last_attempt_code         TEXT NOT NULL DEFAULT '',
+    last_attempt_code_s3_key  TEXT NOT NULL DEFAULT '',
+    last_attempt_submitted_at INTEGER
+);
+
+CREATE INDEX saved_problems_user ON saved_problems (user_id, id);
+CREATE INDEX saved_problems_problem ON saved_problems (problem_id, user_id);
+
+CREATE TABLE saved_problem_attempts (
+    saved_problem_id TEXT NOT NULL REFERENCES saved_problems (id) ON DELETE CASCADE,
+    sort_key         TEXT NOT NULL,
+    attempt_id       TEXT NOT NULL,
+    user_id          TEXT NOT NULL,
+    status           TEXT NOT NULL,
+    updated_at       INTEGER NOT NULL,
+    pass_count       INTEGER NOT NULL DEFAULT 0,
+    fail_count       INTEGER NOT NULL DEFAULT 0,
+    runtime_ms       INTEGER NOT NULL DEFAULT 0,
+    code             TEXT NOT NULL DEFAULT '',
+    code_s3_key      TEXT NOT NULL DEFAULT '',
+    submitted_at     INTEGER,
+    PRIMARY KEY (saved_problem_id, sort_key)
+);
+
+CREATE INDEX saved_problem_attempts_user ON saved_problem_attempts (user_id, updated_at);
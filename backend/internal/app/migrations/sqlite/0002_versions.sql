@@ -0,0 +1,4 @@
+-- Optimistic concurrency: profiles and saved problems carry a version that every write
+-- increments. Rows written before this migration start at 0.
+ALTER TABLE user_profiles ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE saved_problems ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Handle reservations, mirroring the HANDLE#<handle> items in DynamoDB: each handle
+-- belongs to at most one user. Existing handles are reserved by their earliest holder.
+CREATE TABLE user_handles (
+    handle     TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+INSERT OR IGNORE INTO user_handles (handle, user_id, created_at)
+SELECT lower(handle), user_id, created_at FROM user_profiles WHERE handle != '' ORDER BY created_at;
@@ -21,39 +21,41 @@ func NewStaticProblemGenerator() *StaticProblemGenerator {
 }
 
 // Generate selects a problem pack that matches the requested category and difficulty.
-func (g *StaticProblemGenerator) Generate(_ context.Context, req api.GenerateRequest) (domain.ProblemPack, error) {
+func (g *StaticProblemGenerator) Generate(_ context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
 	if g == nil {
-		return domain.ProblemPack{}, api.ErrNotImplemented
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
 	}
 
 	category := strings.ToLower(strings.TrimSpace(req.Category))
 	difficulty := strings.ToLower(strings.TrimSpace(req.Difficulty))
 	key := fmt.Sprintf("%s:%s", category, difficulty)
 
-	if pack, ok := g.packs[key]; ok {
-		return cloneProblemPack(pack), nil
+	pack, ok := g.packs[key]
+	if !ok {
+		pack, ok = g.packs["random:easy"]
 	}
-
-	if pack, ok := g.packs["random:easy"]; ok {
-		return cloneProblemPack(pack), nil
+	if !ok {
+		return domain.GeneratedProblem{}, api.ErrNotFound
 	}
 
-	return domain.ProblemPack{}, api.ErrNotFound
+	metadata := domain.GenerationMetadata{Generator: string(GeneratorModeStatic)}
+	metadata.RecordAttempt(domain.GenerationAttempt{Attempt: 1, Kind: domain.GenerationKindGenerate})
+	return domain.GeneratedProblem{Pack: cloneProblemPack(pack), Metadata: metadata}, nil
 }
 
 // MemoryProblemRepository keeps generated problems available for later lookup.
 type MemoryProblemRepository struct {
 	mu    sync.RWMutex
-	store map[string]domain.ProblemPack
+	store map[string]domain.ProblemRecord
 }
 
 // NewMemoryProblemRepository creates an empty in-memory repository.
 func NewMemoryProblemRepository() *MemoryProblemRepository {
-	return &MemoryProblemRepository{store: make(map[string]domain.ProblemPack)}
+	return &MemoryProblemRepository{store: make(map[string]domain.ProblemRecord)}
 }
 
-// Save persists the provided pack and returns a generated identifier.
-func (r *MemoryProblemRepository) Save(_ context.Context, pack domain.ProblemPack) (string, error) {
+// Save persists the provided record and returns a generated identifier.
+func (r *MemoryProblemRepository) Save(_ context.Context, record domain.ProblemRecord) (string, error) {
 	if r == nil {
 		return "", api.ErrNotImplemented
 	}
@@ -62,7 +64,7 @@ func (r *MemoryProblemRepository) Save(_ context.Context, pack domain.ProblemPac
 	defer r.mu.Unlock()
 
 	id := randomID()
-	r.store[id] = cloneProblemPack(pack)
+	r.store[id] = cloneProblemRecord(id, record)
 	return id, nil
 }
 
@@ -75,25 +77,73 @@ func (r *MemoryProblemRepository) Get(_ context.Context, id string) (domain.Prob
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	pack, ok := r.store[id]
+	record, ok := r.store[id]
 	if !ok {
 		return domain.ProblemPack{}, api.ErrNotFound
 	}
-	return cloneProblemPack(pack), nil
+	return cloneProblemPack(record.Pack), nil
 }
 
+// GetRecord fetches a previously saved pack along with its generation metadata.
+func (r *MemoryProblemRepository) GetRecord(_ context.Context, id string) (domain.ProblemRecord, error) {
+	if r == nil {
+		return domain.ProblemRecord{}, api.ErrNotImplemented
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.store[id]
+	if !ok {
+		return domain.ProblemRecord{}, api.ErrNotFound
+	}
+	return cloneProblemRecord(id, record), nil
+}
+
+const shortestPathSolution = `function shortestPath(grid) {
+  const rows = grid.length;
+  const cols = grid[0].length;
+  if (grid[0][0] === 1 || grid[rows - 1][cols - 1] === 1) return -1;
+  const queue = [[0, 0, 1]];
+  const seen = new Set(["0,0"]);
+  for (let head = 0; head < queue.length; head++) {
+    const [r, c, dist] = queue[head];
+    if (r === rows - 1 && c === cols - 1) return dist;
+    for (const [dr, dc] of [[1, 0], [-1, 0], [0, 1], [0, -1]]) {
+      const nr = r + dr;
+      const nc = c + dc;
+      const key = nr + "," + nc;
+      if (nr < 0 || nc < 0 || nr >= rows || nc >= cols) continue;
+      if (grid[nr][nc] === 1 || seen.has(key)) continue;
+      seen.add(key);
+      queue.push([nr, nc, dist + 1]);
+    }
+  }
+  return -1;
+}`
+
+const twoSumSolution = `function twoSum(nums, target) {
+  const seen = new Map();
+  for (let i = 0; i < nums.length; i++) {
+    const need = target - nums[i];
+    if (seen.has(need)) return [seen.get(need), i];
+    seen.set(nums[i], i);
+  }
+  return [];
+}`
+
 func defaultProblemPacks() map[string]domain.ProblemPack {
 	return map[string]domain.ProblemPack{
 		"bfs:easy": {
 			Problem: domain.ProblemMetadata{
 				Title:     "Shortest Path in Grid",
-				Statement: "Given a binary matrix, compute the shortest path from the top-left corner to the bottom-right corner using BFS.",
+				Statement: "Given a binary matrix, compute the shortest path from the top-left corner to the bottom-right corner using BFS. Return the number of cells on the path, or -1 when no path exists.",
 				Constraints: []string{
 					"1 <= rows, cols <= 30",
 					"Grid cells contain 0 (walkable) or 1 (blocked)",
 				},
 				Examples: []domain.Example{
-					{Input: []any{[][]int{{0, 0, 1}, {1, 0, 0}, {1, 0, 0}}}, Output: 4, Explanation: "Go right, down, down, right."},
+					{Input: []any{[][]int{{0, 0, 1}, {1, 0, 0}, {1, 0, 0}}}, Output: 5, Explanation: "Go right, down, down, right, visiting five cells."},
 				},
 				EdgeCases: []string{"Single cell grid", "No path exists"},
 			},
@@ -109,7 +159,7 @@ func defaultProblemPacks() map[string]domain.ProblemPack {
 				{
 					Approach:   "Breadth-first search",
 					Complexity: domain.Complexity{Time: "O(n*m)", Space: "O(n*m)"},
-					Code:       "function shortestPath(grid) { /* ... */ }",
+					Code:       shortestPathSolution,
 				},
 			},
 			Tests: domain.TestSuite{
@@ -149,11 +199,11 @@ func defaultProblemPacks() map[string]domain.ProblemPack {
 				{
 					Approach:   "Hash map lookup",
 					Complexity: domain.Complexity{Time: "O(n)", Space: "O(n)"},
-					Code:       "function twoSum(nums, target) { /* ... */ }",
+					Code:       twoSumSolution,
 				},
 			},
 			Tests: domain.TestSuite{
-				Public: []domain.Example{{Input: []any{[]int{1, 3, 4, 2}, 6}, Output: []int{1, 3}}},
+				Public: []domain.Example{{Input: []any{[]int{1, 3, 4, 2}, 6}, Output: []int{2, 3}}},
 				Hidden: []domain.Example{{Input: []any{[]int{-1, -2, -3, -4, -5}, -8}, Output: []int{2, 4}}},
 			},
 		},
@@ -169,5 +219,25 @@ func cloneProblemPack(src domain.ProblemPack) domain.ProblemPack {
 	clone.Tests.Public = append([]domain.Example(nil), src.Tests.Public...)
 	clone.Tests.Hidden = append([]domain.Example(nil), src.Tests.Hidden...)
 	clone.API.Params = append([]domain.APIParam(nil), src.API.Params...)
+	if src.Comparator != nil {
+		comparator := *src.Comparator
+		clone.Comparator = &comparator
+	}
+	return clone
+}
+
+func cloneProblemRecord(id string, src domain.ProblemRecord) domain.ProblemRecord {
+	clone := src
+	clone.ID = id
+	clone.Pack = cloneProblemPack(src.Pack)
+	clone.Generation.Errors = append([]string(nil), src.Generation.Errors...)
+	clone.Generation.History = append([]domain.GenerationAttempt(nil), src.Generation.History...)
+	clone.Generation.Skipped = append([]domain.SkippedStep(nil), src.Generation.Skipped...)
+	if src.Generation.Verification != nil {
+		report := *src.Generation.Verification
+		report.Corrected = append([]domain.VerificationIssue(nil), report.Corrected...)
+		report.Dropped = append([]domain.VerificationIssue(nil), report.Dropped...)
+		clone.Generation.Verification = &report
+	}
 	return clone
 }
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"improview/backend/internal/domain"
+	"improview/backend/internal/sandbox"
+)
+
+func TestStaticProblemPacksAreValid(t *testing.T) {
+	for key, pack := range defaultProblemPacks() {
+		if err := domain.ValidateProblemPack(pack); err != nil {
+			t.Fatalf("static pack %s: %v", key, err)
+		}
+	}
+}
+
+func TestStaticProblemPacksPassVerification(t *testing.T) {
+	verifier, err := NewVerifyingProblemGenerator(NewStaticProblemGenerator(), sandbox.Limits{})
+	if err != nil {
+		t.Fatalf("create verifier: %v", err)
+	}
+
+	for key, pack := range defaultProblemPacks() {
+		_, report, err := verifier.verify(context.Background(), pack)
+		if err != nil {
+			t.Fatalf("verify %s: %v", key, err)
+		}
+		if report.Verified != report.Checked {
+			t.Fatalf("static pack %s has unverified tests: %+v", key, report)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+	"improview/backend/internal/sandbox"
+)
+
+const (
+	runStatusPass         = "pass"
+	runStatusFail         = "fail"
+	runStatusError        = "error"
+	runStatusCompileError = "compile_error"
+	runStatusTimeout      = "timeout"
+)
+
+const (
+	// maxRecordedValueBytes bounds expected and actual values kept with a result, which is
+	// stored in the run history.
+	maxRecordedValueBytes = 16 << 10
+	// maxRecordedRunBytes bounds the logs and values kept across all results of a run so a
+	// stored run stays well under DynamoDB's 400 KB item limit.
+	maxRecordedRunBytes = 256 << 10
+	maxRunDiffs         = 20
+)
+
+const recordedTruncationMarker = "\n...(truncated in run history)"
+
+// SandboxTestRunner executes submitted code against the tests of the attempt's problem pack,
+// using the executor registered for the attempt's language.
+type SandboxTestRunner struct {
+	Attempts  api.AttemptStore
+	Problems  api.ProblemRepository
+	Executors map[string]sandbox.Executor
+	Limits    sandbox.Limits
+}
+
+// NewSandboxTestRunner wires a runner backed by the embedded JavaScript sandbox, which also
+// runs TypeScript once its types are stripped, a python3 subprocess sandbox and a Go sandbox
+// that compiles with the local toolchain. Custom output checkers always run in the
+// JavaScript executor, whatever the attempt's language.
+func NewSandboxTestRunner(attempts api.AttemptStore, problems api.ProblemRepository, limits sandbox.Limits) *SandboxTestRunner {
+	return &SandboxTestRunner{
+		Attempts: attempts,
+		Problems: problems,
+		Executors: map[string]sandbox.Executor{
+			sandbox.LanguageJavaScript: sandbox.NewJavaScript(),
+			sandbox.LanguageTypeScript: sandbox.NewTypeScript(),
+			sandbox.LanguagePython:     sandbox.NewPython(""),
+			sandbox.LanguageGo:         sandbox.NewGo(""),
+		},
+		Limits: limits,
+	}
+}
+
+// Run resolves attempt -> problem -> test suite and executes the selected tests. Tests are
+// never taken from the request, so clients cannot substitute their own expectations.
+func (r *SandboxTestRunner) Run(ctx context.Context, req api.RunTestsRequest) (domain.RunSummary, error) {
+	if r == nil || r.Attempts == nil || r.Problems == nil || len(r.Executors) == 0 {
+		return domain.RunSummary{}, api.ErrNotImplemented
+	}
+	if strings.TrimSpace(req.Code) == "" || strings.TrimSpace(req.AttemptID) == "" {
+		return domain.RunSummary{}, api.ErrBadRequest
+	}
+
+	attempt, pack, err := r.problemForAttempt(ctx, req.AttemptID)
+	if err != nil {
+		return domain.RunSummary{}, err
+	}
+	language := sandbox.NormalizeLanguage(attempt.Language)
+	executor, ok := r.Executors[language]
+	if !ok {
+		return domain.RunSummary{}, fmt.Errorf("%w: unsupported language %q", api.ErrBadRequest, attempt.Language)
+	}
+	tests, err := selectTests(pack.Tests, req.Which)
+	if err != nil {
+		return domain.RunSummary{}, err
+	}
+
+	results, err := r.execute(ctx, executor, req.Code, pack, tests)
+	if err != nil {
+		return domain.RunSummary{}, err
+	}
+	return domain.RunSummary{AttemptID: req.AttemptID, Results: results}, nil
+}
+
+func (r *SandboxTestRunner) problemForAttempt(ctx context.Context, attemptID string) (domain.Attempt, domain.ProblemPack, error) {
+	attempt, err := r.Attempts.Get(ctx, attemptID)
+	if err != nil {
+		return domain.Attempt{}, domain.ProblemPack{}, err
+	}
+	pack, err := r.Problems.Get(ctx, attempt.ProblemID)
+	if err != nil {
+		return domain.Attempt{}, domain.ProblemPack{}, err
+	}
+	return attempt, pack, nil
+}
+
+// selectTests resolves a selector against the suite's position-derived test IDs.
+func selectTests(suite domain.TestSuite, selector api.TestSelector) ([]domain.TestCase, error) {
+	cases := suite.Cases()
+
+	if selector.IDs != nil {
+		if len(selector.IDs) == 0 {
+			return nil, fmt.Errorf("%w: which must list at least one test id", api.ErrBadRequest)
+		}
+		byID := make(map[string]domain.TestCase, len(cases))
+		for _, tc := range cases {
+			byID[tc.ID] = tc
+		}
+		selected := make([]domain.TestCase, 0, len(selector.IDs))
+		seen := make(map[string]bool, len(selector.IDs))
+		for _, raw := range selector.IDs {
+			id := strings.ToLower(strings.TrimSpace(raw))
+			tc, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown test id %q", api.ErrBadRequest, raw)
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			selected = append(selected, tc)
+		}
+		return selected, nil
+	}
+
+	var set string
+	switch strings.ToLower(strings.TrimSpace(selector.Set)) {
+	case "", api.TestSelectionPublic:
+		set = domain.TestSetPublic
+	case api.TestSelectionHidden:
+		set = domain.TestSetHidden
+	case api.TestSelectionAll:
+		return cases, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown test selection %q", api.ErrBadRequest, selector.Set)
+	}
+
+	selected := make([]domain.TestCase, 0, len(cases))
+	for _, tc := range cases {
+		if tc.Set == set {
+			selected = append(selected, tc)
+		}
+	}
+	return selected, nil
+}
+
+func (r *SandboxTestRunner) execute(ctx context.Context, executor sandbox.Executor, code string, pack domain.ProblemPack, tests []domain.TestCase) ([]domain.RunResult, error) {
+	if len(tests) == 0 {
+		return []domain.RunResult{}, nil
+	}
+
+	inputs := make([][]any, len(tests))
+	for i, test := range tests {
+		inputs[i] = test.Input
+	}
+
+	outcomes, err := executor.Execute(ctx, sandbox.Request{
+		Code:         code,
+		FunctionName: pack.API.FunctionName,
+		Params:       sandboxParams(pack.API.Params),
+		Returns:      pack.API.Returns.Type,
+		Inputs:       inputs,
+		Limits:       r.Limits,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("runner: execute: %w", err)
+	}
+	if len(outcomes) != len(tests) {
+		return nil, fmt.Errorf("runner: expected %d results, got %d", len(tests), len(outcomes))
+	}
+
+	comparator := newOutputComparator(pack.Comparator, r.Executors[sandbox.LanguageJavaScript], r.Limits)
+	verdicts, err := compareOutputs(ctx, comparator, tests, outcomes)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.RunResult, len(tests))
+	for i, outcome := range outcomes {
+		results[i] = domain.RunResult{
+			TestID: tests[i].ID,
+			Status: gradeOutcome(outcome, verdicts[i]),
+			TimeMS: outcome.Duration.Milliseconds(),
+			Stdout: outcome.Stdout,
+			Stderr: outcome.Stderr,
+		}
+		if verdicts[i].Err != "" {
+			results[i].Stderr += verdicts[i].Err + "\n"
+		}
+		if outcome.Status == sandbox.StatusOK {
+			recordOutput(&results[i], tests[i], outcome.Value, comparator)
+		}
+	}
+	return results, nil
+}
+
+// compareOutputs grades the values returned for tests; outcomes that did not return a
+// value keep a zero verdict.
+func compareOutputs(ctx context.Context, comparator outputComparator, tests []domain.TestCase, outcomes []sandbox.Result) ([]outputVerdict, error) {
+	var checks []outputCheck
+	var returned []int
+	for i, outcome := range outcomes {
+		if outcome.Status == sandbox.StatusOK {
+			checks = append(checks, outputCheck{Input: tests[i].Input, Expected: tests[i].Output, Actual: outcome.Value})
+			returned = append(returned, i)
+		}
+	}
+	graded, err := comparator.compare(ctx, checks)
+	if err != nil {
+		return nil, fmt.Errorf("runner: %w", err)
+	}
+	verdicts := make([]outputVerdict, len(outcomes))
+	for i, index := range returned {
+		verdicts[index] = graded[i]
+	}
+	return verdicts, nil
+}
+
+// recordOutput keeps the returned value and, for a failed public test, the expected output
+// and where the two differ under the problem's comparator. Hidden tests keep their expected
+// output secret. Values too large for the run history are left out; the diff still lists
+// at most maxRunDiffs entries.
+func recordOutput(result *domain.RunResult, test domain.TestCase, actual any, comparator outputComparator) {
+	result.Actual = encodeRecordedValue(actual)
+	if result.Status != runStatusFail || test.Set != domain.TestSetPublic {
+		return
+	}
+	result.Expected = encodeRecordedValue(test.Output)
+
+	expected, err := normalizeJSON(test.Output)
+	if err != nil {
+		return
+	}
+	got, err := normalizeJSON(actual)
+	if err != nil {
+		return
+	}
+	result.Diff = comparator.diff(expected, got)
+	result.DiffText = jsonfmt.FormatDiff(result.Diff)
+}
+
+func encodeRecordedValue(value any) json.RawMessage {
+	encoded, err := json.Marshal(value)
+	if err != nil || len(encoded) > maxRecordedValueBytes {
+		return nil
+	}
+	return encoded
+}
+
+// fitRecordedOutput trims results in place so their logs and values together fit in
+// maxRecordedRunBytes. Each result larger than an equal share of the budget loses its
+// values if they alone exceed the share, then has its stdout and stderr cut to fit.
+func fitRecordedOutput(results []domain.RunResult) {
+	total := 0
+	for _, result := range results {
+		total += len(result.Stdout) + len(result.Stderr) + recordedValuesSize(result)
+	}
+	if total <= maxRecordedRunBytes {
+		return
+	}
+
+	share := maxRecordedRunBytes / len(results)
+	for i := range results {
+		result := &results[i]
+		logs := share - recordedValuesSize(*result)
+		if logs < 0 {
+			result.Expected, result.Actual, result.Diff, result.DiffText = nil, nil, nil, ""
+			logs = share
+		}
+		if len(result.Stdout)+len(result.Stderr) <= logs {
+			continue
+		}
+		result.Stdout = truncateRecordedLog(result.Stdout, max(logs/2, logs-len(result.Stderr)))
+		result.Stderr = truncateRecordedLog(result.Stderr, logs-len(result.Stdout))
+	}
+}
+
+func recordedValuesSize(result domain.RunResult) int {
+	size := len(result.Expected) + len(result.Actual) + len(result.DiffText)
+	if len(result.Diff) > 0 {
+		// Differences hold decoded JSON values, so encoding them cannot fail.
+		diff, _ := json.Marshal(result.Diff)
+		size += len(diff)
+	}
+	return size
+}
+
+// truncateRecordedLog cuts log to at most limit bytes, marker included, on a rune boundary.
+func truncateRecordedLog(log string, limit int) string {
+	if len(log) <= limit {
+		return log
+	}
+	cut := limit - len(recordedTruncationMarker)
+	if cut <= 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(log[cut]) {
+		cut--
+	}
+	return log[:cut] + recordedTruncationMarker
+}
+
+func sandboxParams(params []domain.APIParam) []sandbox.Param {
+	converted := make([]sandbox.Param, len(params))
+	for i, param := range params {
+		converted[i] = sandbox.Param{Name: param.Name, Type: param.Type}
+	}
+	return converted
+}
+
+// gradeOutcome maps a sandbox outcome and the comparator's verdict on its value to a run
+// status. A checker that gave no verdict makes the test an error rather than a failure, and
+// code that never ran is a compile error rather than a runtime one.
+func gradeOutcome(outcome sandbox.Result, verdict outputVerdict) string {
+	switch outcome.Status {
+	case sandbox.StatusOK:
+		switch {
+		case verdict.Err != "":
+			return runStatusError
+		case verdict.Match:
+			return runStatusPass
+		default:
+			return runStatusFail
+		}
+	case sandbox.StatusCompileError:
+		return runStatusCompileError
+	case sandbox.StatusTimeout:
+		return runStatusTimeout
+	default:
+		return runStatusError
+	}
+}
+
+// outputsEqual compares two JSON-compatible values after normalising them through JSON so
+// Go literals (e.g. []int) and decoded values (e.g. []any of float64) compare equal.
+func outputsEqual(expected, actual any) bool {
+	left, err := normalizeJSON(expected)
+	if err != nil {
+		return false
+	}
+	right, err := normalizeJSON(actual)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func normalizeJSON(value any) (any, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
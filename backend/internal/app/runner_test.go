@@ -0,0 +1,389 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+	"improview/backend/internal/sandbox"
+)
+
+func newRunnerFixture(t *testing.T) (*SandboxTestRunner, string) {
+	t.Helper()
+	return newRunnerFixtureForLanguage(t, "javascript")
+}
+
+func newRunnerFixtureForLanguage(t *testing.T, language string) (*SandboxTestRunner, string) {
+	t.Helper()
+
+	generated, err := NewStaticProblemGenerator().Generate(context.Background(), api.GenerateRequest{Category: "random", Difficulty: "easy"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	return newRunnerFixtureForPack(t, generated.Pack, language)
+}
+
+func newRunnerFixtureForPack(t *testing.T, pack domain.ProblemPack, language string) (*SandboxTestRunner, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	problems := NewMemoryProblemRepository()
+	attempts := NewMemoryAttemptStore(nil)
+
+	problemID, err := problems.Save(ctx, domain.ProblemRecord{Pack: pack})
+	if err != nil {
+		t.Fatalf("save problem: %v", err)
+	}
+	attempt, err := attempts.Create(ctx, api.CreateAttemptRequest{ProblemID: problemID, Language: language})
+	if err != nil {
+		t.Fatalf("create attempt: %v", err)
+	}
+
+	return NewSandboxTestRunner(attempts, problems, sandbox.Limits{}), attempt.ID
+}
+
+func TestSandboxTestRunnerGradesReferenceSolution(t *testing.T) {
+	runner, attemptID := newRunnerFixture(t)
+
+	for _, which := range []string{"public", "hidden"} {
+		summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution, Which: api.SelectTests(which)})
+		if err != nil {
+			t.Fatalf("run %s: %v", which, err)
+		}
+		if len(summary.Results) != 1 {
+			t.Fatalf("expected 1 %s result, got %d", which, len(summary.Results))
+		}
+		result := summary.Results[0]
+		if result.Status != runStatusPass {
+			t.Fatalf("expected %s test to pass, got %s (stderr %q)", which, result.Status, result.Stderr)
+		}
+		if result.TestID != which+"_1" {
+			t.Fatalf("unexpected test id %q", result.TestID)
+		}
+	}
+}
+
+func TestSandboxTestRunnerReportsFailuresAndErrors(t *testing.T) {
+	runner, attemptID := newRunnerFixture(t)
+
+	cases := map[string]struct {
+		code   string
+		status string
+	}{
+		"wrong answer":  {code: `function twoSum() { console.log("guessing"); return [0, 0]; }`, status: runStatusFail},
+		"runtime error": {code: `function twoSum() { throw new Error("nope"); }`, status: runStatusError},
+		"syntax error":  {code: `function twoSum( {`, status: runStatusCompileError},
+		"timeout":       {code: `function twoSum() { for (;;) {} }`, status: runStatusTimeout},
+	}
+
+	runner.Limits = sandbox.Limits{CPUTime: 50 * time.Millisecond}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: tc.code})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if got := summary.Results[0].Status; got != tc.status {
+				t.Fatalf("expected status %s, got %s", tc.status, got)
+			}
+		})
+	}
+}
+
+func TestSandboxTestRunnerDiffsFailedPublicTests(t *testing.T) {
+	runner, attemptID := newRunnerFixture(t)
+
+	code := `function twoSum(nums) { return nums[0] === 1 ? [2, 4, 9] : [0, 1]; }`
+	summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: code, Which: api.SelectTests(api.TestSelectionAll)})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	public, hidden := summary.Results[0], summary.Results[1]
+
+	if public.Status != runStatusFail || string(public.Expected) != "[2,3]" || string(public.Actual) != "[2,4,9]" {
+		t.Fatalf("expected public failure with values, got %s expected=%s actual=%s", public.Status, public.Expected, public.Actual)
+	}
+	want := []jsonfmt.Difference{
+		{Path: "$[1]", Kind: jsonfmt.DiffChanged, Expected: float64(3), Actual: float64(4)},
+		{Path: "$[2]", Kind: jsonfmt.DiffExtra, Actual: float64(9)},
+	}
+	if !reflect.DeepEqual(public.Diff, want) {
+		t.Fatalf("unexpected diff %#v", public.Diff)
+	}
+	if public.DiffText != "$[1]: expected 3, got 4\n$[2]: unexpected 9" {
+		t.Fatalf("unexpected diff text %q", public.DiffText)
+	}
+
+	if hidden.Status != runStatusFail || string(hidden.Actual) != "[0,1]" {
+		t.Fatalf("expected hidden failure with its actual value, got %s actual=%s", hidden.Status, hidden.Actual)
+	}
+	if hidden.Expected != nil || hidden.Diff != nil || hidden.DiffText != "" {
+		t.Fatalf("hidden tests must not reveal their expected output: %+v", hidden)
+	}
+}
+
+func pairsPack(comparator *domain.ComparatorSpec) domain.ProblemPack {
+	return domain.ProblemPack{
+		API: domain.APISignature{FunctionName: "pairs", Params: []domain.APIParam{{Name: "nums"}, {Name: "target"}}},
+		Tests: domain.TestSuite{
+			Public: []domain.Example{{Input: []any{[]int{1, 2, 3, 4}, 5}, Output: [][]int{{1, 4}, {2, 3}}}},
+			Hidden: []domain.Example{{Input: []any{[]int{0, 3, 5}, 8}, Output: [][]int{{3, 5}}}},
+		},
+		Comparator: comparator,
+	}
+}
+
+func TestSandboxTestRunnerHonoursComparator(t *testing.T) {
+	const reversed = `function pairs(nums, target) {
+  const out = [];
+  for (let i = nums.length - 1; i >= 0; i--)
+    for (let j = i - 1; j >= 0; j--)
+      if (nums[i] + nums[j] === target) out.push([nums[i], nums[j]]);
+  return out;
+}`
+	cases := map[string]struct {
+		comparator *domain.ComparatorSpec
+		want       string
+	}{
+		"exact":            {want: runStatusFail},
+		"unordered":        {comparator: &domain.ComparatorSpec{Mode: domain.ComparatorUnordered}, want: runStatusFail},
+		"unordered-nested": {comparator: &domain.ComparatorSpec{Mode: domain.ComparatorUnorderedNested}, want: runStatusPass},
+		"custom-checker": {
+			comparator: &domain.ComparatorSpec{Mode: domain.ComparatorCustomChecker, Checker: `function check([nums, target], expected, actual) {
+  return actual.length === expected.length && actual.every(([a, b]) => a + b === target);
+}`},
+			want: runStatusPass,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			runner, attemptID := newRunnerFixtureForPack(t, pairsPack(tc.comparator), "javascript")
+			summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: reversed, Which: api.SelectTests(api.TestSelectionAll)})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			for _, result := range summary.Results {
+				if result.Status != tc.want {
+					t.Fatalf("expected %s to %s, got %s (stderr %q)", result.TestID, tc.want, result.Status, result.Stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestSandboxTestRunnerDiffsUnderComparator(t *testing.T) {
+	runner, attemptID := newRunnerFixtureForPack(t, pairsPack(&domain.ComparatorSpec{Mode: domain.ComparatorUnordered}), "javascript")
+
+	summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: `function pairs() { return [[2, 3], [0, 5]]; }`})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := []jsonfmt.Difference{
+		{Path: "$[0][0]", Kind: jsonfmt.DiffChanged, Expected: float64(1), Actual: float64(0)},
+		{Path: "$[0][1]", Kind: jsonfmt.DiffChanged, Expected: float64(4), Actual: float64(5)},
+	}
+	if result := summary.Results[0]; result.Status != runStatusFail || !reflect.DeepEqual(result.Diff, want) {
+		t.Fatalf("expected the sorted pairs to be diffed, got %s %#v", result.Status, result.Diff)
+	}
+}
+
+func TestSandboxTestRunnerReportsBrokenCheckers(t *testing.T) {
+	pack := pairsPack(&domain.ComparatorSpec{Mode: domain.ComparatorCustomChecker, Checker: `function check(input, expected, actual) { return actual.length; }`})
+	runner, attemptID := newRunnerFixtureForPack(t, pack, "javascript")
+
+	summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: `function pairs() { console.error("solution ran"); return []; }`})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	result := summary.Results[0]
+	if result.Status != runStatusError || result.Stderr != "solution ran\ncomparator: checker returned 0, want a boolean\n" {
+		t.Fatalf("expected checker error after the solution's stderr, got %s %q", result.Status, result.Stderr)
+	}
+	if string(result.Actual) != "[]" || result.Diff != nil {
+		t.Fatalf("expected the returned value without a diff, got actual=%s diff=%#v", result.Actual, result.Diff)
+	}
+}
+
+func TestSandboxTestRunnerRunsPythonAttempts(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	runner, attemptID := newRunnerFixtureForLanguage(t, "py")
+
+	code := `
+def twoSum(nums, target):
+    seen = {}
+    for i, n in enumerate(nums):
+        if target - n in seen:
+            return [seen[target - n], i]
+        seen[n] = i
+`
+	summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: code, Which: api.SelectTests(api.TestSelectionAll)})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, result := range summary.Results {
+		if result.Status != runStatusPass {
+			t.Fatalf("expected %s to pass, got %s (stderr %q)", result.TestID, result.Status, result.Stderr)
+		}
+	}
+
+	summary, err = runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution})
+	if err != nil {
+		t.Fatalf("run javascript as python: %v", err)
+	}
+	if got := summary.Results[0].Status; got != runStatusCompileError {
+		t.Fatalf("expected javascript source to fail as python, got %s", got)
+	}
+}
+
+func TestSandboxTestRunnerRunsTypeScriptAttempts(t *testing.T) {
+	runner, attemptID := newRunnerFixtureForLanguage(t, "ts")
+
+	code := `function twoSum(nums: number[], target: number): number[] {
+  const seen = new Map<number, number>();
+  for (let i = 0; i < nums.length; i++) {
+    const j = seen.get(target - nums[i]);
+    if (j !== undefined) return [j, i];
+    seen.set(nums[i], i);
+  }
+  return [] as number[];
+}`
+	summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: code, Which: api.SelectTests(api.TestSelectionAll)})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, result := range summary.Results {
+		if result.Status != runStatusPass {
+			t.Fatalf("expected %s to pass, got %s (stderr %q)", result.TestID, result.Status, result.Stderr)
+		}
+	}
+
+	summary, err = runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: "function twoSum(nums: number[: number) {}"})
+	if err != nil {
+		t.Fatalf("type syntax errors must not fail the run: %v", err)
+	}
+	if got := summary.Results[0]; got.Status != runStatusCompileError || !strings.Contains(got.Stderr, "solution.ts: Line 1") {
+		t.Fatalf("expected a compile error pointing at solution.ts, got %s %q", got.Status, got.Stderr)
+	}
+}
+
+func TestSandboxTestRunnerRunsGoAttempts(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	runner, attemptID := newRunnerFixtureForLanguage(t, "golang")
+
+	code := `func twoSum(nums []int, target int) []int {
+	seen := make(map[int]int)
+	for i, n := range nums {
+		if j, ok := seen[target-n]; ok {
+			return []int{j, i}
+		}
+		seen[n] = i
+	}
+	return []int{}
+}`
+	summary, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: code, Which: api.SelectTests(api.TestSelectionAll)})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, result := range summary.Results {
+		if result.Status != runStatusPass {
+			t.Fatalf("expected %s to pass, got %s (stderr %q)", result.TestID, result.Status, result.Stderr)
+		}
+	}
+
+	summary, err = runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: "func twoSum(nums []float64, target float64) []int { return nil }"})
+	if err != nil {
+		t.Fatalf("build failures must not fail the run: %v", err)
+	}
+	if got := summary.Results[0]; got.Status != runStatusCompileError || !strings.Contains(got.Stderr, "func twoSum(nums []int, target int) []int") {
+		t.Fatalf("expected a compile error naming the expected signature, got %s %q", got.Status, got.Stderr)
+	}
+
+	summary, err = runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: "func twoSum(nums []int, target int) []int {\n\treturn missing\n}"})
+	if err != nil {
+		t.Fatalf("build failures must not fail the run: %v", err)
+	}
+	if got := summary.Results[0]; got.Status != runStatusCompileError || !strings.Contains(got.Stderr, "solution.go:2:9: undefined: missing") {
+		t.Fatalf("expected the compiler's diagnostics as a compile error, got %s %q", got.Status, got.Stderr)
+	}
+}
+
+func TestSandboxTestRunnerRejectsUnsupportedLanguage(t *testing.T) {
+	runner, attemptID := newRunnerFixtureForLanguage(t, "cobol")
+
+	_, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution})
+	if !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+}
+
+func TestSandboxTestRunnerRejectsUnknownSelection(t *testing.T) {
+	runner, attemptID := newRunnerFixture(t)
+
+	_, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution, Which: api.SelectTests("everything")})
+	if !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+}
+
+func TestOutputsEqualNormalisesJSON(t *testing.T) {
+	if !outputsEqual([]int{1, 2}, []any{float64(1), float64(2)}) {
+		t.Fatalf("expected Go literal and decoded JSON to compare equal")
+	}
+	if outputsEqual([]int{1, 2}, []any{float64(2), float64(1)}) {
+		t.Fatalf("expected order to matter")
+	}
+}
+
+func TestSandboxTestRunnerSelectsTestsByID(t *testing.T) {
+	runner, attemptID := newRunnerFixture(t)
+	ctx := context.Background()
+
+	all, err := runner.Run(ctx, api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution, Which: api.SelectTests(api.TestSelectionAll)})
+	if err != nil {
+		t.Fatalf("run all: %v", err)
+	}
+	if got := testIDs(all.Results); len(got) != 2 || got[0] != "public_1" || got[1] != "hidden_1" {
+		t.Fatalf("unexpected ids for all: %v", got)
+	}
+
+	picked, err := runner.Run(ctx, api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution, Which: api.SelectTestIDs("hidden_1", "public_1", "hidden_1")})
+	if err != nil {
+		t.Fatalf("run ids: %v", err)
+	}
+	if got := testIDs(picked.Results); len(got) != 2 || got[0] != "hidden_1" || got[1] != "public_1" {
+		t.Fatalf("unexpected ids for explicit selection: %v", got)
+	}
+
+	_, err = runner.Run(ctx, api.RunTestsRequest{AttemptID: attemptID, Code: twoSumSolution, Which: api.SelectTestIDs("hidden_9")})
+	if !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request for unknown id, got %v", err)
+	}
+}
+
+func TestSandboxTestRunnerRequiresKnownAttempt(t *testing.T) {
+	runner, _ := newRunnerFixture(t)
+
+	_, err := runner.Run(context.Background(), api.RunTestsRequest{AttemptID: "missing", Code: twoSumSolution})
+	if !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testIDs(results []domain.RunResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.TestID
+	}
+	return ids
+}
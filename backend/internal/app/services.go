@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,6 +12,7 @@ import (
 
 	"improview/backend/internal/api"
 	"improview/backend/internal/auth"
+	"improview/backend/internal/sandbox"
 )
 
 // GeneratorMode selects which problem generator backend to use.
@@ -27,23 +29,40 @@ const (
 type ServicesOptions struct {
 	GeneratorMode GeneratorMode
 	LLM           LLMOptions
+	// Anthropic configures the Anthropic Messages API generator; it is enabled when APIKey is set.
+	Anthropic LLMOptions
+	// FallbackChain lists LLM generation steps tried in order (see ParseFallbackChain).
+	FallbackChain string
+	Breaker       BreakerOptions
+	RunnerLimits  sandbox.Limits
+	Storage       StorageOptions
 }
 
-// LLMOptions holds configuration for the remote LLM generator.
+// LLMOptions holds configuration for the remote LLM generator. When Catalog is nil a
+// single-provider catalog is derived from BaseURL, Model, AllowedModels and Provider.
 type LLMOptions struct {
-	APIKey      string
-	BaseURL     string
-	Model       string
-	Provider    string
-	Temperature float64
-	Timeout     time.Duration
-	HTTPClient  *http.Client
+	APIKey        string
+	BaseURL       string
+	Model         string
+	AllowedModels []string
+	Provider      string
+	Catalog       *LLMCatalog
+	Temperature   float64
+	Timeout       time.Duration
+	HTTPClient    *http.Client
+	// MaxAttempts bounds generation attempts, including repair turns (default 3).
+	MaxAttempts int
+	// MaxRetries bounds retries of 429/5xx responses per attempt (default 3, negative disables).
+	MaxRetries int
+	// RetryBaseDelay is the first backoff delay, doubled on each retry (default 500ms).
+	RetryBaseDelay time.Duration
 }
 
 const (
-	defaultLLMTimeout = 25 * time.Second
-	defaultLLMBaseURL = "https://api.openai.com/v1"
-	defaultLLMModel   = "gpt-4.1-mini"
+	defaultLLMTimeout  = 25 * time.Second
+	defaultLLMBaseURL  = "https://api.openai.com/v1"
+	defaultLLMModel    = "gpt-4.1-mini"
+	defaultLLMProvider = "openai"
 )
 
 // NewInMemoryServices wires together a fully in-memory implementation of the API services.
@@ -61,13 +80,42 @@ func NewInMemoryServices(clock api.Clock) api.Services {
 //   - OPENAI_API_KEY: required when using the LLM generator
 //   - OPENAI_MODEL: overrides the default OpenAI model
 //   - OPENAI_BASE_URL: overrides the OpenAI API base URL
-//   - OPENAI_PROVIDER: optional label recorded in prompts
+//   - OPENAI_PROVIDER: provider name in the catalog and label recorded in prompts
+//   - OPENAI_ALLOWED_MODELS: comma-separated models callers may request besides OPENAI_MODEL
+//   - LLM_MAX_ATTEMPTS: generation attempts including self-repair turns
+//   - LLM_MAX_RETRIES: retries of 429/5xx upstream responses per attempt
+//   - LLM_CATALOG: JSON array of {name, baseUrl, models} replacing the OPENAI_* derived catalog
+//   - ANTHROPIC_API_KEY: enables the Anthropic Messages API generator
+//   - ANTHROPIC_MODEL: overrides the default Anthropic model
+//   - ANTHROPIC_BASE_URL: overrides the Anthropic API base URL
+//   - ANTHROPIC_ALLOWED_MODELS: comma-separated models callers may request besides ANTHROPIC_MODEL
 //   - OPENAI_TIMEOUT_SECONDS: request timeout when mode=llm
 //   - OPENAI_TEMPERATURE: float temperature override when mode=llm
+//   - LLM_FALLBACK_CHAIN: ordered generation steps, e.g. "openai/gpt-4.1,anthropic,static"
+//   - LLM_BREAKER_THRESHOLD: consecutive failures that open a step's circuit breaker
+//   - LLM_BREAKER_COOLDOWN_SECONDS: how long an open breaker skips its step
+//   - RUNNER_CPU_TIME_MS: per-test CPU time limit for the code sandbox
+//   - RUNNER_MAX_OUTPUT_BYTES: cap on captured console output per test
+//   - RUNNER_MAX_MEMORY_MB: per-test memory limit for Python and Go runs in MiB (default 256)
+//   - STORAGE_BACKEND: memory, dynamodb or sqlite (defaults to dynamodb when TABLE_NAME is set)
+//   - TABLE_NAME: DynamoDB single table; TABLE_INDEX_ATTEMPT_LOOKUP and TABLE_INDEX_USER_ACTIVITY name its indexes
+//   - SQLITE_PATH: database file for the sqlite backend
+//   - USER_POOL_ID, USER_POOL_CLIENT_ID(S): Cognito pool whose tokens authenticate requests
+//   - ADMIN_GROUPS: comma-separated token groups allowed to access any user's attempts (default "admin")
 func NewServicesFromEnv(clock api.Clock) (api.Services, error) {
+	llmOptions, err := parseLLMOptionsFromEnv()
+	if err != nil {
+		return api.Services{}, err
+	}
+
 	options := ServicesOptions{
 		GeneratorMode: "",
-		LLM:           parseLLMOptionsFromEnv(),
+		LLM:           llmOptions,
+		Anthropic:     parseAnthropicOptionsFromEnv(llmOptions),
+		FallbackChain: strings.TrimSpace(os.Getenv("LLM_FALLBACK_CHAIN")),
+		Breaker:       parseBreakerOptionsFromEnv(),
+		RunnerLimits:  parseRunnerLimitsFromEnv(),
+		Storage:       parseStorageOptionsFromEnv(),
 	}
 
 	services, err := newServices(clock, options)
@@ -80,6 +128,7 @@ func NewServicesFromEnv(clock api.Clock) (api.Services, error) {
 		return api.Services{}, err
 	}
 	services.Authenticator = authenticator
+	services.AdminGroups = uniqueStrings(splitCSV(os.Getenv("ADMIN_GROUPS")))
 
 	return services, nil
 }
@@ -127,7 +176,7 @@ func resolveCognitoRegion(userPoolID string) string {
 	return ""
 }
 
-func parseLLMOptionsFromEnv() LLMOptions {
+func parseLLMOptionsFromEnv() (LLMOptions, error) {
 	timeout := defaultLLMTimeout
 	if raw := strings.TrimSpace(os.Getenv("OPENAI_TIMEOUT_SECONDS")); raw != "" {
 		if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
@@ -142,14 +191,94 @@ func parseLLMOptionsFromEnv() LLMOptions {
 		}
 	}
 
+	var catalog *LLMCatalog
+	if raw := strings.TrimSpace(os.Getenv("LLM_CATALOG")); raw != "" {
+		parsed, err := ParseLLMCatalog(raw)
+		if err != nil {
+			return LLMOptions{}, err
+		}
+		catalog = parsed
+	}
+
+	var maxAttempts, maxRetries int
+	if raw := strings.TrimSpace(os.Getenv("LLM_MAX_ATTEMPTS")); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val > 0 {
+			maxAttempts = val
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("LLM_MAX_RETRIES")); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil {
+			maxRetries = val
+			if val == 0 {
+				maxRetries = -1
+			}
+		}
+	}
+
+	return LLMOptions{
+		APIKey:        strings.TrimSpace(os.Getenv("OPENAI_API_KEY")),
+		BaseURL:       defaultString(os.Getenv("OPENAI_BASE_URL"), defaultLLMBaseURL),
+		Model:         defaultString(os.Getenv("OPENAI_MODEL"), defaultLLMModel),
+		AllowedModels: splitCSV(os.Getenv("OPENAI_ALLOWED_MODELS")),
+		Provider:      strings.TrimSpace(os.Getenv("OPENAI_PROVIDER")),
+		Catalog:       catalog,
+		Temperature:   temperature,
+		Timeout:       timeout,
+		MaxAttempts:   maxAttempts,
+		MaxRetries:    maxRetries,
+	}, nil
+}
+
+// parseAnthropicOptionsFromEnv reads the ANTHROPIC_* variables. Timeout, temperature and
+// retry settings are shared with the OpenAI-compatible generator.
+func parseAnthropicOptionsFromEnv(shared LLMOptions) LLMOptions {
 	return LLMOptions{
-		APIKey:      strings.TrimSpace(os.Getenv("OPENAI_API_KEY")),
-		BaseURL:     defaultString(os.Getenv("OPENAI_BASE_URL"), defaultLLMBaseURL),
-		Model:       defaultString(os.Getenv("OPENAI_MODEL"), defaultLLMModel),
-		Provider:    strings.TrimSpace(os.Getenv("OPENAI_PROVIDER")),
-		Temperature: temperature,
-		Timeout:     timeout,
+		APIKey:         strings.TrimSpace(os.Getenv("ANTHROPIC_API_KEY")),
+		BaseURL:        defaultString(os.Getenv("ANTHROPIC_BASE_URL"), defaultAnthropicBaseURL),
+		Model:          defaultString(os.Getenv("ANTHROPIC_MODEL"), defaultAnthropicModel),
+		AllowedModels:  splitCSV(os.Getenv("ANTHROPIC_ALLOWED_MODELS")),
+		Provider:       defaultAnthropicProvider,
+		Temperature:    shared.Temperature,
+		Timeout:        shared.Timeout,
+		MaxAttempts:    shared.MaxAttempts,
+		MaxRetries:     shared.MaxRetries,
+		RetryBaseDelay: shared.RetryBaseDelay,
+	}
+}
+
+func parseBreakerOptionsFromEnv() BreakerOptions {
+	var options BreakerOptions
+	if raw := strings.TrimSpace(os.Getenv("LLM_BREAKER_THRESHOLD")); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val > 0 {
+			options.Threshold = val
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("LLM_BREAKER_COOLDOWN_SECONDS")); raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+			options.Cooldown = time.Duration(seconds) * time.Second
+		}
+	}
+	return options
+}
+
+func parseRunnerLimitsFromEnv() sandbox.Limits {
+	limits := sandbox.DefaultLimits()
+	if raw := strings.TrimSpace(os.Getenv("RUNNER_CPU_TIME_MS")); raw != "" {
+		if millis, err := strconv.Atoi(raw); err == nil && millis > 0 {
+			limits.CPUTime = time.Duration(millis) * time.Millisecond
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("RUNNER_MAX_OUTPUT_BYTES")); raw != "" {
+		if size, err := strconv.Atoi(raw); err == nil && size > 0 {
+			limits.MaxOutputBytes = size
+		}
 	}
+	if raw := strings.TrimSpace(os.Getenv("RUNNER_MAX_MEMORY_MB")); raw != "" {
+		if megabytes, err := strconv.Atoi(raw); err == nil && megabytes > 0 {
+			limits.MaxMemoryBytes = int64(megabytes) << 20
+		}
+	}
+	return limits
 }
 
 func defaultString(value, fallback string) string {
@@ -214,13 +343,65 @@ func newServices(clock api.Clock, options ServicesOptions) (api.Services, error)
 
 	staticGenerator := NewStaticProblemGenerator()
 
-	var llmGenerator api.ProblemGenerator
+	var llmGenerators []CatalogedGenerator
 	if strings.TrimSpace(options.LLM.APIKey) != "" {
-		var err error
-		llmGenerator, err = NewLLMProblemGenerator(options.LLM)
+		generator, err := NewLLMProblemGenerator(options.LLM)
+		if err != nil {
+			return api.Services{}, err
+		}
+		llmGenerators = append(llmGenerators, generator)
+	}
+	if strings.TrimSpace(options.Anthropic.APIKey) != "" {
+		generator, err := NewAnthropicProblemGenerator(options.Anthropic)
+		if err != nil {
+			return api.Services{}, err
+		}
+		llmGenerators = append(llmGenerators, generator)
+	}
+
+	// Packs are verified per generator rather than around the fallback chain, so a pack
+	// that fails verification counts against its step and the chain moves on.
+	verifiedStatic, err := NewVerifyingProblemGenerator(staticGenerator, options.RunnerLimits)
+	if err != nil {
+		return api.Services{}, err
+	}
+
+	var llmGenerator api.ProblemGenerator
+	// Without an LLM generator the catalog is empty so clients see no selectable models.
+	var models api.ModelCatalog = &LLMCatalog{}
+	if len(llmGenerators) > 0 {
+		router, err := NewLLMRouter(llmGenerators...)
+		if err != nil {
+			return api.Services{}, err
+		}
+		verifiedRouter, err := NewVerifyingProblemGenerator(router, options.RunnerLimits)
 		if err != nil {
 			return api.Services{}, err
 		}
+		llmGenerator = verifiedRouter
+		models = router
+
+		if options.FallbackChain != "" {
+			steps, err := ParseFallbackChain(options.FallbackChain, verifiedStatic, verifiedRouter)
+			if err != nil {
+				return api.Services{}, err
+			}
+			for _, step := range steps {
+				if step.Overrides == nil {
+					continue
+				}
+				if err := router.checkSelection(*step.Overrides); err != nil {
+					return api.Services{}, fmt.Errorf("fallback chain: step %q: %w", step.Name, err)
+				}
+			}
+			chain, err := NewFallbackChainGenerator(steps, verifiedRouter, options.Breaker, clock)
+			if err != nil {
+				return api.Services{}, err
+			}
+			llmGenerator = chain
+		}
+	} else if options.FallbackChain != "" {
+		return api.Services{}, errors.New("fallback chain: LLM_FALLBACK_CHAIN requires an LLM API key")
 	}
 
 	defaultMode := options.GeneratorMode
@@ -235,35 +416,29 @@ func newServices(clock api.Clock, options ServicesOptions) (api.Services, error)
 		return api.Services{}, fmt.Errorf("llm generator: missing API key")
 	}
 
-	generator, err := NewDynamicProblemGenerator(defaultMode, staticGenerator, llmGenerator)
+	generator, err := NewDynamicProblemGenerator(defaultMode, verifiedStatic, llmGenerator)
 	if err != nil {
 		return api.Services{}, err
 	}
 
-	problems := NewMemoryProblemRepository()
-	attempts := NewMemoryAttemptStore(clock)
-	runner := SimpleTestRunner{}
-	submission := SubmissionService{Runner: runner, Attempts: attempts}
-
-	var profiles api.UserProfileStore
-	var savedProblems api.SavedProblemStore
-	if tableName := strings.TrimSpace(os.Getenv("TABLE_NAME")); tableName != "" {
-		store, err := NewDynamoUserDataStoreFromEnv(context.Background(), tableName, strings.TrimSpace(os.Getenv("TABLE_INDEX_ATTEMPT_LOOKUP")), strings.TrimSpace(os.Getenv("TABLE_INDEX_USER_ACTIVITY")))
-		if err != nil {
-			return api.Services{}, err
-		}
-		profiles = store
-		savedProblems = store
+	stores, err := newStores(context.Background(), options.Storage, clock)
+	if err != nil {
+		return api.Services{}, err
 	}
+	problems, attempts := stores.problems, stores.attempts
+
+	runner := NewSandboxTestRunner(attempts, problems, options.RunnerLimits)
+	submission := SubmissionService{Runner: runner, Attempts: attempts}
 
 	return api.Services{
 		Generator:     generator,
 		Problems:      problems,
 		Attempts:      attempts,
-		Profiles:      profiles,
-		SavedProblems: savedProblems,
+		Profiles:      stores.profiles,
+		SavedProblems: stores.savedProblems,
 		Tests:         runner,
 		Submission:    submission,
+		Models:        models,
 		Health:        nil,
 		Clock:         clock,
 	}, nil
@@ -1,6 +1,10 @@
 package app
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+)
 
 func TestConfigureAuthenticatorFromEnvDisabledByDefault(t *testing.T) {
 	t.Setenv("USER_POOL_ID", "")
@@ -42,3 +46,64 @@ func TestConfigureAuthenticatorFromEnvRequiresClientID(t *testing.T) {
 		t.Fatalf("expected error when user pool is set without client ids")
 	}
 }
+
+func TestParseLLMOptionsFromEnvBuildsCatalog(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("OPENAI_PROVIDER", "")
+	t.Setenv("OPENAI_ALLOWED_MODELS", "gpt-4.1, gpt-4.1-mini")
+	t.Setenv("LLM_CATALOG", "")
+
+	options, err := parseLLMOptionsFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generator, err := NewLLMProblemGenerator(options)
+	if err != nil {
+		t.Fatalf("create generator: %v", err)
+	}
+
+	providers, err := generator.Catalog().ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list models: %v", err)
+	}
+	if len(providers) != 1 || providers[0].Name != "openai" || providers[0].BaseURL != defaultLLMBaseURL {
+		t.Fatalf("unexpected providers %+v", providers)
+	}
+	if got := strings.Join(providers[0].Models, ","); got != "gpt-4.1-mini,gpt-4.1" {
+		t.Fatalf("unexpected models %q", got)
+	}
+}
+
+func TestParseLLMOptionsFromEnvRejectsInvalidCatalog(t *testing.T) {
+	t.Setenv("LLM_CATALOG", `[{"name":"x","baseUrl":"not a url","models":["m"]}]`)
+
+	if _, err := parseLLMOptionsFromEnv(); err == nil {
+		t.Fatalf("expected error for invalid LLM_CATALOG")
+	}
+}
+
+func TestNewServicesEnablesAnthropicOnly(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "ant-key")
+	t.Setenv("ANTHROPIC_MODEL", "")
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	t.Setenv("ANTHROPIC_ALLOWED_MODELS", "claude-extra")
+
+	options := ServicesOptions{Anthropic: parseAnthropicOptionsFromEnv(LLMOptions{})}
+	services, err := newServices(nil, options)
+	if err != nil {
+		t.Fatalf("new services: %v", err)
+	}
+
+	providers, err := services.Models.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list models: %v", err)
+	}
+	if len(providers) != 1 || providers[0].Name != "anthropic" || providers[0].BaseURL != defaultAnthropicBaseURL {
+		t.Fatalf("unexpected providers %+v", providers)
+	}
+	if got := strings.Join(providers[0].Models, ","); got != defaultAnthropicModel+",claude-extra" {
+		t.Fatalf("unexpected models %s", got)
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// OpenSQLiteDB opens (creating if needed) the SQLite database at path and applies any
+// pending schema migrations. The pool is limited to one connection: SQLite serialises
+// writers anyway, and a single connection turns concurrent requests into queued
+// transactions instead of SQLITE_BUSY errors.
+func OpenSQLiteDB(ctx context.Context, dbPath string) (*sql.DB, error) {
+	if strings.TrimSpace(dbPath) == "" {
+		return nil, errors.New("sqlite store: database path is required")
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: open %s: %w", dbPath, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrateSQLite applies the embedded migrations that are not yet recorded in
+// schema_migrations, each in its own transaction and in file name order.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("sqlite store: create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(sqliteMigrations, "migrations/sqlite/*.sql")
+	if err != nil {
+		return fmt.Errorf("sqlite store: list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version, err := sqliteMigrationVersion(name)
+		if err != nil {
+			return err
+		}
+
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("sqlite store: check migration %d: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := sqliteMigrations.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("sqlite store: read migration %s: %w", name, err)
+		}
+		if err := withSQLiteTx(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return fmt.Errorf("sqlite store: apply migration %s: %w", name, err)
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UnixMilli())
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteMigrationVersion parses the numeric prefix of a migration file, e.g. 1 for
+// "0001_init.sql".
+func sqliteMigrationVersion(name string) (int, error) {
+	base := path.Base(name)
+	prefix, _, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, fmt.Errorf("sqlite store: migration %s must be named <version>_<name>.sql", base)
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("sqlite store: migration %s has an invalid version", base)
+	}
+	return version, nil
+}
+
+// withSQLiteTx runs fn in a transaction, committing when it returns nil.
+func withSQLiteTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite store: begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite store: commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+// SQLiteAttemptStore persists attempts in the attempts table and each run in attempt_runs.
+type SQLiteAttemptStore struct {
+	db    *sql.DB
+	clock api.Clock
+}
+
+// NewSQLiteAttemptStore constructs a store on a database opened by OpenSQLiteDB.
+func NewSQLiteAttemptStore(db *sql.DB, clock api.Clock) (*SQLiteAttemptStore, error) {
+	if db == nil {
+		return nil, errors.New("sqlite attempts: database is required")
+	}
+	if clock == nil {
+		clock = api.RealClock{}
+	}
+	return &SQLiteAttemptStore{db: db, clock: clock}, nil
+}
+
+const sqliteAttemptColumns = `id, problem_id, user_id, lang, started_at, ended_at, duration_ms, hint_used,
+	solutions_unlocked, pass_count, fail_count, last_run_id, lifetime_runs, lifetime_pass_count, lifetime_fail_count`
+
+// sqliteQueryer is satisfied by both *sql.DB and *sql.Tx.
+type sqliteQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanSQLiteAttempt(ctx context.Context, q sqliteQueryer, attemptID string) (domain.Attempt, error) {
+	var attempt domain.Attempt
+	err := q.QueryRowContext(ctx, `SELECT `+sqliteAttemptColumns+` FROM attempts WHERE id = ?`, attemptID).Scan(
+		&attempt.ID,
+		&attempt.ProblemID,
+		&attempt.UserID,
+		&attempt.Language,
+		&attempt.StartedAt,
+		&attempt.EndedAt,
+		&attempt.DurationMS,
+		&attempt.HintUsed,
+		&attempt.SolutionsUnlocked,
+		&attempt.PassCount,
+		&attempt.FailCount,
+		&attempt.LastRunID,
+		&attempt.Lifetime.Runs,
+		&attempt.Lifetime.PassCount,
+		&attempt.Lifetime.FailCount,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Attempt{}, api.ErrNotFound
+	}
+	if err != nil {
+		return domain.Attempt{}, fmt.Errorf("sqlite attempts: get attempt: %w", err)
+	}
+	return attempt, nil
+}
+
+// Create records a new attempt when a user begins solving.
+func (s *SQLiteAttemptStore) Create(ctx context.Context, req api.CreateAttemptRequest) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+	if strings.TrimSpace(req.ProblemID) == "" {
+		return domain.Attempt{}, api.ErrBadRequest
+	}
+
+	now := s.clock.Now().UnixMilli()
+	attempt := domain.Attempt{
+		ID:        randomID(),
+		ProblemID: req.ProblemID,
+		UserID:    api.CallerID(ctx),
+		Language:  req.Language,
+		StartedAt: now,
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO attempts (id, problem_id, user_id, lang, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		attempt.ID, attempt.ProblemID, attempt.UserID, attempt.Language, now, now,
+	); err != nil {
+		return domain.Attempt{}, fmt.Errorf("sqlite attempts: create attempt: %w", err)
+	}
+	return attempt, nil
+}
+
+// RecordRun stores the run in the attempt's history, makes it the latest run and adds it
+// to the lifetime totals in one transaction.
+func (s *SQLiteAttemptStore) RecordRun(ctx context.Context, attemptID string, run domain.AttemptRun) (domain.AttemptRun, error) {
+	if s == nil {
+		return domain.AttemptRun{}, api.ErrNotImplemented
+	}
+
+	now := s.clock.Now().UnixMilli()
+	run = newAttemptRun(run, now)
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return domain.AttemptRun{}, fmt.Errorf("sqlite attempts: encode run results: %w", err)
+	}
+
+	err = withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE attempts SET
+			pass_count = ?, fail_count = ?, last_run_id = ?, updated_at = ?,
+			lifetime_runs = lifetime_runs + 1,
+			lifetime_pass_count = lifetime_pass_count + ?,
+			lifetime_fail_count = lifetime_fail_count + ?
+			WHERE id = ? AND ended_at = 0`,
+			run.PassCount, run.FailCount, run.ID, now, run.PassCount, run.FailCount, attemptID)
+		if err != nil {
+			return fmt.Errorf("sqlite attempts: update attempt: %w", err)
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("sqlite attempts: update attempt: %w", err)
+		} else if rows > 0 {
+			return insertSQLiteRun(ctx, tx, attemptID, run, results)
+		}
+		if _, err := scanSQLiteAttempt(ctx, tx, attemptID); err != nil {
+			return err
+		}
+		return errAttemptCompleted
+	})
+	if err != nil {
+		return domain.AttemptRun{}, err
+	}
+	return run, nil
+}
+
+func insertSQLiteRun(ctx context.Context, tx *sql.Tx, attemptID string, run domain.AttemptRun, results []byte) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO attempt_runs
+		(attempt_id, id, created_at, which, code_hash, pass_count, fail_count, results)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		attemptID, run.ID, run.CreatedAt, run.Which, run.CodeHash, run.PassCount, run.FailCount, string(results),
+	); err != nil {
+		return fmt.Errorf("sqlite attempts: put run: %w", err)
+	}
+	return nil
+}
+
+// Get returns the attempt metadata.
+func (s *SQLiteAttemptStore) Get(ctx context.Context, attemptID string) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+	return scanSQLiteAttempt(ctx, s.db, attemptID)
+}
+
+// ListRuns returns a page of the attempt's run history, oldest run first. The next token
+// is the last run ID of the page and is only returned when more runs follow.
+func (s *SQLiteAttemptStore) ListRuns(ctx context.Context, attemptID string, opts domain.RunListOptions) (domain.RunListResult, error) {
+	if s == nil {
+		return domain.RunListResult{}, api.ErrNotImplemented
+	}
+	if _, err := scanSQLiteAttempt(ctx, s.db, attemptID); err != nil {
+		return domain.RunListResult{}, err
+	}
+
+	limit := int(runPageSize(opts.Limit))
+	rows, err := s.db.QueryContext(ctx, `SELECT id, created_at, which, code_hash, pass_count, fail_count, results
+		FROM attempt_runs WHERE attempt_id = ? AND id > ? ORDER BY id LIMIT ?`,
+		attemptID, strings.TrimSpace(opts.NextToken), limit+1)
+	if err != nil {
+		return domain.RunListResult{}, fmt.Errorf("sqlite attempts: list runs: %w", err)
+	}
+	defer rows.Close()
+
+	result := domain.RunListResult{Runs: make([]domain.AttemptRun, 0, limit)}
+	for rows.Next() {
+		var run domain.AttemptRun
+		var results string
+		if err := rows.Scan(&run.ID, &run.CreatedAt, &run.Which, &run.CodeHash, &run.PassCount, &run.FailCount, &results); err != nil {
+			return domain.RunListResult{}, fmt.Errorf("sqlite attempts: decode run: %w", err)
+		}
+		if err := json.Unmarshal([]byte(results), &run.Results); err != nil {
+			return domain.RunListResult{}, fmt.Errorf("sqlite attempts: decode run results: %w", err)
+		}
+		result.Runs = append(result.Runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.RunListResult{}, fmt.Errorf("sqlite attempts: list runs: %w", err)
+	}
+
+	if len(result.Runs) > limit {
+		result.Runs = result.Runs[:limit]
+		result.NextToken = result.Runs[limit-1].ID
+	}
+	return result, nil
+}
+
+// Complete finalizes an attempt after submission and records the submission's run in the
+// same transaction. The update only matches attempts that have not ended, so an attempt can
+// only be completed once and a rejected Complete records nothing.
+func (s *SQLiteAttemptStore) Complete(ctx context.Context, attemptID string, run domain.AttemptRun, summary domain.SubmissionSummary) error {
+	if s == nil {
+		return api.ErrNotImplemented
+	}
+
+	now := s.clock.Now().UnixMilli()
+	run = newAttemptRun(run, now)
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return fmt.Errorf("sqlite attempts: encode run results: %w", err)
+	}
+
+	return withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE attempts SET
+			ended_at = ?,
+			duration_ms = CASE WHEN started_at > 0 THEN ? - started_at ELSE 0 END,
+			updated_at = ?,
+			pass_count = ?, fail_count = ?, last_run_id = ?,
+			lifetime_runs = lifetime_runs + 1,
+			lifetime_pass_count = lifetime_pass_count + ?,
+			lifetime_fail_count = lifetime_fail_count + ?
+			WHERE id = ? AND ended_at = 0`,
+			now, now, now, run.PassCount, run.FailCount, run.ID, run.PassCount, run.FailCount, attemptID)
+		if err != nil {
+			return fmt.Errorf("sqlite attempts: complete attempt: %w", err)
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("sqlite attempts: complete attempt: %w", err)
+		} else if rows > 0 {
+			return insertSQLiteRun(ctx, tx, attemptID, run, results)
+		}
+		if _, err := scanSQLiteAttempt(ctx, tx, attemptID); err != nil {
+			return err
+		}
+		return errAttemptCompleted
+	})
+}
+
+// RecordUnlock marks protected problem content as revealed for the attempt.
+func (s *SQLiteAttemptStore) RecordUnlock(ctx context.Context, attemptID string, unlock domain.Unlock) (domain.Attempt, error) {
+	if s == nil {
+		return domain.Attempt{}, api.ErrNotImplemented
+	}
+
+	var column string
+	switch unlock {
+	case domain.UnlockHint:
+		column = "hint_used"
+	case domain.UnlockSolutions:
+		column = "solutions_unlocked"
+	default:
+		if _, err := s.Get(ctx, attemptID); err != nil {
+			return domain.Attempt{}, err
+		}
+		return domain.Attempt{}, fmt.Errorf("%w: unknown unlock %q", api.ErrBadRequest, unlock)
+	}
+
+	var attempt domain.Attempt
+	err := withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE attempts SET `+column+` = 1, updated_at = ? WHERE id = ?`,
+			s.clock.Now().UnixMilli(), attemptID); err != nil {
+			return fmt.Errorf("sqlite attempts: record unlock: %w", err)
+		}
+		var err error
+		attempt, err = scanSQLiteAttempt(ctx, tx, attemptID)
+		return err
+	})
+	if err != nil {
+		return domain.Attempt{}, err
+	}
+	return attempt, nil
+}
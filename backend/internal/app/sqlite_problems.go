@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+// SQLiteProblemRepository persists generated problem packs in the problems table. Like the
+// DynamoDB repository it stores the pack and generation metadata as JSON documents and
+// lifts the fields used for reporting into columns.
+type SQLiteProblemRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteProblemRepository constructs a repository on a database opened by OpenSQLiteDB.
+func NewSQLiteProblemRepository(db *sql.DB) (*SQLiteProblemRepository, error) {
+	if db == nil {
+		return nil, errors.New("sqlite problems: database is required")
+	}
+	return &SQLiteProblemRepository{db: db}, nil
+}
+
+// Save stores the record under a new identifier.
+func (r *SQLiteProblemRepository) Save(ctx context.Context, record domain.ProblemRecord) (string, error) {
+	if r == nil {
+		return "", api.ErrNotImplemented
+	}
+
+	pack, err := json.Marshal(record.Pack)
+	if err != nil {
+		return "", fmt.Errorf("sqlite problems: encode pack: %w", err)
+	}
+	generation, err := json.Marshal(record.Generation)
+	if err != nil {
+		return "", fmt.Errorf("sqlite problems: encode generation metadata: %w", err)
+	}
+
+	id := randomID()
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO problems
+		(id, title, category, difficulty, generator, provider, model, prompt_hash, created_by, created_at, pack, generation)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id,
+		record.Pack.Problem.Title,
+		strings.ToLower(record.Category),
+		strings.ToLower(record.Difficulty),
+		record.Generation.Generator,
+		record.Generation.Provider,
+		record.Generation.Model,
+		record.PromptHash,
+		record.CreatedBy,
+		record.CreatedAt,
+		string(pack),
+		string(generation),
+	); err != nil {
+		return "", fmt.Errorf("sqlite problems: save problem: %w", err)
+	}
+	return id, nil
+}
+
+// Get fetches a previously saved problem pack.
+func (r *SQLiteProblemRepository) Get(ctx context.Context, id string) (domain.ProblemPack, error) {
+	record, err := r.GetRecord(ctx, id)
+	if err != nil {
+		return domain.ProblemPack{}, err
+	}
+	return record.Pack, nil
+}
+
+// GetRecord fetches a previously saved pack along with its generation metadata.
+func (r *SQLiteProblemRepository) GetRecord(ctx context.Context, id string) (domain.ProblemRecord, error) {
+	if r == nil {
+		return domain.ProblemRecord{}, api.ErrNotImplemented
+	}
+
+	record := domain.ProblemRecord{ID: id}
+	var pack, generation string
+	err := r.db.QueryRowContext(ctx, `SELECT category, difficulty, prompt_hash, created_by, created_at, pack, generation
+		FROM problems WHERE id = ?`, id).
+		Scan(&record.Category, &record.Difficulty, &record.PromptHash, &record.CreatedBy, &record.CreatedAt, &pack, &generation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ProblemRecord{}, api.ErrNotFound
+	}
+	if err != nil {
+		return domain.ProblemRecord{}, fmt.Errorf("sqlite problems: get problem: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(pack), &record.Pack); err != nil {
+		return domain.ProblemRecord{}, fmt.Errorf("sqlite problems: decode pack: %w", err)
+	}
+	if err := json.Unmarshal([]byte(generation), &record.Generation); err != nil {
+		return domain.ProblemRecord{}, fmt.Errorf("sqlite problems: decode generation metadata: %w", err)
+	}
+	return record, nil
+}
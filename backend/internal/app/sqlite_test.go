@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"improview/backend/internal/domain"
+	"improview/backend/internal/storetest"
+)
+
+func testSQLitePath(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "improview.db")
+}
+
+func TestSQLiteStoresConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db, err := OpenSQLiteDB(context.Background(), testSQLitePath(t))
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		userData, err := NewSQLiteUserDataStore(db)
+		if err != nil {
+			t.Fatalf("user data store: %v", err)
+		}
+		attempts, err := NewSQLiteAttemptStore(db, nil)
+		if err != nil {
+			t.Fatalf("attempt store: %v", err)
+		}
+		problems, err := NewSQLiteProblemRepository(db)
+		if err != nil {
+			t.Fatalf("problem repository: %v", err)
+		}
+		return storetest.Stores{
+			Profiles:      userData,
+			SavedProblems: userData,
+			Attempts:      attempts,
+			Problems:      problems,
+		}
+	})
+}
+
+func TestSQLiteMigrationsApplyOnce(t *testing.T) {
+	ctx := context.Background()
+	path := testSQLitePath(t)
+
+	migrations, err := fs.Glob(sqliteMigrations, "migrations/sqlite/*.sql")
+	if err != nil {
+		t.Fatalf("list migrations: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		db, err := OpenSQLiteDB(ctx, path)
+		if err != nil {
+			t.Fatalf("open sqlite (pass %d): %v", i+1, err)
+		}
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+			t.Fatalf("count migrations: %v", err)
+		}
+		db.Close()
+		if applied != len(migrations) {
+			t.Fatalf("expected %d applied migrations, got %d", len(migrations), applied)
+		}
+	}
+}
+
+func TestNewServicesSelectsSQLiteStorage(t *testing.T) {
+	ctx := context.Background()
+	options := ServicesOptions{Storage: StorageOptions{Backend: StorageBackendSQLite, SQLitePath: testSQLitePath(t)}}
+	now := time.UnixMilli(1_700_000_000_000)
+
+	services, err := newServices(nil, options)
+	if err != nil {
+		t.Fatalf("new services: %v", err)
+	}
+	if _, ok := services.SavedProblems.(*SQLiteUserDataStore); !ok {
+		t.Fatalf("expected sqlite saved problem store, got %T", services.SavedProblems)
+	}
+	saved, err := services.SavedProblems.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1", Language: "go"}, now)
+	if err != nil {
+		t.Fatalf("create saved problem: %v", err)
+	}
+
+	// A second process on the same file sees the data.
+	reopened, err := newServices(nil, options)
+	if err != nil {
+		t.Fatalf("reopen services: %v", err)
+	}
+	if _, err := reopened.SavedProblems.GetSavedProblem(ctx, "user-1", saved.ID); err != nil {
+		t.Fatalf("expected saved problem to persist: %v", err)
+	}
+
+	if _, err := newServices(nil, ServicesOptions{Storage: StorageOptions{Backend: "postgres"}}); err == nil || !strings.Contains(err.Error(), "postgres") {
+		t.Fatalf("expected unknown backend to be rejected, got %v", err)
+	}
+	if _, err := newServices(nil, ServicesOptions{Storage: StorageOptions{Backend: StorageBackendDynamo}}); err == nil {
+		t.Fatalf("expected dynamodb backend without a table to be rejected")
+	}
+}
@@ -0,0 +1,520 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+// SQLiteUserDataStore persists profiles, saved problems and their attempt snapshots. It
+// builds the same rows as DynamoUserDataStore, so listing, filtering and pagination match.
+type SQLiteUserDataStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteUserDataStore constructs a store on a database opened by OpenSQLiteDB.
+func NewSQLiteUserDataStore(db *sql.DB) (*SQLiteUserDataStore, error) {
+	if db == nil {
+		return nil, errors.New("sqlite store: database is required")
+	}
+	return &SQLiteUserDataStore{db: db}, nil
+}
+
+const sqliteSavedProblemColumns = `id, user_id, problem_id, title, language, status, tags, notes, hint_unlocked,
+	created_at, updated_at, last_attempt_id, last_attempt_status, last_attempt_updated_at, last_attempt_pass_count,
+	last_attempt_fail_count, last_attempt_runtime_ms, last_attempt_code, last_attempt_code_s3_key, last_attempt_submitted_at, version`
+
+type sqliteScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSQLiteSavedProblem(row sqliteScanner) (savedProblemItem, error) {
+	var item savedProblemItem
+	var tags string
+	var submittedAt sql.NullInt64
+	if err := row.Scan(
+		&item.SavedProblemID,
+		&item.UserID,
+		&item.ProblemID,
+		&item.Title,
+		&item.Language,
+		&item.Status,
+		&tags,
+		&item.Notes,
+		&item.HintUnlocked,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&item.LastAttemptID,
+		&item.LastAttemptStatus,
+		&item.LastAttemptUpdatedAt,
+		&item.LastAttemptPassCount,
+		&item.LastAttemptFailCount,
+		&item.LastAttemptRuntimeMS,
+		&item.LastAttemptCode,
+		&item.LastAttemptCodeS3Key,
+		&submittedAt,
+		&item.Version,
+	); err != nil {
+		return savedProblemItem{}, err
+	}
+	if err := json.Unmarshal([]byte(tags), &item.Tags); err != nil {
+		return savedProblemItem{}, fmt.Errorf("sqlite store: decode tags: %w", err)
+	}
+	if submittedAt.Valid {
+		item.LastAttemptSubmittedAt = &submittedAt.Int64
+	}
+	return item, nil
+}
+
+func (s *SQLiteUserDataStore) fetchSavedProblemItem(ctx context.Context, q sqliteQueryer, userID, savedProblemID string) (savedProblemItem, error) {
+	item, err := scanSQLiteSavedProblem(q.QueryRowContext(ctx,
+		`SELECT `+sqliteSavedProblemColumns+` FROM saved_problems WHERE user_id = ? AND id = ?`, userID, savedProblemID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return savedProblemItem{}, api.ErrNotFound
+	}
+	if err != nil {
+		return savedProblemItem{}, fmt.Errorf("sqlite store: get saved problem: %w", err)
+	}
+	return item, nil
+}
+
+// putSavedProblemItem inserts or updates the saved problem row.
+func (s *SQLiteUserDataStore) putSavedProblemItem(ctx context.Context, tx *sql.Tx, item savedProblemItem) error {
+	tags, err := json.Marshal(append([]string{}, item.Tags...))
+	if err != nil {
+		return fmt.Errorf("sqlite store: encode tags: %w", err)
+	}
+	var submittedAt sql.NullInt64
+	if item.LastAttemptSubmittedAt != nil {
+		submittedAt = sql.NullInt64{Int64: *item.LastAttemptSubmittedAt, Valid: true}
+	}
+	// An upsert rather than INSERT OR REPLACE: REPLACE deletes the old row first, which
+	// would cascade to the attempt snapshots.
+	if _, err := tx.ExecContext(ctx, `INSERT INTO saved_problems (`+sqliteSavedProblemColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status, tags = excluded.tags, notes = excluded.notes,
+			hint_unlocked = excluded.hint_unlocked, updated_at = excluded.updated_at,
+			last_attempt_id = excluded.last_attempt_id, last_attempt_status = excluded.last_attempt_status,
+			last_attempt_updated_at = excluded.last_attempt_updated_at,
+			last_attempt_pass_count = excluded.last_attempt_pass_count,
+			last_attempt_fail_count = excluded.last_attempt_fail_count,
+			last_attempt_runtime_ms = excluded.last_attempt_runtime_ms,
+			last_attempt_code = excluded.last_attempt_code,
+			last_attempt_code_s3_key = excluded.last_attempt_code_s3_key,
+			last_attempt_submitted_at = excluded.last_attempt_submitted_at,
+			version = excluded.version`,
+		item.SavedProblemID,
+		item.UserID,
+		item.ProblemID,
+		item.Title,
+		item.Language,
+		item.Status,
+		string(tags),
+		item.Notes,
+		item.HintUnlocked,
+		item.CreatedAt,
+		item.UpdatedAt,
+		item.LastAttemptID,
+		item.LastAttemptStatus,
+		item.LastAttemptUpdatedAt,
+		item.LastAttemptPassCount,
+		item.LastAttemptFailCount,
+		item.LastAttemptRuntimeMS,
+		item.LastAttemptCode,
+		item.LastAttemptCodeS3Key,
+		submittedAt,
+		item.Version,
+	); err != nil {
+		return fmt.Errorf("sqlite store: save saved problem: %w", err)
+	}
+	return nil
+}
+
+// GetProfile retrieves the persisted profile for the given user.
+func (s *SQLiteUserDataStore) GetProfile(ctx context.Context, userID string) (domain.UserProfile, error) {
+	if s == nil {
+		return domain.UserProfile{}, api.ErrNotImplemented
+	}
+	return s.getProfile(ctx, s.db, userID)
+}
+
+func (s *SQLiteUserDataStore) getProfile(ctx context.Context, q sqliteQueryer, userID string) (domain.UserProfile, error) {
+	var profile domain.UserProfile
+	var preferences string
+	err := q.QueryRowContext(ctx, `SELECT user_id, handle, display_name, bio, avatar_url, timezone, preferences, created_at, updated_at, version
+		FROM user_profiles WHERE user_id = ?`, userID).Scan(
+		&profile.UserID,
+		&profile.Handle,
+		&profile.DisplayName,
+		&profile.Bio,
+		&profile.AvatarURL,
+		&profile.Timezone,
+		&preferences,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+		&profile.Version,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.UserProfile{}, api.ErrNotFound
+	}
+	if err != nil {
+		return domain.UserProfile{}, fmt.Errorf("sqlite store: get profile: %w", err)
+	}
+	if err := json.Unmarshal([]byte(preferences), &profile.Preferences); err != nil {
+		return domain.UserProfile{}, fmt.Errorf("sqlite store: decode preferences: %w", err)
+	}
+	if profile.Preferences == nil {
+		profile.Preferences = map[string]string{}
+	}
+	return profile, nil
+}
+
+// GetProfileByHandle returns the profile holding the handle's reservation.
+func (s *SQLiteUserDataStore) GetProfileByHandle(ctx context.Context, handle string) (domain.UserProfile, error) {
+	if s == nil {
+		return domain.UserProfile{}, api.ErrNotImplemented
+	}
+
+	var userID string
+	err := s.db.QueryRowContext(ctx, `SELECT user_id FROM user_handles WHERE handle = ?`, handle).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.UserProfile{}, api.ErrNotFound
+	}
+	if err != nil {
+		return domain.UserProfile{}, fmt.Errorf("sqlite store: get handle: %w", err)
+	}
+
+	profile, err := s.getProfile(ctx, s.db, userID)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	if profile.Handle != handle {
+		return domain.UserProfile{}, api.ErrNotFound
+	}
+	return profile, nil
+}
+
+// UpsertProfile stores or updates the user's profile. The handle is reserved in
+// user_handles in the same transaction, and a replaced handle is released.
+func (s *SQLiteUserDataStore) UpsertProfile(ctx context.Context, userID string, update domain.UserProfileUpdate, now time.Time) (domain.UserProfile, error) {
+	if s == nil {
+		return domain.UserProfile{}, api.ErrNotImplemented
+	}
+
+	var profile domain.UserProfile
+	err := withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		existing, err := s.getProfile(ctx, tx, userID)
+		if err != nil && !errors.Is(err, api.ErrNotFound) {
+			return err
+		}
+		if err := checkVersion(existing.Version, update.IfVersion); err != nil {
+			return err
+		}
+		update, err := normalizeProfileUpdate(update)
+		if err != nil {
+			return err
+		}
+		profile = applyProfileUpdate(existing, userID, update, now.UnixMilli())
+
+		if err := s.reserveHandle(ctx, tx, userID, existing.Handle, profile.Handle, profile.UpdatedAt); err != nil {
+			return err
+		}
+
+		preferences, err := json.Marshal(profile.Preferences)
+		if err != nil {
+			return fmt.Errorf("sqlite store: encode preferences: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_profiles
+			(user_id, handle, display_name, bio, avatar_url, timezone, preferences, created_at, updated_at, version)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET
+				handle = excluded.handle, display_name = excluded.display_name, bio = excluded.bio,
+				avatar_url = excluded.avatar_url, timezone = excluded.timezone,
+				preferences = excluded.preferences, updated_at = excluded.updated_at,
+				version = excluded.version`,
+			profile.UserID,
+			profile.Handle,
+			profile.DisplayName,
+			profile.Bio,
+			profile.AvatarURL,
+			profile.Timezone,
+			string(preferences),
+			profile.CreatedAt,
+			profile.UpdatedAt,
+			profile.Version,
+		); err != nil {
+			return fmt.Errorf("sqlite store: save profile: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	return profile, nil
+}
+
+// reserveHandle claims handle for the user and releases oldHandle when it changed.
+func (s *SQLiteUserDataStore) reserveHandle(ctx context.Context, tx *sql.Tx, userID, oldHandle, handle string, nowMillis int64) error {
+	if handle != "" {
+		var owner string
+		err := tx.QueryRowContext(ctx, `SELECT user_id FROM user_handles WHERE handle = ?`, handle).Scan(&owner)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if _, err := tx.ExecContext(ctx, `INSERT INTO user_handles (handle, user_id, created_at) VALUES (?, ?, ?)`,
+				handle, userID, nowMillis); err != nil {
+				return fmt.Errorf("sqlite store: reserve handle: %w", err)
+			}
+		case err != nil:
+			return fmt.Errorf("sqlite store: get handle: %w", err)
+		case owner != userID:
+			return errHandleTaken(handle)
+		}
+	}
+	if oldHandle != "" && oldHandle != handle {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_handles WHERE handle = ? AND user_id = ?`, oldHandle, userID); err != nil {
+			return fmt.Errorf("sqlite store: release handle: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListSavedProblems returns one page of the user's saved problems. Like the DynamoDB query
+// it evaluates up to the page size in descending saved problem ID order, applies the
+// status filter to that page, and returns the last evaluated ID whenever the page was full.
+func (s *SQLiteUserDataStore) ListSavedProblems(ctx context.Context, userID string, opts domain.SavedProblemListOptions) (domain.SavedProblemListResult, error) {
+	if s == nil {
+		return domain.SavedProblemListResult{}, api.ErrNotImplemented
+	}
+
+	limit := int(savedProblemPageSize(opts.Limit))
+	query := `SELECT ` + sqliteSavedProblemColumns + ` FROM saved_problems WHERE user_id = ?`
+	args := []any{userID}
+	if token := strings.TrimSpace(opts.NextToken); token != "" {
+		query += ` AND id < ?`
+		args = append(args, token)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return domain.SavedProblemListResult{}, fmt.Errorf("sqlite store: list saved problems: %w", err)
+	}
+	defer rows.Close()
+
+	var evaluated int
+	var lastID string
+	summaries := make([]domain.SavedProblemSummary, 0, limit)
+	for rows.Next() {
+		item, err := scanSQLiteSavedProblem(rows)
+		if err != nil {
+			return domain.SavedProblemListResult{}, fmt.Errorf("sqlite store: decode saved problems: %w", err)
+		}
+		evaluated++
+		lastID = item.SavedProblemID
+		if opts.Status != "" && item.Status != string(opts.Status) {
+			continue
+		}
+		summaries = append(summaries, toDomainSavedProblemSummary(item))
+	}
+	if err := rows.Err(); err != nil {
+		return domain.SavedProblemListResult{}, fmt.Errorf("sqlite store: list saved problems: %w", err)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt > summaries[j].UpdatedAt
+	})
+
+	result := domain.SavedProblemListResult{Items: summaries}
+	if evaluated == limit {
+		result.NextToken = lastID
+	}
+	return result, nil
+}
+
+// CreateSavedProblem persists a new saved problem for the user.
+func (s *SQLiteUserDataStore) CreateSavedProblem(ctx context.Context, userID string, input domain.SavedProblemCreateInput, now time.Time) (domain.SavedProblemSummary, error) {
+	if s == nil {
+		return domain.SavedProblemSummary{}, api.ErrNotImplemented
+	}
+
+	item, err := newSavedProblemItem(userID, input, now.UnixMilli())
+	if err != nil {
+		return domain.SavedProblemSummary{}, err
+	}
+	if err := withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		return s.putSavedProblemItem(ctx, tx, item)
+	}); err != nil {
+		return domain.SavedProblemSummary{}, err
+	}
+	return toDomainSavedProblemSummary(item), nil
+}
+
+// GetSavedProblem fetches saved problem detail with attempts.
+func (s *SQLiteUserDataStore) GetSavedProblem(ctx context.Context, userID, savedProblemID string) (domain.SavedProblemDetail, error) {
+	if s == nil {
+		return domain.SavedProblemDetail{}, api.ErrNotImplemented
+	}
+
+	item, err := s.fetchSavedProblemItem(ctx, s.db, userID, savedProblemID)
+	if err != nil {
+		return domain.SavedProblemDetail{}, err
+	}
+	attempts, err := s.listAttemptsInternal(ctx, item)
+	if err != nil {
+		return domain.SavedProblemDetail{}, err
+	}
+	return domain.SavedProblemDetail{
+		SavedProblemSummary: toDomainSavedProblemSummary(item),
+		Attempts:            attempts,
+	}, nil
+}
+
+// UpdateSavedProblem applies partial metadata updates.
+func (s *SQLiteUserDataStore) UpdateSavedProblem(ctx context.Context, userID, savedProblemID string, input domain.SavedProblemUpdateInput, now time.Time) (domain.SavedProblemSummary, error) {
+	if s == nil {
+		return domain.SavedProblemSummary{}, api.ErrNotImplemented
+	}
+
+	var item savedProblemItem
+	err := withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		item, err = s.fetchSavedProblemItem(ctx, tx, userID, savedProblemID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(item.Version, input.IfVersion); err != nil {
+			return err
+		}
+		item = applySavedProblemUpdate(item, input, now.UnixMilli())
+		return s.putSavedProblemItem(ctx, tx, item)
+	})
+	if err != nil {
+		return domain.SavedProblemSummary{}, err
+	}
+	return toDomainSavedProblemSummary(item), nil
+}
+
+// DeleteSavedProblem removes the saved problem; its attempt snapshots are removed by the
+// foreign key cascade.
+func (s *SQLiteUserDataStore) DeleteSavedProblem(ctx context.Context, userID, savedProblemID string) error {
+	if s == nil {
+		return api.ErrNotImplemented
+	}
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM saved_problems WHERE user_id = ? AND id = ?`, userID, savedProblemID)
+	if err != nil {
+		return fmt.Errorf("sqlite store: delete saved problem: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite store: delete saved problem: %w", err)
+	}
+	if rows == 0 {
+		return api.ErrNotFound
+	}
+	return nil
+}
+
+// AppendAttempt persists a new attempt snapshot and records it as the saved problem's last
+// attempt in one transaction.
+func (s *SQLiteUserDataStore) AppendAttempt(ctx context.Context, userID, savedProblemID string, input domain.SavedProblemAttemptInput, now time.Time) (domain.SavedAttemptSnapshot, error) {
+	if s == nil {
+		return domain.SavedAttemptSnapshot{}, api.ErrNotImplemented
+	}
+
+	var attemptItem savedAttemptItem
+	err := withSQLiteTx(ctx, s.db, func(tx *sql.Tx) error {
+		item, err := s.fetchSavedProblemItem(ctx, tx, userID, savedProblemID)
+		if err != nil {
+			return err
+		}
+		if err := checkAttemptOrder(item, now.UnixMilli()); err != nil {
+			return err
+		}
+		item, attemptItem = appendSavedAttempt(userID, item, input, now.UnixMilli())
+
+		var submittedAt sql.NullInt64
+		if attemptItem.SubmittedAt != nil {
+			submittedAt = sql.NullInt64{Int64: *attemptItem.SubmittedAt, Valid: true}
+		}
+		res, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO saved_problem_attempts
+			(saved_problem_id, sort_key, attempt_id, user_id, status, updated_at, pass_count, fail_count, runtime_ms, code, code_s3_key, submitted_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			attemptItem.SavedProblemID,
+			attemptItem.SK,
+			attemptItem.AttemptID,
+			attemptItem.UserID,
+			attemptItem.Status,
+			attemptItem.UpdatedAt,
+			attemptItem.PassCount,
+			attemptItem.FailCount,
+			attemptItem.RuntimeMS,
+			attemptItem.Code,
+			attemptItem.CodeS3Key,
+			submittedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("sqlite store: put attempt: %w", err)
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("sqlite store: put attempt: %w", err)
+		} else if rows == 0 {
+			return api.ErrBadRequest
+		}
+		return s.putSavedProblemItem(ctx, tx, item)
+	})
+	if err != nil {
+		return domain.SavedAttemptSnapshot{}, err
+	}
+	return toDomainAttemptSnapshot(attemptItem), nil
+}
+
+// ListAttempts returns attempt snapshots for a saved problem, newest first.
+func (s *SQLiteUserDataStore) ListAttempts(ctx context.Context, userID, savedProblemID string) ([]domain.SavedAttemptSnapshot, error) {
+	if s == nil {
+		return nil, api.ErrNotImplemented
+	}
+
+	item, err := s.fetchSavedProblemItem(ctx, s.db, userID, savedProblemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.listAttemptsInternal(ctx, item)
+}
+
+func (s *SQLiteUserDataStore) listAttemptsInternal(ctx context.Context, parent savedProblemItem) ([]domain.SavedAttemptSnapshot, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT attempt_id, status, updated_at, pass_count, fail_count, runtime_ms, code, code_s3_key, submitted_at
+		FROM saved_problem_attempts WHERE saved_problem_id = ? ORDER BY sort_key DESC`, parent.SavedProblemID)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []domain.SavedAttemptSnapshot{}
+	for rows.Next() {
+		var item savedAttemptItem
+		var submittedAt sql.NullInt64
+		if err := rows.Scan(&item.AttemptID, &item.Status, &item.UpdatedAt, &item.PassCount, &item.FailCount,
+			&item.RuntimeMS, &item.Code, &item.CodeS3Key, &submittedAt); err != nil {
+			return nil, fmt.Errorf("sqlite store: decode attempts: %w", err)
+		}
+		if submittedAt.Valid {
+			item.SubmittedAt = &submittedAt.Int64
+		}
+		snapshots = append(snapshots, toDomainAttemptSnapshot(item))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite store: list attempts: %w", err)
+	}
+	return snapshots, nil
+}
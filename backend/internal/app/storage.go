@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"improview/backend/internal/api"
+)
+
+// StorageBackend selects where problems, attempts, profiles and saved problems persist.
+type StorageBackend string
+
+const (
+	// StorageBackendMemory keeps everything in process memory.
+	StorageBackendMemory StorageBackend = "memory"
+	// StorageBackendDynamo uses the shared DynamoDB single table.
+	StorageBackendDynamo StorageBackend = "dynamodb"
+	// StorageBackendSQLite uses a local SQLite database file.
+	StorageBackendSQLite StorageBackend = "sqlite"
+)
+
+const defaultSQLitePath = "improview.db"
+
+// StorageOptions configures the storage backend. When Backend is empty DynamoDB is used if
+// TableName is set, otherwise memory.
+type StorageOptions struct {
+	Backend           StorageBackend
+	TableName         string
+	AttemptIndex      string
+	UserActivityIndex string
+	// SQLitePath is the database file for the SQLite backend (defaults to improview.db).
+	SQLitePath string
+}
+
+func parseStorageOptionsFromEnv() StorageOptions {
+	return StorageOptions{
+		Backend:           StorageBackend(strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_BACKEND")))),
+		TableName:         strings.TrimSpace(os.Getenv("TABLE_NAME")),
+		AttemptIndex:      strings.TrimSpace(os.Getenv("TABLE_INDEX_ATTEMPT_LOOKUP")),
+		UserActivityIndex: strings.TrimSpace(os.Getenv("TABLE_INDEX_USER_ACTIVITY")),
+		SQLitePath:        strings.TrimSpace(os.Getenv("SQLITE_PATH")),
+	}
+}
+
+// storeSet groups the stores of one backend.
+type storeSet struct {
+	problems      api.ProblemRepository
+	attempts      api.AttemptStore
+	profiles      api.UserProfileStore
+	savedProblems api.SavedProblemStore
+}
+
+func newStores(ctx context.Context, options StorageOptions, clock api.Clock) (storeSet, error) {
+	backend := options.Backend
+	if backend == "" {
+		backend = StorageBackendMemory
+		if options.TableName != "" {
+			backend = StorageBackendDynamo
+		}
+	}
+
+	switch backend {
+	case StorageBackendMemory:
+		userData := NewMemoryUserDataStore()
+		return storeSet{
+			problems:      NewMemoryProblemRepository(),
+			attempts:      NewMemoryAttemptStore(clock),
+			profiles:      userData,
+			savedProblems: userData,
+		}, nil
+
+	case StorageBackendDynamo:
+		if options.TableName == "" {
+			return storeSet{}, fmt.Errorf("storage: %s backend requires TABLE_NAME", backend)
+		}
+		client, err := NewDynamoClientFromEnv(ctx)
+		if err != nil {
+			return storeSet{}, err
+		}
+		userData := NewDynamoUserDataStore(client, options.TableName, options.AttemptIndex, options.UserActivityIndex)
+		problems, err := NewDynamoProblemRepository(client, options.TableName)
+		if err != nil {
+			return storeSet{}, err
+		}
+		attempts, err := NewDynamoAttemptStore(client, options.TableName, options.AttemptIndex, clock)
+		if err != nil {
+			return storeSet{}, err
+		}
+		return storeSet{problems: problems, attempts: attempts, profiles: userData, savedProblems: userData}, nil
+
+	case StorageBackendSQLite:
+		db, err := OpenSQLiteDB(ctx, defaultString(options.SQLitePath, defaultSQLitePath))
+		if err != nil {
+			return storeSet{}, err
+		}
+		userData, err := NewSQLiteUserDataStore(db)
+		if err != nil {
+			return storeSet{}, err
+		}
+		problems, err := NewSQLiteProblemRepository(db)
+		if err != nil {
+			return storeSet{}, err
+		}
+		attempts, err := NewSQLiteAttemptStore(db, clock)
+		if err != nil {
+			return storeSet{}, err
+		}
+		return storeSet{problems: problems, attempts: attempts, profiles: userData, savedProblems: userData}, nil
+
+	default:
+		return storeSet{}, fmt.Errorf("storage: unknown STORAGE_BACKEND %q (want memory, dynamodb or sqlite)", backend)
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"improview/backend/internal/storetest"
+)
+
+func TestMemoryStoresConformance(t *testing.T) {
+	storetest.Run(t, func(*testing.T) storetest.Stores {
+		userData := NewMemoryUserDataStore()
+		return storetest.Stores{
+			Profiles:      userData,
+			SavedProblems: userData,
+			Attempts:      NewMemoryAttemptStore(nil),
+			Problems:      NewMemoryProblemRepository(),
+		}
+	})
+}
+
+// TestDynamoStoresConformance runs the suite against a DynamoDB-compatible endpoint, e.g.
+//
+//	docker run -p 8000:8000 amazon/dynamodb-local
+//	DYNAMODB_ENDPOINT=http://localhost:8000 AWS_REGION=us-east-1 \
+//	  AWS_ACCESS_KEY_ID=local AWS_SECRET_ACCESS_KEY=local go test ./internal/app -run Dynamo
+//
+// Every subtest gets its own table, which is deleted afterwards.
+func TestDynamoStoresConformance(t *testing.T) {
+	if strings.TrimSpace(os.Getenv("DYNAMODB_ENDPOINT")) == "" {
+		t.Skip("DYNAMODB_ENDPOINT not set")
+	}
+
+	client, err := NewDynamoClientFromEnv(context.Background())
+	if err != nil {
+		t.Fatalf("dynamo client: %v", err)
+	}
+
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		tableName := createConformanceTable(t, client)
+		userData := NewDynamoUserDataStore(client, tableName, "", "")
+		attempts, err := NewDynamoAttemptStore(client, tableName, "", nil)
+		if err != nil {
+			t.Fatalf("attempt store: %v", err)
+		}
+		problems, err := NewDynamoProblemRepository(client, tableName)
+		if err != nil {
+			t.Fatalf("problem repository: %v", err)
+		}
+		return storetest.Stores{
+			Profiles:      userData,
+			SavedProblems: userData,
+			Attempts:      attempts,
+			Problems:      problems,
+		}
+	})
+}
+
+// createConformanceTable creates a table shaped like the deployed one (pk/sk with the gsi1
+// and gsi2 indexes).
+func createConformanceTable(t *testing.T, client *dynamodb.Client) string {
+	t.Helper()
+	ctx := context.Background()
+	tableName := fmt.Sprintf("improview-conformance-%s", randomID())
+
+	keyAttr := func(name string) types.AttributeDefinition {
+		return types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: types.ScalarAttributeTypeS}
+	}
+	keySchema := func(hash, rangeKey string) []types.KeySchemaElement {
+		return []types.KeySchemaElement{
+			{AttributeName: aws.String(hash), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(rangeKey), KeyType: types.KeyTypeRange},
+		}
+	}
+	index := func(name string) types.GlobalSecondaryIndex {
+		return types.GlobalSecondaryIndex{
+			IndexName:  aws.String(name),
+			KeySchema:  keySchema(name+"pk", name+"sk"),
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}
+	}
+
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String(tableName),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			keyAttr("pk"), keyAttr("sk"), keyAttr("gsi1pk"), keyAttr("gsi1sk"), keyAttr("gsi2pk"), keyAttr("gsi2sk"),
+		},
+		KeySchema:              keySchema("pk", "sk"),
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{index(defaultAttemptIndex), index(defaultUserActivityIndex)},
+	})
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := client.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(tableName)}); err != nil {
+			t.Logf("delete table %s: %v", tableName, err)
+		}
+	})
+
+	waiter := dynamodb.NewTableExistsWaiter(client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, time.Minute); err != nil {
+		t.Fatalf("wait for table: %v", err)
+	}
+	return tableName
+}
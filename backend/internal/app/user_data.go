@@ -0,0 +1,323 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+// MemoryUserDataStore keeps profiles, saved problems and their attempt snapshots in
+// memory. It builds the same rows as DynamoUserDataStore and mirrors its key ordering, so
+// listing, filtering and pagination behave identically without AWS.
+type MemoryUserDataStore struct {
+	mu       sync.RWMutex
+	profiles map[string]domain.UserProfile
+	// handles maps each reserved handle to the user that holds it.
+	handles map[string]string
+	// saved is keyed by user ID, then saved problem ID.
+	saved map[string]map[string]savedProblemItem
+	// attempts is keyed by saved problem ID, then attempt sort key.
+	attempts map[string]map[string]savedAttemptItem
+}
+
+// NewMemoryUserDataStore constructs an empty MemoryUserDataStore.
+func NewMemoryUserDataStore() *MemoryUserDataStore {
+	return &MemoryUserDataStore{
+		profiles: make(map[string]domain.UserProfile),
+		handles:  make(map[string]string),
+		saved:    make(map[string]map[string]savedProblemItem),
+		attempts: make(map[string]map[string]savedAttemptItem),
+	}
+}
+
+// GetProfile retrieves the stored profile for the given user.
+func (s *MemoryUserDataStore) GetProfile(_ context.Context, userID string) (domain.UserProfile, error) {
+	if s == nil {
+		return domain.UserProfile{}, api.ErrNotImplemented
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[userID]
+	if !ok {
+		return domain.UserProfile{}, api.ErrNotFound
+	}
+	return cloneProfile(profile), nil
+}
+
+// GetProfileByHandle returns the profile holding the handle.
+func (s *MemoryUserDataStore) GetProfileByHandle(_ context.Context, handle string) (domain.UserProfile, error) {
+	if s == nil {
+		return domain.UserProfile{}, api.ErrNotImplemented
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[s.handles[handle]]
+	if !ok || profile.Handle != handle {
+		return domain.UserProfile{}, api.ErrNotFound
+	}
+	return cloneProfile(profile), nil
+}
+
+// UpsertProfile stores or updates the user's profile, reserving its handle and releasing
+// a replaced one.
+func (s *MemoryUserDataStore) UpsertProfile(_ context.Context, userID string, update domain.UserProfileUpdate, now time.Time) (domain.UserProfile, error) {
+	if s == nil {
+		return domain.UserProfile{}, api.ErrNotImplemented
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.profiles[userID]
+	if err := checkVersion(existing.Version, update.IfVersion); err != nil {
+		return domain.UserProfile{}, err
+	}
+	update, err := normalizeProfileUpdate(update)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	profile := applyProfileUpdate(cloneProfile(existing), userID, update, now.UnixMilli())
+
+	if profile.Handle != "" {
+		if owner, ok := s.handles[profile.Handle]; ok && owner != userID {
+			return domain.UserProfile{}, errHandleTaken(profile.Handle)
+		}
+		s.handles[profile.Handle] = userID
+	}
+	if old := existing.Handle; old != "" && old != profile.Handle && s.handles[old] == userID {
+		delete(s.handles, old)
+	}
+	s.profiles[userID] = cloneProfile(profile)
+	return profile, nil
+}
+
+// ListSavedProblems returns one page of the user's saved problems. Like the DynamoDB query
+// it evaluates up to the page size in descending saved problem ID order, applies the
+// status filter to that page, and returns the last evaluated ID as the next token whenever
+// the page was full.
+func (s *MemoryUserDataStore) ListSavedProblems(_ context.Context, userID string, opts domain.SavedProblemListOptions) (domain.SavedProblemListResult, error) {
+	if s == nil {
+		return domain.SavedProblemListResult{}, api.ErrNotImplemented
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.saved[userID]
+	ids := make([]string, 0, len(items))
+	token := strings.TrimSpace(opts.NextToken)
+	for id := range items {
+		if token == "" || id < token {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	var nextToken string
+	if limit := int(savedProblemPageSize(opts.Limit)); len(ids) >= limit {
+		ids = ids[:limit]
+		nextToken = ids[limit-1]
+	}
+
+	summaries := make([]domain.SavedProblemSummary, 0, len(ids))
+	for _, id := range ids {
+		item := items[id]
+		if opts.Status != "" && item.Status != string(opts.Status) {
+			continue
+		}
+		summaries = append(summaries, toDomainSavedProblemSummary(item))
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt > summaries[j].UpdatedAt
+	})
+
+	return domain.SavedProblemListResult{
+		Items:     summaries,
+		NextToken: nextToken,
+	}, nil
+}
+
+// CreateSavedProblem stores a new saved problem for the user.
+func (s *MemoryUserDataStore) CreateSavedProblem(_ context.Context, userID string, input domain.SavedProblemCreateInput, now time.Time) (domain.SavedProblemSummary, error) {
+	if s == nil {
+		return domain.SavedProblemSummary{}, api.ErrNotImplemented
+	}
+
+	item, err := newSavedProblemItem(userID, input, now.UnixMilli())
+	if err != nil {
+		return domain.SavedProblemSummary{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putSavedProblemItem(item)
+	return toDomainSavedProblemSummary(item), nil
+}
+
+// GetSavedProblem fetches saved problem detail with attempts, newest first.
+func (s *MemoryUserDataStore) GetSavedProblem(_ context.Context, userID, savedProblemID string) (domain.SavedProblemDetail, error) {
+	if s == nil {
+		return domain.SavedProblemDetail{}, api.ErrNotImplemented
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, err := s.fetchSavedProblemItem(userID, savedProblemID)
+	if err != nil {
+		return domain.SavedProblemDetail{}, err
+	}
+	return domain.SavedProblemDetail{
+		SavedProblemSummary: toDomainSavedProblemSummary(item),
+		Attempts:            s.listAttemptsInternal(item),
+	}, nil
+}
+
+// UpdateSavedProblem applies partial metadata updates.
+func (s *MemoryUserDataStore) UpdateSavedProblem(_ context.Context, userID, savedProblemID string, input domain.SavedProblemUpdateInput, now time.Time) (domain.SavedProblemSummary, error) {
+	if s == nil {
+		return domain.SavedProblemSummary{}, api.ErrNotImplemented
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.fetchSavedProblemItem(userID, savedProblemID)
+	if err != nil {
+		return domain.SavedProblemSummary{}, err
+	}
+	if err := checkVersion(item.Version, input.IfVersion); err != nil {
+		return domain.SavedProblemSummary{}, err
+	}
+	item = applySavedProblemUpdate(item, input, now.UnixMilli())
+	s.putSavedProblemItem(item)
+	return toDomainSavedProblemSummary(item), nil
+}
+
+// DeleteSavedProblem removes the saved problem and all associated attempts.
+func (s *MemoryUserDataStore) DeleteSavedProblem(_ context.Context, userID, savedProblemID string) error {
+	if s == nil {
+		return api.ErrNotImplemented
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.fetchSavedProblemItem(userID, savedProblemID)
+	if err != nil {
+		return err
+	}
+	delete(s.attempts, item.SavedProblemID)
+	delete(s.saved[userID], item.SavedProblemID)
+	if len(s.saved[userID]) == 0 {
+		delete(s.saved, userID)
+	}
+	return nil
+}
+
+// AppendAttempt stores a new attempt snapshot and records it as the saved problem's last
+// attempt. Appending the same attempt ID twice in one millisecond is rejected.
+func (s *MemoryUserDataStore) AppendAttempt(_ context.Context, userID, savedProblemID string, input domain.SavedProblemAttemptInput, now time.Time) (domain.SavedAttemptSnapshot, error) {
+	if s == nil {
+		return domain.SavedAttemptSnapshot{}, api.ErrNotImplemented
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.fetchSavedProblemItem(userID, savedProblemID)
+	if err != nil {
+		return domain.SavedAttemptSnapshot{}, err
+	}
+	if err := checkAttemptOrder(item, now.UnixMilli()); err != nil {
+		return domain.SavedAttemptSnapshot{}, err
+	}
+
+	item, attemptItem := appendSavedAttempt(userID, item, input, now.UnixMilli())
+	attempts := s.attempts[item.SavedProblemID]
+	if _, exists := attempts[attemptItem.SK]; exists {
+		return domain.SavedAttemptSnapshot{}, api.ErrBadRequest
+	}
+	if attempts == nil {
+		attempts = make(map[string]savedAttemptItem)
+		s.attempts[item.SavedProblemID] = attempts
+	}
+	attempts[attemptItem.SK] = attemptItem
+	s.putSavedProblemItem(item)
+
+	return toDomainAttemptSnapshot(attemptItem), nil
+}
+
+// ListAttempts returns attempt snapshots for a saved problem, newest first.
+func (s *MemoryUserDataStore) ListAttempts(_ context.Context, userID, savedProblemID string) ([]domain.SavedAttemptSnapshot, error) {
+	if s == nil {
+		return nil, api.ErrNotImplemented
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, err := s.fetchSavedProblemItem(userID, savedProblemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.listAttemptsInternal(item), nil
+}
+
+// fetchSavedProblemItem returns a copy of the stored row; callers hold the lock.
+func (s *MemoryUserDataStore) fetchSavedProblemItem(userID, savedProblemID string) (savedProblemItem, error) {
+	item, ok := s.saved[userID][savedProblemID]
+	if !ok {
+		return savedProblemItem{}, api.ErrNotFound
+	}
+	item.Tags = append([]string(nil), item.Tags...)
+	return item, nil
+}
+
+// putSavedProblemItem stores the row; callers hold the write lock.
+func (s *MemoryUserDataStore) putSavedProblemItem(item savedProblemItem) {
+	items := s.saved[item.UserID]
+	if items == nil {
+		items = make(map[string]savedProblemItem)
+		s.saved[item.UserID] = items
+	}
+	items[item.SavedProblemID] = item
+}
+
+// listAttemptsInternal orders snapshots by descending sort key, as the DynamoDB query does.
+func (s *MemoryUserDataStore) listAttemptsInternal(parent savedProblemItem) []domain.SavedAttemptSnapshot {
+	attempts := s.attempts[parent.SavedProblemID]
+	keys := make([]string, 0, len(attempts))
+	for key := range attempts {
+		keys = append(keys, key)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	snapshots := make([]domain.SavedAttemptSnapshot, 0, len(keys))
+	for _, key := range keys {
+		snapshots = append(snapshots, toDomainAttemptSnapshot(attempts[key]))
+	}
+	return snapshots
+}
+
+func cloneProfile(profile domain.UserProfile) domain.UserProfile {
+	if profile.Preferences != nil {
+		prefs := make(map[string]string, len(profile.Preferences))
+		for key, value := range profile.Preferences {
+			prefs[key] = value
+		}
+		profile.Preferences = prefs
+	}
+	return profile
+}
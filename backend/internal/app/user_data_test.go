@@ -0,0 +1,229 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+)
+
+func TestMemoryUserDataStorePagesSavedProblemsLikeDynamo(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryUserDataStore()
+	now := time.UnixMilli(1_700_000_000_000)
+
+	created := make(map[string]domain.SavedProblemSummary)
+	for i := 0; i < 5; i++ {
+		status := domain.SavedProblemStatusInProgress
+		if i%2 == 1 {
+			status = domain.SavedProblemStatusCompleted
+		}
+		summary, err := store.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{
+			ProblemID: fmt.Sprintf("prob_%d", i),
+			Language:  "javascript",
+			Status:    status,
+		}, now.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("create saved problem: %v", err)
+		}
+		created[summary.ID] = summary
+	}
+	if _, err := store.CreateSavedProblem(ctx, "user-2", domain.SavedProblemCreateInput{ProblemID: "prob_x", Language: "go"}, now); err != nil {
+		t.Fatalf("create saved problem for other user: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	opts := domain.SavedProblemListOptions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("pagination did not terminate")
+		}
+		result, err := store.ListSavedProblems(ctx, "user-1", opts)
+		if err != nil {
+			t.Fatalf("list saved problems: %v", err)
+		}
+		for i, item := range result.Items {
+			if _, ok := created[item.ID]; !ok || seen[item.ID] {
+				t.Fatalf("unexpected or repeated item %s", item.ID)
+			}
+			if i > 0 && item.UpdatedAt > result.Items[i-1].UpdatedAt {
+				t.Fatalf("expected newest first within a page")
+			}
+			seen[item.ID] = true
+		}
+		if result.NextToken == "" {
+			break
+		}
+		opts.NextToken = result.NextToken
+	}
+	if len(seen) != len(created) {
+		t.Fatalf("expected %d saved problems across pages, saw %d", len(created), len(seen))
+	}
+
+	// The status filter applies after the limit, as DynamoDB's FilterExpression does.
+	filtered, err := store.ListSavedProblems(ctx, "user-1", domain.SavedProblemListOptions{Status: domain.SavedProblemStatusCompleted, Limit: 1})
+	if err != nil {
+		t.Fatalf("list filtered: %v", err)
+	}
+	if len(filtered.Items) > 1 || filtered.NextToken == "" {
+		t.Fatalf("expected a single evaluated item and a next token, got %+v", filtered)
+	}
+	all, err := store.ListSavedProblems(ctx, "user-1", domain.SavedProblemListOptions{Status: domain.SavedProblemStatusCompleted})
+	if err != nil {
+		t.Fatalf("list completed: %v", err)
+	}
+	if len(all.Items) != 2 || all.NextToken != "" {
+		t.Fatalf("expected two completed problems on one page, got %+v", all)
+	}
+}
+
+func TestMemoryUserDataStoreSavedProblemLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryUserDataStore()
+	now := time.UnixMilli(1_700_000_000_000)
+
+	if _, err := store.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, now); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected bad request without language, got %v", err)
+	}
+
+	saved, err := store.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{
+		ProblemID: " prob_1 ",
+		Language:  "javascript",
+		Title:     "  Two Sum ",
+		Tags:      []string{"graphs", " Arrays ", "GRAPHS", ""},
+	}, now)
+	if err != nil {
+		t.Fatalf("create saved problem: %v", err)
+	}
+	if saved.ProblemID != "prob_1" || saved.Title != "Two Sum" || saved.Status != domain.SavedProblemStatusInProgress {
+		t.Fatalf("unexpected summary %+v", saved)
+	}
+	if !reflect.DeepEqual(saved.Tags, []string{"Arrays", "graphs"}) {
+		t.Fatalf("expected normalized tags, got %v", saved.Tags)
+	}
+
+	if _, err := store.GetSavedProblem(ctx, "user-2", saved.ID); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("expected not found for another user, got %v", err)
+	}
+
+	notes := " remember the edge cases "
+	updated, err := store.UpdateSavedProblem(ctx, "user-1", saved.ID, domain.SavedProblemUpdateInput{Notes: &notes}, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("update saved problem: %v", err)
+	}
+	if updated.Notes != "remember the edge cases" || !reflect.DeepEqual(updated.Tags, saved.Tags) || updated.UpdatedAt != now.Add(time.Second).UnixMilli() {
+		t.Fatalf("expected partial update, got %+v", updated)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if _, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{
+			AttemptID: fmt.Sprintf("attempt-%d", i),
+			Code:      "code",
+			PassCount: i,
+		}, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("append attempt: %v", err)
+		}
+	}
+	if _, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{AttemptID: "attempt-2"}, now.Add(2*time.Minute)); !errors.Is(err, api.ErrBadRequest) {
+		t.Fatalf("expected duplicate attempt to be rejected, got %v", err)
+	}
+
+	detail, err := store.GetSavedProblem(ctx, "user-1", saved.ID)
+	if err != nil {
+		t.Fatalf("get saved problem: %v", err)
+	}
+	if len(detail.Attempts) != 2 || detail.Attempts[0].AttemptID != "attempt-2" || detail.Attempts[0].Status != domain.SavedAttemptStatusSubmitted {
+		t.Fatalf("expected newest attempt first, got %+v", detail.Attempts)
+	}
+	if detail.LastAttempt == nil || detail.LastAttempt.AttemptID != "attempt-2" || detail.LastAttempt.PassCount != 2 {
+		t.Fatalf("expected last attempt on summary, got %+v", detail.LastAttempt)
+	}
+
+	if err := store.DeleteSavedProblem(ctx, "user-1", saved.ID); err != nil {
+		t.Fatalf("delete saved problem: %v", err)
+	}
+	if _, err := store.ListAttempts(ctx, "user-1", saved.ID); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("expected attempts to be gone, got %v", err)
+	}
+	if len(store.attempts) != 0 {
+		t.Fatalf("expected attempt snapshots to be deleted with their saved problem")
+	}
+}
+
+func TestMemoryUserDataStoreConcurrentAppends(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryUserDataStore()
+	now := time.UnixMilli(1_700_000_000_000)
+
+	saved, err := store.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1", Language: "go"}, now)
+	if err != nil {
+		t.Fatalf("create saved problem: %v", err)
+	}
+
+	const writers = 20
+	var clock atomic.Int64
+	clock.Store(now.UnixMilli())
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				_, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{}, time.UnixMilli(clock.Add(1)))
+				if errors.Is(err, api.ErrRetryable) {
+					continue
+				}
+				if err != nil {
+					t.Errorf("append attempt: %v", err)
+				}
+				return
+			}
+		}()
+	}
+	wg.Wait()
+
+	attempts, err := store.ListAttempts(ctx, "user-1", saved.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	if len(attempts) != writers {
+		t.Fatalf("expected %d attempts, got %d", writers, len(attempts))
+	}
+}
+
+func TestMemoryUserDataStoreProfiles(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryUserDataStore()
+	now := time.UnixMilli(1_700_000_000_000)
+
+	if _, err := store.GetProfile(ctx, "user-1"); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("expected not found before upsert, got %v", err)
+	}
+
+	handle := " ada "
+	created, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{Handle: &handle, Preferences: map[string]string{"theme": "dark"}}, now)
+	if err != nil {
+		t.Fatalf("upsert profile: %v", err)
+	}
+	created.Preferences["theme"] = "mutated"
+
+	bio := "hello"
+	updated, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{Bio: &bio}, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	if updated.Handle != "ada" || updated.Bio != "hello" || updated.Preferences["theme"] != "dark" {
+		t.Fatalf("expected partial update over stored profile, got %+v", updated)
+	}
+	if updated.CreatedAt != now.UnixMilli() || updated.UpdatedAt != now.Add(time.Second).UnixMilli() {
+		t.Fatalf("unexpected timestamps %+v", updated)
+	}
+}
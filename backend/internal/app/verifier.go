@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+	"improview/backend/internal/sandbox"
+)
+
+const (
+	defaultMinVerifiedPublic = 1
+	defaultMinVerifiedHidden = 1
+)
+
+// VerifyingProblemGenerator runs every reference solution of a generated pack against its
+// public and hidden tests, graded by the pack's comparator, before the pack is accepted.
+// Tests whose stated output the solutions contradict are corrected when two or more
+// solutions agree on a different value and dropped otherwise; any-of and custom-checker
+// tests cannot be corrected and are dropped. The pack is rejected when fewer than MinPublic
+// public or MinHidden hidden tests survive.
+type VerifyingProblemGenerator struct {
+	Next      api.ProblemGenerator
+	Executor  sandbox.Executor
+	Limits    sandbox.Limits
+	MinPublic int
+	MinHidden int
+}
+
+// NewVerifyingProblemGenerator wraps next with verification in the embedded JavaScript sandbox.
+func NewVerifyingProblemGenerator(next api.ProblemGenerator, limits sandbox.Limits) (*VerifyingProblemGenerator, error) {
+	if next == nil {
+		return nil, errors.New("problem verifier: generator is required")
+	}
+	return &VerifyingProblemGenerator{
+		Next:      next,
+		Executor:  sandbox.NewJavaScript(),
+		Limits:    limits,
+		MinPublic: defaultMinVerifiedPublic,
+		MinHidden: defaultMinVerifiedHidden,
+	}, nil
+}
+
+// Generate produces a pack with the wrapped generator and verifies it. The report is
+// attached to the generation metadata.
+func (g *VerifyingProblemGenerator) Generate(ctx context.Context, req api.GenerateRequest) (domain.GeneratedProblem, error) {
+	if g == nil || g.Next == nil || g.Executor == nil {
+		return domain.GeneratedProblem{}, api.ErrNotImplemented
+	}
+
+	generated, err := g.Next.Generate(ctx, req)
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+
+	pack, report, err := g.verify(ctx, generated.Pack)
+	if err != nil {
+		return domain.GeneratedProblem{}, err
+	}
+	generated.Pack = pack
+	generated.Metadata.Verification = &report
+
+	public, hidden := len(pack.Tests.Public), len(pack.Tests.Hidden)
+	if public < g.MinPublic || hidden < g.MinHidden {
+		return domain.GeneratedProblem{}, fmt.Errorf("%w: problem verifier: only %d public and %d hidden tests match the reference solutions (need %d and %d)",
+			api.ErrUpstream, public, hidden, g.MinPublic, g.MinHidden)
+	}
+	return generated, nil
+}
+
+func (g *VerifyingProblemGenerator) verify(ctx context.Context, pack domain.ProblemPack) (domain.ProblemPack, domain.VerificationReport, error) {
+	cases := pack.Tests.Cases()
+	report := domain.VerificationReport{Solutions: len(pack.Solutions), Checked: len(cases)}
+	if len(cases) == 0 {
+		return pack, report, nil
+	}
+
+	inputs := make([][]any, len(cases))
+	for i, tc := range cases {
+		inputs[i] = tc.Input
+	}
+
+	// outcomes[s][i] is solution s run against test i.
+	outcomes := make([][]sandbox.Result, len(pack.Solutions))
+	for s, solution := range pack.Solutions {
+		results, err := g.Executor.Execute(ctx, sandbox.Request{
+			Code:         solution.Code,
+			FunctionName: pack.API.FunctionName,
+			Inputs:       inputs,
+			Limits:       g.Limits,
+		})
+		if err != nil {
+			return domain.ProblemPack{}, domain.VerificationReport{}, fmt.Errorf("problem verifier: execute solution %d: %w", s, err)
+		}
+		if len(results) != len(cases) {
+			return domain.ProblemPack{}, domain.VerificationReport{}, fmt.Errorf("problem verifier: expected %d results, got %d", len(cases), len(results))
+		}
+		outcomes[s] = results
+	}
+
+	comparator := newOutputComparator(pack.Comparator, g.Executor, g.Limits)
+	verdicts, err := gradeSolutions(ctx, comparator, cases, outcomes)
+	if err != nil {
+		return domain.ProblemPack{}, domain.VerificationReport{}, fmt.Errorf("problem verifier: %w", err)
+	}
+
+	verified := domain.TestSuite{Public: []domain.Example{}, Hidden: []domain.Example{}}
+	for i, tc := range cases {
+		perSolution := make([]sandbox.Result, len(outcomes))
+		for s := range outcomes {
+			perSolution[s] = outcomes[s][i]
+		}
+
+		example := domain.Example{Input: tc.Input, Output: tc.Output}
+		output, reason := judgeTest(comparator, perSolution, verdicts[i])
+		switch {
+		case reason == "":
+			report.Verified++
+		case output != nil:
+			example.Output = output
+			report.Corrected = append(report.Corrected, domain.VerificationIssue{TestID: tc.ID, Reason: reason})
+		default:
+			report.Dropped = append(report.Dropped, domain.VerificationIssue{TestID: tc.ID, Reason: reason})
+			continue
+		}
+
+		if tc.Set == domain.TestSetHidden {
+			verified.Hidden = append(verified.Hidden, example)
+		} else {
+			verified.Public = append(verified.Public, example)
+		}
+	}
+
+	pack.Tests = verified
+	return pack, report, nil
+}
+
+// gradeSolutions grades every value the solutions returned against the stated outputs in
+// one batch. verdicts[i][s] is solution s against test i.
+func gradeSolutions(ctx context.Context, comparator outputComparator, cases []domain.TestCase, outcomes [][]sandbox.Result) ([][]outputVerdict, error) {
+	type position struct{ test, solution int }
+	var checks []outputCheck
+	var positions []position
+	for s, results := range outcomes {
+		for i, result := range results {
+			if result.Status == sandbox.StatusOK {
+				checks = append(checks, outputCheck{Input: cases[i].Input, Expected: cases[i].Output, Actual: result.Value})
+				positions = append(positions, position{test: i, solution: s})
+			}
+		}
+	}
+
+	graded, err := comparator.compare(ctx, checks)
+	if err != nil {
+		return nil, err
+	}
+	verdicts := make([][]outputVerdict, len(cases))
+	for i := range verdicts {
+		verdicts[i] = make([]outputVerdict, len(outcomes))
+	}
+	for k, at := range positions {
+		verdicts[at.test][at.solution] = graded[k]
+	}
+	return verdicts, nil
+}
+
+// judgeTest weighs what the solutions returned and the comparator's verdicts against the
+// stated output. An empty reason means the test is verified as stated; a non-nil output is
+// the corrected value.
+func judgeTest(comparator outputComparator, results []sandbox.Result, verdicts []outputVerdict) (any, string) {
+	if len(results) == 0 {
+		return nil, domain.VerificationSolutionError
+	}
+	for _, result := range results {
+		switch result.Status {
+		case sandbox.StatusOK:
+		case sandbox.StatusTimeout:
+			return nil, domain.VerificationSolutionTimeout
+		default:
+			return nil, domain.VerificationSolutionError
+		}
+	}
+
+	matchesStated := 0
+	for _, verdict := range verdicts {
+		if verdict.Err != "" {
+			return nil, domain.VerificationCheckerError
+		}
+		if verdict.Match {
+			matchesStated++
+		}
+	}
+	if matchesStated == len(results) {
+		return nil, ""
+	}
+	if !comparator.correctable() {
+		return nil, domain.VerificationOutputMismatch
+	}
+
+	for _, result := range results[1:] {
+		if !comparator.matches(results[0].Value, result.Value) {
+			return nil, domain.VerificationSolutionsDisagree
+		}
+	}
+	// A lone solution contradicting the stated output is as likely wrong as the test.
+	if len(results) < 2 {
+		return nil, domain.VerificationOutputMismatch
+	}
+	corrected, err := normalizeJSON(results[0].Value)
+	if err != nil || corrected == nil {
+		return nil, domain.VerificationOutputMismatch
+	}
+	return corrected, domain.VerificationOutputMismatch
+}
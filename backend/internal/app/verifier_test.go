@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/domain"
+	"improview/backend/internal/sandbox"
+)
+
+type packGenerator struct {
+	pack domain.ProblemPack
+}
+
+func (g packGenerator) Generate(context.Context, api.GenerateRequest) (domain.GeneratedProblem, error) {
+	return domain.GeneratedProblem{Pack: g.pack, Metadata: domain.GenerationMetadata{Generator: "test"}}, nil
+}
+
+func sumPack(solutions ...string) domain.ProblemPack {
+	pack := domain.ProblemPack{
+		API: domain.APISignature{FunctionName: "sum", Params: []domain.APIParam{{Name: "a"}, {Name: "b"}}},
+		Tests: domain.TestSuite{
+			Public: []domain.Example{{Input: []any{1, 2}, Output: 3}},
+			Hidden: []domain.Example{
+				{Input: []any{2, 2}, Output: 4},
+				{Input: []any{5, 5}, Output: 11},
+			},
+		},
+	}
+	for _, code := range solutions {
+		pack.Solutions = append(pack.Solutions, domain.SolutionOutline{Code: code})
+	}
+	return pack
+}
+
+func newTestVerifier(t *testing.T, pack domain.ProblemPack) *VerifyingProblemGenerator {
+	t.Helper()
+	verifier, err := NewVerifyingProblemGenerator(packGenerator{pack: pack}, sandbox.Limits{})
+	if err != nil {
+		t.Fatalf("create verifier: %v", err)
+	}
+	return verifier
+}
+
+func TestVerifierDropsTestsContradictedBySingleSolution(t *testing.T) {
+	verifier := newTestVerifier(t, sumPack("function sum(a, b) { return a + b; }"))
+
+	generated, err := verifier.Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if len(generated.Pack.Tests.Hidden) != 1 || generated.Pack.Tests.Hidden[0].Output != 4 {
+		t.Fatalf("expected the wrong hidden test to be dropped, got %+v", generated.Pack.Tests.Hidden)
+	}
+	report := generated.Metadata.Verification
+	if report == nil {
+		t.Fatalf("expected verification report in metadata")
+	}
+	if report.Checked != 3 || report.Verified != 2 || len(report.Dropped) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Dropped[0] != (domain.VerificationIssue{TestID: "hidden_2", Reason: domain.VerificationOutputMismatch}) {
+		t.Fatalf("unexpected dropped test %+v", report.Dropped[0])
+	}
+}
+
+func TestVerifierCorrectsOutputWhenSolutionsAgree(t *testing.T) {
+	verifier := newTestVerifier(t, sumPack(
+		"function sum(a, b) { return a + b; }",
+		"function sum(a, b) { return [a, b].reduce((x, y) => x + y, 0); }",
+	))
+
+	generated, err := verifier.Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	hidden := generated.Pack.Tests.Hidden
+	if len(hidden) != 2 || !outputsEqual(hidden[1].Output, 10) {
+		t.Fatalf("expected hidden_2 to be corrected to 10, got %+v", hidden)
+	}
+	report := generated.Metadata.Verification
+	if len(report.Corrected) != 1 || report.Corrected[0].TestID != "hidden_2" || report.Kept() != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestVerifierDropsTestsWhenSolutionsDisagree(t *testing.T) {
+	verifier := newTestVerifier(t, sumPack(
+		"function sum(a, b) { return a + b; }",
+		"function sum(a, b) { return a === 5 ? 99 : a + b; }",
+	))
+
+	generated, err := verifier.Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	report := generated.Metadata.Verification
+	if len(report.Dropped) != 1 || report.Dropped[0].Reason != domain.VerificationSolutionsDisagree {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestVerifierRejectsPackWithTooFewVerifiedTests(t *testing.T) {
+	verifier := newTestVerifier(t, sumPack("function sum(a, b) { throw new Error('boom'); }"))
+
+	_, err := verifier.Generate(context.Background(), api.GenerateRequest{})
+	if !errors.Is(err, api.ErrUpstream) {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+}
+
+func TestVerifierGradesWithThePackComparator(t *testing.T) {
+	pack := sumPack("function sum(a, b) { return [a, b]; }")
+	pack.Comparator = &domain.ComparatorSpec{Mode: domain.ComparatorAnyOf}
+	pack.Tests.Public[0].Output = [][]int{{1, 2}, {2, 1}}
+	pack.Tests.Hidden[0].Output = [][]int{{2, 2}}
+	pack.Tests.Hidden[1].Output = [][]int{{5, 6}}
+
+	generated, err := newTestVerifier(t, pack).Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	report := generated.Metadata.Verification
+	if report.Verified != 2 || len(report.Dropped) != 1 || report.Dropped[0] != (domain.VerificationIssue{TestID: "hidden_2", Reason: domain.VerificationOutputMismatch}) {
+		t.Fatalf("expected any-of outputs to be verified and the contradicted one dropped, got %+v", report)
+	}
+}
+
+func TestVerifierDropsTestsWhenTheCheckerFails(t *testing.T) {
+	pack := sumPack("function sum(a, b) { return a + b; }")
+	pack.Comparator = &domain.ComparatorSpec{Mode: domain.ComparatorCustomChecker, Checker: `function check([a], expected, actual) {
+  if (a === 5) throw new Error("no verdict");
+  return actual === expected;
+}`}
+
+	generated, err := newTestVerifier(t, pack).Generate(context.Background(), api.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	report := generated.Metadata.Verification
+	if report.Verified != 2 || len(report.Dropped) != 1 || report.Dropped[0].Reason != domain.VerificationCheckerError {
+		t.Fatalf("expected the test the checker fails on to be dropped, got %+v", report)
+	}
+}
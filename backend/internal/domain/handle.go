@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Bounds enforced by NormalizeHandle.
+const (
+	MinHandleLength = 3
+	MaxHandleLength = 30
+)
+
+// ErrInvalidHandle is wrapped by the errors NormalizeHandle returns.
+var ErrInvalidHandle = errors.New("invalid handle")
+
+// reservedHandles cannot be claimed because they collide with routes, roles or the product
+// name.
+var reservedHandles = map[string]bool{
+	"about":     true,
+	"admin":     true,
+	"api":       true,
+	"auth":      true,
+	"help":      true,
+	"improview": true,
+	"login":     true,
+	"logout":    true,
+	"me":        true,
+	"null":      true,
+	"profile":   true,
+	"root":      true,
+	"settings":  true,
+	"signup":    true,
+	"support":   true,
+	"system":    true,
+	"undefined": true,
+	"user":      true,
+	"users":     true,
+}
+
+// NormalizeHandle trims and lowercases a requested handle and checks it: 3 to 30
+// characters of a-z, 0-9, '-' or '_', starting with a letter or digit, and not a reserved
+// word. Handles are unique case-insensitively, so the normalized form is what gets stored.
+func NormalizeHandle(raw string) (string, error) {
+	handle := strings.ToLower(strings.TrimSpace(raw))
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength {
+		return "", fmt.Errorf("%w: must be %d to %d characters", ErrInvalidHandle, MinHandleLength, MaxHandleLength)
+	}
+	for i, r := range handle {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case (r == '-' || r == '_') && i > 0:
+		default:
+			return "", fmt.Errorf("%w: %q may only contain letters, digits, '-' and '_' and must start with a letter or digit", ErrInvalidHandle, raw)
+		}
+	}
+	if reservedHandles[handle] {
+		return "", fmt.Errorf("%w: %q is reserved", ErrInvalidHandle, handle)
+	}
+	return handle, nil
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHandle(t *testing.T) {
+	valid := map[string]string{
+		" Ada ":                              "ada",
+		"grace_hopper":                       "grace_hopper",
+		"x-99":                               "x-99",
+		"007":                                "007",
+		strings.Repeat("a", MaxHandleLength): strings.Repeat("a", MaxHandleLength),
+	}
+	for raw, want := range valid {
+		got, err := NormalizeHandle(raw)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeHandle(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+
+	for _, raw := range []string{
+		"",
+		"ab",
+		strings.Repeat("a", MaxHandleLength+1),
+		"_ada",
+		"-ada",
+		"ada lovelace",
+		"ada.l",
+		"adà",
+		"Admin",
+		"me",
+	} {
+		if _, err := NormalizeHandle(raw); !errors.Is(err, ErrInvalidHandle) {
+			t.Fatalf("expected NormalizeHandle(%q) to be invalid, got %v", raw, err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package domain
+
+// LLMProvider describes a provider, its base URL and the models callers may select.
+type LLMProvider struct {
+	Name         string   `json:"name"`
+	BaseURL      string   `json:"base_url"`
+	Models       []string `json:"models"`
+	DefaultModel string   `json:"default_model"`
+	Default      bool     `json:"default"`
+}
@@ -1,5 +1,15 @@
 package domain
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"improview/backend/internal/jsonfmt"
+)
+
 // Example represents one illustrative or test case for a generated problem.
 type Example struct {
 	Input       []any  `json:"input"`
@@ -56,6 +66,47 @@ type TestSuite struct {
 	Hidden []Example `json:"hidden"`
 }
 
+// Test set names used to derive stable test identifiers.
+const (
+	TestSetPublic = "public"
+	TestSetHidden = "hidden"
+)
+
+// TestCase is a single test from a suite addressed by a stable identifier.
+type TestCase struct {
+	ID     string
+	Set    string
+	Input  []any
+	Output any
+}
+
+// Cases flattens the suite into public tests followed by hidden tests. Identifiers are
+// derived from each test's set and 1-based position, e.g. "public_1" or "hidden_3".
+func (s TestSuite) Cases() []TestCase {
+	cases := make([]TestCase, 0, len(s.Public)+len(s.Hidden))
+	for i, example := range s.Public {
+		cases = append(cases, newTestCase(TestSetPublic, i, example))
+	}
+	for i, example := range s.Hidden {
+		cases = append(cases, newTestCase(TestSetHidden, i, example))
+	}
+	return cases
+}
+
+// TestCaseID returns the identifier of the test at index within the named set.
+func TestCaseID(set string, index int) string {
+	return set + "_" + strconv.Itoa(index+1)
+}
+
+func newTestCase(set string, index int, example Example) TestCase {
+	return TestCase{
+		ID:     TestCaseID(set, index),
+		Set:    set,
+		Input:  example.Input,
+		Output: example.Output,
+	}
+}
+
 // ProblemPack is the full payload returned by the LLM broker.
 type ProblemPack struct {
 	Problem          ProblemMetadata   `json:"problem"`
@@ -64,29 +115,162 @@ type ProblemPack struct {
 	Hint             string            `json:"hint"`
 	Solutions        []SolutionOutline `json:"solutions"`
 	Tests            TestSuite         `json:"tests"`
+	// Comparator selects how returned values are graded against test outputs; nil means
+	// exact comparison.
+	Comparator *ComparatorSpec `json:"comparator,omitempty"`
 }
 
+// Comparator modes a ProblemPack may declare.
+const (
+	// ComparatorExact requires the returned value to equal the expected output.
+	ComparatorExact = "exact"
+	// ComparatorUnordered ignores the order of the elements of a top-level array.
+	ComparatorUnordered = "unordered"
+	// ComparatorUnorderedNested ignores the order of elements in every array, at any depth.
+	ComparatorUnorderedNested = "unordered-nested"
+	// ComparatorFloatEpsilon accepts numbers within Epsilon of the expected ones.
+	ComparatorFloatEpsilon = "float-epsilon"
+	// ComparatorAnyOf treats each test output as an array of acceptable values.
+	ComparatorAnyOf = "any-of"
+	// ComparatorCustomChecker delegates grading to the JavaScript function in Checker.
+	ComparatorCustomChecker = "custom-checker"
+)
+
+const (
+	// DefaultComparatorEpsilon is the tolerance used by float-epsilon when Epsilon is unset.
+	DefaultComparatorEpsilon = 1e-6
+	// CheckerFunctionName is the function a custom checker must define. It is called as
+	// check(input, expected, actual) and returns true when actual is acceptable.
+	CheckerFunctionName = "check"
+)
+
+// ComparatorModes lists every supported comparator mode.
+var ComparatorModes = []string{
+	ComparatorExact,
+	ComparatorUnordered,
+	ComparatorUnorderedNested,
+	ComparatorFloatEpsilon,
+	ComparatorAnyOf,
+	ComparatorCustomChecker,
+}
+
+// ComparatorSpec describes how a problem's outputs are compared. Epsilon only applies to
+// float-epsilon and Checker only to custom-checker.
+type ComparatorSpec struct {
+	Mode    string  `json:"mode"`
+	Epsilon float64 `json:"epsilon,omitempty"`
+	Checker string  `json:"checker,omitempty"`
+}
+
+// ProblemView is the candidate-facing projection of a ProblemPack. It omits the hint,
+// reference solutions and hidden tests, which are only released through explicit unlocks,
+// as well as the source of a custom checker.
+type ProblemView struct {
+	Problem          ProblemMetadata `json:"problem"`
+	API              APISignature    `json:"api"`
+	TimeEstimateMins int             `json:"time_estimate_minutes"`
+	HasHint          bool            `json:"has_hint"`
+	SolutionCount    int             `json:"solution_count"`
+	Tests            PublicTestSuite `json:"tests"`
+	Comparator       *ComparatorSpec `json:"comparator,omitempty"`
+}
+
+// PublicTestSuite exposes the public tests and only the number of hidden tests.
+type PublicTestSuite struct {
+	Public      []Example `json:"public"`
+	HiddenCount int       `json:"hidden_count"`
+}
+
+// View returns the redacted projection of the pack.
+func (p ProblemPack) View() ProblemView {
+	public := append([]Example(nil), p.Tests.Public...)
+	if public == nil {
+		public = []Example{}
+	}
+	var comparator *ComparatorSpec
+	if p.Comparator != nil {
+		comparator = &ComparatorSpec{Mode: p.Comparator.Mode, Epsilon: p.Comparator.Epsilon}
+	}
+	return ProblemView{
+		Problem:          p.Problem,
+		API:              p.API,
+		TimeEstimateMins: p.TimeEstimateMins,
+		HasHint:          strings.TrimSpace(p.Hint) != "",
+		SolutionCount:    len(p.Solutions),
+		Tests: PublicTestSuite{
+			Public:      public,
+			HiddenCount: len(p.Tests.Hidden),
+		},
+		Comparator: comparator,
+	}
+}
+
+// Unlock identifies protected problem content a candidate can reveal during an attempt.
+type Unlock string
+
+const (
+	// UnlockHint reveals the problem hint.
+	UnlockHint Unlock = "hint"
+	// UnlockSolutions reveals the reference solutions.
+	UnlockSolutions Unlock = "solutions"
+)
+
 // Attempt captures stored attempt metadata.
 type Attempt struct {
-	ID         string `json:"id"`
-	ProblemID  string `json:"problem_id"`
-	UserID     string `json:"user_id"`
-	Language   string `json:"lang"`
-	StartedAt  int64  `json:"started_at"`
-	EndedAt    int64  `json:"ended_at"`
-	HintUsed   bool   `json:"hint_used"`
-	PassCount  int    `json:"pass_count"`
-	FailCount  int    `json:"fail_count"`
-	DurationMS int64  `json:"duration_ms"`
-}
-
-// RunResult captures output from executing a single test case.
+	ID                string `json:"id"`
+	ProblemID         string `json:"problem_id"`
+	UserID            string `json:"user_id"`
+	Language          string `json:"lang"`
+	StartedAt         int64  `json:"started_at"`
+	EndedAt           int64  `json:"ended_at"`
+	HintUsed          bool   `json:"hint_used"`
+	SolutionsUnlocked bool   `json:"solutions_unlocked"`
+	// PassCount and FailCount are the totals of the latest run; Lifetime sums every run.
+	PassCount  int       `json:"pass_count"`
+	FailCount  int       `json:"fail_count"`
+	LastRunID  string    `json:"last_run_id,omitempty"`
+	Lifetime   RunTotals `json:"lifetime"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// RunTotals counts the runs of an attempt and their passing and failing test results.
+type RunTotals struct {
+	Runs      int `json:"runs"`
+	PassCount int `json:"pass_count"`
+	FailCount int `json:"fail_count"`
+}
+
+// AttemptRun is one execution of an attempt's code against a selection of its tests.
+type AttemptRun struct {
+	ID        string `json:"id"`
+	CreatedAt int64  `json:"created_at"`
+	// Which is the test selection: "public", "hidden", "all" or comma-separated test IDs.
+	Which     string      `json:"which"`
+	CodeHash  string      `json:"code_hash"`
+	PassCount int         `json:"pass_count"`
+	FailCount int         `json:"fail_count"`
+	Results   []RunResult `json:"results"`
+}
+
+// CodeHash fingerprints submitted code so runs of the same code can be recognised.
+func CodeHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// RunResult captures output from executing a single test case. Actual holds the returned
+// value whenever the solution returned one. Failed public tests also carry the expected
+// output and a structured diff against it; hidden tests never reveal their expected output.
 type RunResult struct {
-	TestID string `json:"test_id"`
-	Status string `json:"status"`
-	TimeMS int64  `json:"time_ms"`
-	Stdout string `json:"stdout"`
-	Stderr string `json:"stderr"`
+	TestID   string               `json:"test_id"`
+	Status   string               `json:"status"`
+	TimeMS   int64                `json:"time_ms"`
+	Stdout   string               `json:"stdout"`
+	Stderr   string               `json:"stderr"`
+	Expected json.RawMessage      `json:"expected,omitempty"`
+	Actual   json.RawMessage      `json:"actual,omitempty"`
+	Diff     []jsonfmt.Difference `json:"diff,omitempty"`
+	DiffText string               `json:"diff_text,omitempty"`
 }
 
 // RunSummary groups multiple run results.
@@ -95,6 +279,19 @@ type RunSummary struct {
 	Results   []RunResult `json:"results"`
 }
 
+// RunListOptions pages through an attempt's run history.
+type RunListOptions struct {
+	Limit     int32
+	NextToken string
+}
+
+// RunListResult is one page of run history, oldest run first. NextToken is empty on the
+// last page.
+type RunListResult struct {
+	Runs      []AttemptRun
+	NextToken string
+}
+
 // SubmissionSummary contains outcome metrics after running hidden tests.
 type SubmissionSummary struct {
 	AttemptID     string      `json:"attempt_id"`
@@ -103,3 +300,84 @@ type SubmissionSummary struct {
 	Operations    int64       `json:"operations"`
 	HiddenResults []RunResult `json:"hidden_results"`
 }
+
+// GeneratedProblem pairs a freshly generated pack with how it was produced.
+type GeneratedProblem struct {
+	Pack     ProblemPack
+	Metadata GenerationMetadata
+}
+
+// ProblemRecord is a stored problem pack together with how it was generated.
+type ProblemRecord struct {
+	ID         string             `json:"id"`
+	Category   string             `json:"category,omitempty"`
+	Difficulty string             `json:"difficulty,omitempty"`
+	PromptHash string             `json:"prompt_hash,omitempty"`
+	CreatedBy  string             `json:"created_by,omitempty"`
+	CreatedAt  int64              `json:"created_at"`
+	Pack       ProblemPack        `json:"pack"`
+	Generation GenerationMetadata `json:"generation"`
+}
+
+// PromptHash fingerprints the generation inputs so identical requests can be grouped.
+func PromptHash(category, difficulty, customPrompt string) string {
+	normalised := strings.Join([]string{
+		strings.ToLower(strings.TrimSpace(category)),
+		strings.ToLower(strings.TrimSpace(difficulty)),
+		strings.TrimSpace(customPrompt),
+	}, "\n")
+	sum := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerationMetadata records how a problem pack was produced, including every upstream
+// call made along the way.
+type GenerationMetadata struct {
+	Generator string              `json:"generator"`
+	Provider  string              `json:"provider,omitempty"`
+	Model     string              `json:"model,omitempty"`
+	Attempts  int                 `json:"attempts"`
+	Errors    []string            `json:"errors,omitempty"`
+	LatencyMS int64               `json:"latency_ms"`
+	History   []GenerationAttempt `json:"history,omitempty"`
+	// Step names the fallback chain step that produced the pack; Skipped lists the steps
+	// tried or bypassed before it.
+	Step    string        `json:"step,omitempty"`
+	Skipped []SkippedStep `json:"skipped,omitempty"`
+	// Verification is set once the pack's tests have been checked against its solutions.
+	Verification *VerificationReport `json:"verification,omitempty"`
+}
+
+// SkippedStep records a fallback chain step that did not produce the pack.
+type SkippedStep struct {
+	Step   string `json:"step"`
+	Reason string `json:"reason"`
+}
+
+// Kinds of GenerationAttempt.
+const (
+	GenerationKindGenerate = "generate"
+	GenerationKindRepair   = "repair"
+	GenerationKindRetry    = "retry"
+)
+
+// GenerationAttempt captures a single upstream call during generation.
+type GenerationAttempt struct {
+	Attempt    int    `json:"attempt"`
+	Kind       string `json:"kind"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+}
+
+// RecordAttempt appends a call to the history. Attempts counts generation attempts, so
+// retries of the same attempt do not increase it.
+func (m *GenerationMetadata) RecordAttempt(attempt GenerationAttempt) {
+	m.History = append(m.History, attempt)
+	if attempt.Attempt > m.Attempts {
+		m.Attempts = attempt.Attempt
+	}
+	if attempt.Error != "" {
+		m.Errors = append(m.Errors, attempt.Error)
+	}
+}
@@ -11,6 +11,63 @@ type UserProfile struct {
 	Preferences map[string]string `json:"preferences,omitempty"`
 	CreatedAt   int64             `json:"created_at"`
 	UpdatedAt   int64             `json:"updated_at"`
+	// Version increases with every write and is served as the profile's ETag.
+	Version int64 `json:"version"`
+}
+
+// Profile preferences read by the backend.
+const (
+	// PreferenceStatsVisibility controls whether aggregate stats appear on the public
+	// profile. Only StatsVisibilityPublic shows them; anything else keeps them private.
+	PreferenceStatsVisibility = "profile.stats_visibility"
+	// StatsVisibilityPublic opts into showing stats on the public profile.
+	StatsVisibilityPublic = "public"
+)
+
+// PublicProfile is the projection of a profile that anyone can read by handle. It never
+// carries the user ID, email, timezone or preferences.
+type PublicProfile struct {
+	Handle      string              `json:"handle"`
+	DisplayName string              `json:"display_name,omitempty"`
+	Bio         string              `json:"bio,omitempty"`
+	AvatarURL   string              `json:"avatar_url,omitempty"`
+	JoinedAt    int64               `json:"joined_at"`
+	Stats       *PublicProfileStats `json:"stats,omitempty"`
+}
+
+// PublicProfileStats aggregates a user's saved problems for their public profile.
+type PublicProfileStats struct {
+	SavedProblems int `json:"saved_problems"`
+	Completed     int `json:"completed"`
+	// Passed counts saved problems whose last attempt passed.
+	Passed int `json:"passed"`
+}
+
+// Public returns the public projection of the profile, without stats.
+func (p UserProfile) Public() PublicProfile {
+	return PublicProfile{
+		Handle:      p.Handle,
+		DisplayName: p.DisplayName,
+		Bio:         p.Bio,
+		AvatarURL:   p.AvatarURL,
+		JoinedAt:    p.CreatedAt,
+	}
+}
+
+// StatsArePublic reports whether the user opted into showing stats on their public profile.
+func (p UserProfile) StatsArePublic() bool {
+	return p.Preferences[PreferenceStatsVisibility] == StatsVisibilityPublic
+}
+
+// Add counts one saved problem towards the stats.
+func (s *PublicProfileStats) Add(summary SavedProblemSummary) {
+	s.SavedProblems++
+	if summary.Status == SavedProblemStatusCompleted {
+		s.Completed++
+	}
+	if summary.LastAttempt != nil && summary.LastAttempt.Status == SavedAttemptStatusPassed {
+		s.Passed++
+	}
 }
 
 // UserProfileUpdate captures partial profile updates.
@@ -21,6 +78,9 @@ type UserProfileUpdate struct {
 	AvatarURL   *string
 	Timezone    *string
 	Preferences map[string]string
+	// IfVersion, when non-zero, applies the update only if the stored profile is still at
+	// this version.
+	IfVersion int64
 }
 
 // SavedProblemStatus enumerates lifecycle states for a saved problem.
@@ -48,6 +108,9 @@ type SavedProblemSummary struct {
 	CreatedAt    int64                 `json:"created_at"`
 	UpdatedAt    int64                 `json:"updated_at"`
 	LastAttempt  *SavedAttemptSnapshot `json:"last_attempt,omitempty"`
+	// Version increases with every metadata write (create and update) and is served as the
+	// saved problem's ETag. Appending attempts does not change it.
+	Version int64 `json:"version"`
 }
 
 // SavedAttemptSummary captures high-level attempt metadata.
@@ -103,6 +166,9 @@ type SavedProblemUpdateInput struct {
 	Tags         []string
 	Notes        *string
 	HintUnlocked *bool
+	// IfVersion, when non-zero, applies the update only if the saved problem is still at
+	// this version.
+	IfVersion int64
 }
 
 // SavedProblemAttemptInput represents a new attempt snapshot.
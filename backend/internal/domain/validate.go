@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Bounds enforced by ValidateProblemPack.
+const (
+	MinTimeEstimateMins = 10
+	MaxTimeEstimateMins = 120
+	MinSolutions        = 1
+	MaxSolutions        = 2
+)
+
+// PackValidationError lists every problem found in a ProblemPack.
+type PackValidationError struct {
+	Issues []string
+}
+
+func (e *PackValidationError) Error() string {
+	return "invalid problem pack: " + strings.Join(e.Issues, "; ")
+}
+
+// ValidateProblemPack checks that a pack is internally consistent before it is stored or
+// served. It returns a *PackValidationError describing all issues, or nil.
+func ValidateProblemPack(pack ProblemPack) error {
+	v := &packValidator{}
+
+	if strings.TrimSpace(pack.Problem.Title) == "" {
+		v.addf("problem.title is empty")
+	}
+	if strings.TrimSpace(pack.Problem.Statement) == "" {
+		v.addf("problem.statement is empty")
+	}
+
+	functionName := strings.TrimSpace(pack.API.FunctionName)
+	if functionName == "" {
+		v.addf("api.function_name is empty")
+	} else if !strings.Contains(pack.API.Signature, functionName) {
+		v.addf("api.signature %q does not mention function %q", pack.API.Signature, functionName)
+	}
+
+	if pack.TimeEstimateMins < MinTimeEstimateMins || pack.TimeEstimateMins > MaxTimeEstimateMins {
+		v.addf("time_estimate_minutes %d is outside [%d,%d]", pack.TimeEstimateMins, MinTimeEstimateMins, MaxTimeEstimateMins)
+	}
+
+	if n := len(pack.Solutions); n < MinSolutions || n > MaxSolutions {
+		v.addf("expected %d-%d solutions, got %d", MinSolutions, MaxSolutions, n)
+	}
+	for i, solution := range pack.Solutions {
+		if strings.TrimSpace(solution.Code) == "" {
+			v.addf("solutions[%d].code is empty", i)
+		}
+	}
+
+	arity := len(pack.API.Params)
+	v.checkArity("problem.examples", pack.Problem.Examples, arity)
+	v.checkArity("tests.public", pack.Tests.Public, arity)
+	v.checkArity("tests.hidden", pack.Tests.Hidden, arity)
+
+	if len(pack.Tests.Public) == 0 {
+		v.addf("tests.public is empty")
+	}
+	if len(pack.Tests.Hidden) == 0 {
+		v.addf("tests.hidden is empty")
+	}
+	v.checkDuplicates(pack.Tests)
+	if pack.Comparator != nil {
+		v.checkComparator(*pack.Comparator, pack.Tests)
+	}
+
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &PackValidationError{Issues: v.issues}
+}
+
+type packValidator struct {
+	issues []string
+}
+
+func (v *packValidator) addf(format string, args ...any) {
+	v.issues = append(v.issues, fmt.Sprintf(format, args...))
+}
+
+func (v *packValidator) checkArity(field string, examples []Example, arity int) {
+	for i, example := range examples {
+		if len(example.Input) != arity {
+			v.addf("%s[%d].input has %d argument(s), api.params declares %d", field, i, len(example.Input), arity)
+		}
+	}
+}
+
+// checkDuplicates reports tests whose input repeats an earlier test in either set.
+func (v *packValidator) checkDuplicates(suite TestSuite) {
+	seen := make(map[string]string)
+	for _, tc := range suite.Cases() {
+		encoded, err := json.Marshal(tc.Input)
+		if err != nil {
+			v.addf("%s input is not JSON-serializable: %v", tc.ID, err)
+			continue
+		}
+		key := string(encoded)
+		if first, ok := seen[key]; ok {
+			v.addf("%s duplicates the input of %s", tc.ID, first)
+			continue
+		}
+		seen[key] = tc.ID
+	}
+}
+
+// checkComparator reports unknown modes, unusable settings and, for any-of, test outputs
+// that are not a list of accepted values.
+func (v *packValidator) checkComparator(spec ComparatorSpec, suite TestSuite) {
+	switch mode := spec.Mode; {
+	case !slices.Contains(ComparatorModes, mode):
+		v.addf("comparator.mode %q is not one of %s", mode, strings.Join(ComparatorModes, ", "))
+	case mode == ComparatorFloatEpsilon:
+		if spec.Epsilon < 0 || math.IsNaN(spec.Epsilon) || math.IsInf(spec.Epsilon, 0) {
+			v.addf("comparator.epsilon %v must be a finite, non-negative number", spec.Epsilon)
+		}
+	case mode == ComparatorCustomChecker:
+		if strings.TrimSpace(spec.Checker) == "" {
+			v.addf("comparator.checker is empty")
+		} else if !strings.Contains(spec.Checker, CheckerFunctionName) {
+			v.addf("comparator.checker does not define function %q", CheckerFunctionName)
+		}
+	case mode == ComparatorAnyOf:
+		for _, tc := range suite.Cases() {
+			if value := reflect.ValueOf(tc.Output); value.Kind() != reflect.Slice || value.Len() == 0 {
+				v.addf("%s output must be a non-empty array of accepted outputs for any-of", tc.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validPack() ProblemPack {
+	return ProblemPack{
+		Problem: ProblemMetadata{
+			Title:     "Sum Pair",
+			Statement: "Return true when two numbers add up to target.",
+			Examples:  []Example{{Input: []any{[]int{1, 2}, 3}, Output: true}},
+		},
+		API: APISignature{
+			FunctionName: "hasPair",
+			Signature:    "function hasPair(nums, target)",
+			Params:       []APIParam{{Name: "nums"}, {Name: "target"}},
+		},
+		TimeEstimateMins: 15,
+		Solutions:        []SolutionOutline{{Approach: "Set", Code: "function hasPair() {}"}},
+		Tests: TestSuite{
+			Public: []Example{{Input: []any{[]int{1, 2}, 3}, Output: true}},
+			Hidden: []Example{{Input: []any{[]int{1, 2}, 4}, Output: false}},
+		},
+	}
+}
+
+func TestValidateProblemPackAcceptsConsistentPack(t *testing.T) {
+	if err := ValidateProblemPack(validPack()); err != nil {
+		t.Fatalf("expected valid pack, got %v", err)
+	}
+}
+
+func TestValidateProblemPackReportsIssues(t *testing.T) {
+	cases := map[string]struct {
+		mutate func(*ProblemPack)
+		want   string
+	}{
+		"arity mismatch": {
+			mutate: func(p *ProblemPack) { p.Tests.Hidden[0].Input = []any{[]int{1}} },
+			want:   "tests.hidden[0].input has 1 argument(s), api.params declares 2",
+		},
+		"example arity": {
+			mutate: func(p *ProblemPack) { p.Problem.Examples[0].Input = nil },
+			want:   "problem.examples[0].input has 0 argument(s)",
+		},
+		"signature": {
+			mutate: func(p *ProblemPack) { p.API.Signature = "function solve(nums, target)" },
+			want:   `does not mention function "hasPair"`,
+		},
+		"time estimate low": {
+			mutate: func(p *ProblemPack) { p.TimeEstimateMins = 5 },
+			want:   "time_estimate_minutes 5 is outside [10,120]",
+		},
+		"time estimate high": {
+			mutate: func(p *ProblemPack) { p.TimeEstimateMins = 121 },
+			want:   "time_estimate_minutes 121 is outside [10,120]",
+		},
+		"no solutions": {
+			mutate: func(p *ProblemPack) { p.Solutions = nil },
+			want:   "expected 1-2 solutions, got 0",
+		},
+		"too many solutions": {
+			mutate: func(p *ProblemPack) { p.Solutions = append(p.Solutions, p.Solutions[0], p.Solutions[0]) },
+			want:   "expected 1-2 solutions, got 3",
+		},
+		"empty public": {
+			mutate: func(p *ProblemPack) { p.Tests.Public = nil },
+			want:   "tests.public is empty",
+		},
+		"empty hidden": {
+			mutate: func(p *ProblemPack) { p.Tests.Hidden = nil },
+			want:   "tests.hidden is empty",
+		},
+		"duplicate across sets": {
+			mutate: func(p *ProblemPack) { p.Tests.Hidden[0].Input = []any{[]int{1, 2}, 3} },
+			want:   "hidden_1 duplicates the input of public_1",
+		},
+		"duplicate within set": {
+			mutate: func(p *ProblemPack) { p.Tests.Public = append(p.Tests.Public, p.Tests.Public[0]) },
+			want:   "public_2 duplicates the input of public_1",
+		},
+		"unknown comparator": {
+			mutate: func(p *ProblemPack) { p.Comparator = &ComparatorSpec{Mode: "fuzzy"} },
+			want:   `comparator.mode "fuzzy" is not one of exact, unordered`,
+		},
+		"negative epsilon": {
+			mutate: func(p *ProblemPack) { p.Comparator = &ComparatorSpec{Mode: ComparatorFloatEpsilon, Epsilon: -1} },
+			want:   "comparator.epsilon -1 must be a finite, non-negative number",
+		},
+		"missing checker": {
+			mutate: func(p *ProblemPack) { p.Comparator = &ComparatorSpec{Mode: ComparatorCustomChecker} },
+			want:   "comparator.checker is empty",
+		},
+		"any-of scalar output": {
+			mutate: func(p *ProblemPack) {
+				p.Comparator = &ComparatorSpec{Mode: ComparatorAnyOf}
+				p.Tests.Public[0].Output = []any{true}
+			},
+			want: "hidden_1 output must be a non-empty array of accepted outputs for any-of",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pack := validPack()
+			tc.mutate(&pack)
+
+			err := ValidateProblemPack(pack)
+			var validationErr *PackValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected PackValidationError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected issue %q, got %v", tc.want, validationErr.Issues)
+			}
+		})
+	}
+}
+
+func TestValidateProblemPackAcceptsComparators(t *testing.T) {
+	for _, spec := range []ComparatorSpec{
+		{Mode: ComparatorExact},
+		{Mode: ComparatorUnorderedNested},
+		{Mode: ComparatorFloatEpsilon},
+		{Mode: ComparatorCustomChecker, Checker: "function check(input, expected, actual) { return actual === expected; }"},
+	} {
+		pack := validPack()
+		pack.Comparator = &spec
+		if err := ValidateProblemPack(pack); err != nil {
+			t.Fatalf("expected %s comparator to be valid, got %v", spec.Mode, err)
+		}
+	}
+}
+
+func TestValidateProblemPackCollectsAllIssues(t *testing.T) {
+	err := ValidateProblemPack(ProblemPack{})
+	var validationErr *PackValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected PackValidationError, got %v", err)
+	}
+	if len(validationErr.Issues) < 6 {
+		t.Fatalf("expected every issue to be reported, got %v", validationErr.Issues)
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+// Reasons recorded in a VerificationReport.
+const (
+	// VerificationOutputMismatch means the reference solutions agree with each other but not
+	// with the stated output.
+	VerificationOutputMismatch = "output_mismatch"
+	// VerificationSolutionsDisagree means the reference solutions returned different values.
+	VerificationSolutionsDisagree = "solutions_disagree"
+	// VerificationSolutionError means a reference solution threw or failed to compile.
+	VerificationSolutionError = "solution_error"
+	// VerificationSolutionTimeout means a reference solution exceeded its time budget.
+	VerificationSolutionTimeout = "solution_timeout"
+	// VerificationCheckerError means the pack's custom checker threw, timed out or returned
+	// something other than a boolean.
+	VerificationCheckerError = "checker_error"
+)
+
+// VerificationReport summarises how a generated pack's tests held up when its reference
+// solutions were executed against them. Test IDs refer to the pack as generated, before any
+// tests were dropped. Values are deliberately omitted so the report never reveals hidden
+// test outputs.
+type VerificationReport struct {
+	Solutions int                 `json:"solutions"`
+	Checked   int                 `json:"checked"`
+	Verified  int                 `json:"verified"`
+	Corrected []VerificationIssue `json:"corrected,omitempty"`
+	Dropped   []VerificationIssue `json:"dropped,omitempty"`
+}
+
+// VerificationIssue records a test that was corrected or dropped and why.
+type VerificationIssue struct {
+	TestID string `json:"test_id"`
+	Reason string `json:"reason"`
+}
+
+// Kept returns the number of tests that survived verification.
+func (r VerificationReport) Kept() int {
+	return r.Verified + len(r.Corrected)
+}
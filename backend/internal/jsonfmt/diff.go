@@ -0,0 +1,178 @@
+package jsonfmt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Difference kinds reported by Diff.
+const (
+	// DiffChanged marks a scalar whose value differs.
+	DiffChanged = "changed"
+	// DiffTypeMismatch marks values of different JSON types.
+	DiffTypeMismatch = "type_mismatch"
+	// DiffMissing marks an array element or object key only present in the expected value.
+	DiffMissing = "missing"
+	// DiffExtra marks an array element or object key only present in the actual value.
+	DiffExtra = "extra"
+)
+
+// Difference describes one place where two JSON values disagree. Path is a JSONPath-style
+// location such as $[2].name; Expected is null for extra entries and Actual for missing ones.
+type Difference struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Expected any    `json:"expected"`
+	Actual   any    `json:"actual"`
+}
+
+// Diff compares two decoded JSON values (maps, slices, float64, string, bool and nil) and
+// returns their differences in path order, visiting object keys sorted like FormatValue.
+// Arrays are compared index by index, so surplus elements on either side are reported as
+// missing or extra. At most limit differences are returned; limit <= 0 returns them all.
+func Diff(expected, actual any, limit int) []Difference {
+	d := differ{limit: limit}
+	d.compare("$", expected, actual)
+	return d.diffs
+}
+
+type differ struct {
+	limit int
+	diffs []Difference
+}
+
+func (d *differ) full() bool {
+	return d.limit > 0 && len(d.diffs) >= d.limit
+}
+
+func (d *differ) add(path, kind string, expected, actual any) {
+	if !d.full() {
+		d.diffs = append(d.diffs, Difference{Path: path, Kind: kind, Expected: expected, Actual: actual})
+	}
+}
+
+func (d *differ) compare(path string, expected, actual any) {
+	if d.full() {
+		return
+	}
+	if typeName(expected) != typeName(actual) {
+		d.add(path, DiffTypeMismatch, expected, actual)
+		return
+	}
+
+	switch want := expected.(type) {
+	case map[string]any:
+		got := actual.(map[string]any)
+		keys := make([]string, 0, len(want)+len(got))
+		for k := range want {
+			keys = append(keys, k)
+		}
+		for k := range got {
+			if _, ok := want[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			wantValue, inWant := want[k]
+			gotValue, inGot := got[k]
+			switch {
+			case !inGot:
+				d.add(keyPath(path, k), DiffMissing, wantValue, nil)
+			case !inWant:
+				d.add(keyPath(path, k), DiffExtra, nil, gotValue)
+			default:
+				d.compare(keyPath(path, k), wantValue, gotValue)
+			}
+		}
+	case []any:
+		got := actual.([]any)
+		for i := 0; i < max(len(want), len(got)); i++ {
+			elementPath := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(got):
+				d.add(elementPath, DiffMissing, want[i], nil)
+			case i >= len(want):
+				d.add(elementPath, DiffExtra, nil, got[i])
+			default:
+				d.compare(elementPath, want[i], got[i])
+			}
+		}
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			d.add(path, DiffChanged, expected, actual)
+		}
+	}
+}
+
+// FormatDiff renders differences one per line, formatting values like FormatValue:
+//
+//	$[1]: expected 2, got 3
+//	$.name: expected string "a", got number 5
+//	$[3]: missing 4
+//	$[4]: unexpected 7
+func FormatDiff(diffs []Difference) string {
+	lines := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		var line string
+		switch diff.Kind {
+		case DiffMissing:
+			line = fmt.Sprintf("%s: missing %s", diff.Path, FormatValue(diff.Expected))
+		case DiffExtra:
+			line = fmt.Sprintf("%s: unexpected %s", diff.Path, FormatValue(diff.Actual))
+		case DiffTypeMismatch:
+			line = fmt.Sprintf("%s: expected %s %s, got %s %s", diff.Path,
+				typeName(diff.Expected), FormatValue(diff.Expected), typeName(diff.Actual), FormatValue(diff.Actual))
+		default:
+			line = fmt.Sprintf("%s: expected %s, got %s", diff.Path, FormatValue(diff.Expected), FormatValue(diff.Actual))
+		}
+		lines = append(lines, strings.ReplaceAll(line, "\n", "\n"+indentUnit))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// typeName names the JSON type of a decoded value.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// keyPath appends an object key, bracket-quoting keys that are not plain identifiers.
+func keyPath(path, key string) string {
+	if isPlainKey(key) {
+		return path + "." + key
+	}
+	return path + "[" + quoteJSONString(key) + "]"
+}
+
+func isPlainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
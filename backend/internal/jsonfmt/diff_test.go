@@ -0,0 +1,55 @@
+package jsonfmt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, raw string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return v
+}
+
+func TestDiffReportsPathsKindsAndValues(t *testing.T) {
+	expected := decode(t, `{"ids": [1, 2, 3], "name": "a", "meta": {"ok": true}, "first name": null}`)
+	actual := decode(t, `{"ids": [1, 5], "name": 5, "meta": {"ok": true, "extra": [0]}}`)
+
+	want := []Difference{
+		{Path: `$["first name"]`, Kind: DiffMissing, Expected: nil},
+		{Path: "$.ids[1]", Kind: DiffChanged, Expected: float64(2), Actual: float64(5)},
+		{Path: "$.ids[2]", Kind: DiffMissing, Expected: float64(3)},
+		{Path: "$.meta.extra", Kind: DiffExtra, Actual: []any{float64(0)}},
+		{Path: "$.name", Kind: DiffTypeMismatch, Expected: "a", Actual: float64(5)},
+	}
+	if got := Diff(expected, actual, 0); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff\n got %#v\nwant %#v", got, want)
+	}
+	if got := Diff(expected, actual, 2); !reflect.DeepEqual(got, want[:2]) {
+		t.Fatalf("expected the limit to keep the first two differences, got %#v", got)
+	}
+	if got := Diff(expected, decode(t, `{"ids": [1, 2, 3], "name": "a", "meta": {"ok": true}, "first name": null}`), 0); len(got) != 0 {
+		t.Fatalf("expected equal values to have no differences, got %#v", got)
+	}
+}
+
+func TestFormatDiffRendersOneLinePerDifference(t *testing.T) {
+	diffs := Diff(decode(t, `[1, {"b": 2, "a": "x"}, 3]`), decode(t, `[2, [], 3, {"k": 1, "j": [true]}]`), 0)
+
+	want := `$[0]: expected 1, got 2
+$[1]: expected object {
+    "a": "x",
+    "b": 2
+  }, got array []
+$[3]: unexpected {
+    "j": [ true ],
+    "k": 1
+  }`
+	if got := FormatDiff(diffs); got != want {
+		t.Fatalf("unexpected rendering\n got:\n%s\nwant:\n%s", got, want)
+	}
+}
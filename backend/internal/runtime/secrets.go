@@ -13,11 +13,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 )
 
-var envKeys = []string{
-	"OPENAI_API_KEY",
-	"OPENAI_BASE_URL",
-	"OPENAI_MODEL",
-	"OPENAI_PROVIDER",
+// providerEnvKeys groups the settings of each provider. Secret values only fill unset
+// variables, so the secret is fetched unless every provider is already fully configured.
+var providerEnvKeys = [][]string{
+	{"OPENAI_API_KEY", "OPENAI_BASE_URL", "OPENAI_MODEL", "OPENAI_PROVIDER"},
+	{"ANTHROPIC_API_KEY", "ANTHROPIC_BASE_URL", "ANTHROPIC_MODEL"},
 }
 
 // LoadLLMEnvFromSecret pulls provider credentials from Secrets Manager when they are not
@@ -69,15 +69,24 @@ func LoadLLMEnvFromSecret(ctx context.Context) error {
 		}
 	}
 
-	if strings.TrimSpace(os.Getenv("OPENAI_API_KEY")) == "" {
-		return errors.New("provider secret did not include openaiApiKey")
+	if strings.TrimSpace(os.Getenv("OPENAI_API_KEY")) == "" && strings.TrimSpace(os.Getenv("ANTHROPIC_API_KEY")) == "" {
+		return errors.New("provider secret did not include openaiApiKey or anthropicApiKey")
 	}
 
 	return nil
 }
 
 func envSatisfied() bool {
-	for _, key := range envKeys {
+	for _, keys := range providerEnvKeys {
+		if !allSet(keys) {
+			return false
+		}
+	}
+	return true
+}
+
+func allSet(keys []string) bool {
+	for _, key := range keys {
 		if strings.TrimSpace(os.Getenv(key)) == "" {
 			return false
 		}
@@ -104,6 +113,15 @@ func parseProviderSecret(secret string) (map[string]string, error) {
 	if val, ok := stringValue(payload["openaiProvider"]); ok {
 		results["OPENAI_PROVIDER"] = val
 	}
+	if val, ok := stringValue(payload["anthropicApiKey"]); ok {
+		results["ANTHROPIC_API_KEY"] = val
+	}
+	if val, ok := stringValue(payload["anthropicBaseUrl"]); ok {
+		results["ANTHROPIC_BASE_URL"] = val
+	}
+	if val, ok := stringValue(payload["anthropicModel"]); ok {
+		results["ANTHROPIC_MODEL"] = val
+	}
 
 	return results, nil
 }
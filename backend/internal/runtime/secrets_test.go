@@ -26,6 +26,26 @@ func TestParseProviderSecretExtractsKnownFields(t *testing.T) {
 	}
 }
 
+func TestParseProviderSecretExtractsAnthropicFields(t *testing.T) {
+	payload := `{
+		"anthropicApiKey": "sk-ant-123",
+		"anthropicBaseUrl": "https://anthropic.sandbox/v1",
+		"anthropicModel": "claude-sandbox"
+	}`
+
+	env, err := parseProviderSecret(payload)
+	if err != nil {
+		t.Fatalf("parse provider secret: %v", err)
+	}
+
+	assertEqual(t, env["ANTHROPIC_API_KEY"], "sk-ant-123")
+	assertEqual(t, env["ANTHROPIC_BASE_URL"], "https://anthropic.sandbox/v1")
+	assertEqual(t, env["ANTHROPIC_MODEL"], "claude-sandbox")
+	if _, ok := env["OPENAI_API_KEY"]; ok {
+		t.Fatalf("unexpected OPENAI_API_KEY from anthropic-only secret")
+	}
+}
+
 func TestParseProviderSecretIgnoresMissingFields(t *testing.T) {
 	payload := `{"updatedAt":"2024-01-01T00:00:00Z"}`
 	env, err := parseProviderSecret(payload)
@@ -43,3 +63,32 @@ func assertEqual(t *testing.T, got, want string) {
 		t.Fatalf("expected %q, got %q", want, got)
 	}
 }
+
+func TestEnvSatisfiedOnlyWhenEveryProviderIsConfigured(t *testing.T) {
+	for _, keys := range providerEnvKeys {
+		for _, key := range keys {
+			t.Setenv(key, "")
+		}
+	}
+	if envSatisfied() {
+		t.Fatalf("expected an empty environment to need the secret")
+	}
+
+	t.Setenv("OPENAI_API_KEY", "key-123")
+	t.Setenv("OPENAI_BASE_URL", "https://api.openai.com/v1")
+	t.Setenv("OPENAI_MODEL", "gpt-4.1-mini")
+	t.Setenv("OPENAI_PROVIDER", "openai")
+	if envSatisfied() {
+		t.Fatalf("expected an OpenAI-only environment to still fetch the Anthropic key")
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "key-456")
+	t.Setenv("ANTHROPIC_BASE_URL", "https://api.anthropic.com/v1")
+	if envSatisfied() {
+		t.Fatalf("expected a partially configured provider to need the secret")
+	}
+	t.Setenv("ANTHROPIC_MODEL", "claude-sonnet-4-5")
+	if !envSatisfied() {
+		t.Fatalf("expected a fully configured environment to skip the secret")
+	}
+}
@@ -0,0 +1,302 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	goSourceName   = "solution.go"
+	goHarnessName  = "harness.go"
+	goBinaryName   = "solution.bin"
+	defaultGo      = "go"
+	goModule       = "module solution\n\ngo 1.22\n"
+	goBuildTimeout = time.Minute
+)
+
+//go:embed golang_harness.go.tmpl
+var goHarnessSource string
+
+var goHarnessTemplate = template.Must(template.New(goHarnessName).Parse(goHarnessSource))
+
+// goBlockedImports are standard library packages a solution may not import: they reach
+// processes, the network, native code or the runtime settings that enforce the limits. The
+// list keeps solutions to plain computation; the isolation, not the list, keeps them away
+// from the host.
+var goBlockedImports = []string{
+	"C", "unsafe", "syscall", "os", "net", "plugin", "embed", "debug", "internal", "vendor",
+	"runtime/cgo", "runtime/debug", "io/ioutil", "log/syslog",
+}
+
+// Go compiles solutions with the local Go toolchain into a main package around the user's
+// function and runs the binary once per input. The harness is generated from the declared
+// signature, so the solution must use the Go types GoType derives from it; builds run
+// offline with modules, cgo and toolchain downloads disabled, and share a build cache so
+// only the first build pays for compiling the standard library.
+//
+// Each run is isolated like Python's, seeing only its working directory (the binary is
+// static), and gets an empty environment, GOMAXPROCS=1 and rlimits on CPU time, data segment
+// size, open files and written file size. The address space is left unlimited because the
+// Go runtime reserves far more of it than it uses. As with Python, the CPU limit rounds up
+// to whole seconds and a wall-clock deadline catches solutions that sleep.
+type Go struct {
+	toolchain string
+	cacheDir  string
+
+	resolve  sync.Once
+	resolved string
+	err      error
+}
+
+// NewGo constructs a Go executor. An empty toolchain means go from PATH.
+func NewGo(toolchain string) *Go {
+	if strings.TrimSpace(toolchain) == "" {
+		toolchain = defaultGo
+	}
+	return &Go{toolchain: toolchain, cacheDir: filepath.Join(os.TempDir(), "improview-go-cache")}
+}
+
+// goRequest is the JSON document the harness reads from stdin.
+type goRequest struct {
+	Args          []any `json:"args"`
+	CPUMS         int64 `json:"cpu_ms"`
+	MemoryBytes   int64 `json:"memory_bytes"`
+	MaxOpenFiles  int   `json:"max_open_files"`
+	MaxFileBytes  int64 `json:"max_file_bytes"`
+	MaxStackBytes int64 `json:"max_stack_bytes"`
+}
+
+type goHarnessParam struct {
+	Name string
+	Type string
+}
+
+// Execute builds the solution once and runs it per input. A failed build is reported as a
+// compile error for every input.
+func (g *Go) Execute(ctx context.Context, req Request) ([]Result, error) {
+	if !ValidFunctionName(req.FunctionName) {
+		return nil, fmt.Errorf("sandbox: invalid function name %q", req.FunctionName)
+	}
+	if len(req.Inputs) == 0 {
+		return []Result{}, nil
+	}
+	toolchain, err := g.toolchainPath()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "improview-go-*")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: create working dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	limits := req.Limits.withDefaults()
+	failure, err := g.build(ctx, toolchain, dir, req, limits)
+	if err != nil {
+		return nil, err
+	}
+	if failure != "" {
+		return compileFailure(len(req.Inputs), failure), nil
+	}
+
+	results := make([]Result, 0, len(req.Inputs))
+	for _, input := range req.Inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := g.call(ctx, dir, input, limits)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (g *Go) toolchainPath() (string, error) {
+	g.resolve.Do(func() {
+		g.resolved, g.err = exec.LookPath(g.toolchain)
+		if g.err != nil {
+			g.err = fmt.Errorf("sandbox: go toolchain %q unavailable: %w", g.toolchain, g.err)
+		}
+	})
+	return g.resolved, g.err
+}
+
+// build writes the module, solution and generated harness into dir and compiles them. It
+// returns the message to report as a compile error, or an error if the toolchain itself
+// could not run.
+func (g *Go) build(ctx context.Context, toolchain, dir string, req Request, limits Limits) (string, error) {
+	source, failure := goSolutionSource(req.Code)
+	if failure != "" {
+		return failure, nil
+	}
+	params := goHarnessParams(req)
+	var harness bytes.Buffer
+	if err := goHarnessTemplate.Execute(&harness, map[string]any{
+		"Function": req.FunctionName,
+		"Params":   params,
+		"Returns":  GoType(req.Returns) != "",
+	}); err != nil {
+		return "", fmt.Errorf("sandbox: generate harness: %w", err)
+	}
+
+	files := map[string][]byte{"go.mod": []byte(goModule), goSourceName: []byte(source), goHarnessName: harness.Bytes()}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+			return "", fmt.Errorf("sandbox: write %s: %w", name, err)
+		}
+	}
+
+	buildCtx, cancel := context.WithTimeout(ctx, goBuildTimeout)
+	defer cancel()
+
+	output := newCappedBuffer(limits.MaxOutputBytes)
+	cmd := exec.CommandContext(buildCtx, toolchain, "build", "-trimpath", "-o", goBinaryName, ".")
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=/usr/bin:/bin",
+		"HOME=" + dir,
+		"GOPATH=" + filepath.Join(dir, "gopath"),
+		"GOCACHE=" + g.cacheDir,
+		"GOPROXY=off",
+		"GOTOOLCHAIN=local",
+		"GOENV=off",
+		"GOWORK=off",
+		"GOFLAGS=",
+		"GOTELEMETRY=off",
+		"CGO_ENABLED=0",
+	}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = harnessProcessWait
+
+	err := cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	if buildCtx.Err() != nil {
+		return fmt.Sprintf("go build timed out after %s", goBuildTimeout), nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return goBuildFailure(output.String(), GoSignature(req.FunctionName, req.Params, req.Returns)), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sandbox: run go build: %w", err)
+	}
+	return "", nil
+}
+
+func (g *Go) call(ctx context.Context, dir string, input []any, limits Limits) (Result, error) {
+	if input == nil {
+		input = []any{}
+	}
+	stdin, err := json.Marshal(goRequest{
+		Args:          input,
+		CPUMS:         limits.CPUTime.Milliseconds(),
+		MemoryBytes:   limits.MaxMemoryBytes,
+		MaxOpenFiles:  limits.MaxOpenFiles,
+		MaxFileBytes:  harnessMaxFileBytes,
+		MaxStackBytes: limits.MaxMemoryBytes / 4,
+	})
+	if err != nil {
+		return Result{Status: StatusError, Error: fmt.Sprintf("encode input: %v", err), Stderr: fmt.Sprintf("encode input: %v\n", err)}, nil
+	}
+
+	return runHarness(ctx, harnessProcess{
+		runtime: "go",
+		path:    sandboxWorkDir + "/" + goBinaryName,
+		dir:     dir,
+		env:     []string{"HOME=" + sandboxWorkDir, "TMPDIR=" + sandboxWorkDir, "GOMAXPROCS=1"},
+		stdin:   stdin,
+	}, limits)
+}
+
+// goSolutionSource puts the solution in package main, adding the package clause when the
+// code has none (on the first line, so reported line numbers stay correct) and renaming
+// any other package. It returns a compile error message when the code imports a package
+// the sandbox does not allow.
+func goSolutionSource(code string) (string, string) {
+	fset := token.NewFileSet()
+	if clause, err := parser.ParseFile(fset, goSourceName, code, parser.PackageClauseOnly); err != nil {
+		code = "package main; " + code
+	} else if clause.Name.Name != "main" {
+		offset := fset.Position(clause.Name.Pos()).Offset
+		code = code[:offset] + "main" + code[offset+len(clause.Name.Name):]
+	}
+
+	file, err := parser.ParseFile(fset, goSourceName, code, parser.ImportsOnly)
+	if err != nil {
+		// Leave syntax errors to the compiler so they read like any other build failure.
+		return code, ""
+	}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		position := fset.Position(spec.Pos())
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			return "", fmt.Sprintf("%s:%d:%d: package %q is not available: only the standard library can be imported", goSourceName, position.Line, position.Column, path)
+		}
+		for _, blocked := range goBlockedImports {
+			if path == blocked || strings.HasPrefix(path, blocked+"/") {
+				return "", fmt.Sprintf("%s:%d:%d: import %q is not allowed in the sandbox", goSourceName, position.Line, position.Column, path)
+			}
+		}
+	}
+	return code, ""
+}
+
+// goHarnessParams derives the harness arguments from the declared parameters. Problems
+// without declared parameters take as many untyped arguments as the first input has.
+func goHarnessParams(req Request) []goHarnessParam {
+	params := make([]goHarnessParam, 0, len(req.Params))
+	for i, param := range req.Params {
+		params = append(params, goHarnessParam{Name: goParamName(param.Name, i), Type: elementType(param.Type)})
+	}
+	if len(params) == 0 && len(req.Inputs) > 0 {
+		for i := range req.Inputs[0] {
+			params = append(params, goHarnessParam{Name: fmt.Sprintf("arg%d", i), Type: "any"})
+		}
+	}
+	return params
+}
+
+// goBuildFailure trims the toolchain's output to the compiler diagnostics. Errors in the
+// harness mean the solution does not match the declared signature, so the expected
+// declaration is appended.
+func goBuildFailure(output, signature string) string {
+	var lines []string
+	mismatch := false
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "./")
+		if strings.HasPrefix(line, goHarnessName+":") {
+			mismatch = true
+		}
+		lines = append(lines, line)
+	}
+	if mismatch {
+		lines = append(lines, "the solution must be declared as: "+signature)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,143 @@
+package sandbox
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// GoType maps a parameter or return type written in the problem's TypeScript-style notation
+// to the Go type a solution declares for it. Arrays become slices, Record<string, T> becomes
+// a map and number means int, since generated problems use it for integers; "float" asks for
+// float64. Go spellings such as []int or map[string]bool are accepted as well. void maps to
+// the empty string, meaning no result; an empty or unrecognised type maps to any.
+func GoType(t string) string {
+	t = strings.TrimSpace(t)
+	for len(t) > 1 && t[0] == '(' && t[len(t)-1] == ')' && closingBracket(t, 0) == len(t)-1 {
+		t = strings.TrimSpace(t[1 : len(t)-1])
+	}
+	switch {
+	case strings.HasSuffix(t, "[]"):
+		return "[]" + elementType(t[:len(t)-2])
+	case strings.HasPrefix(t, "[]"):
+		return "[]" + elementType(t[2:])
+	case strings.HasPrefix(t, "map["):
+		if end := closingBracket(t, 3); end > 0 {
+			return mapType(t[4:end], t[end+1:])
+		}
+		return "any"
+	}
+	if open := strings.IndexByte(t, '<'); open > 0 && strings.HasSuffix(t, ">") && closingBracket(t, open) == len(t)-1 {
+		args := splitTypeArgs(t[open+1 : len(t)-1])
+		switch name := strings.TrimSpace(t[:open]); {
+		case (name == "Array" || name == "ReadonlyArray") && len(args) == 1:
+			return "[]" + elementType(args[0])
+		case (name == "Record" || name == "Map") && len(args) == 2:
+			return mapType(args[0], args[1])
+		}
+		return "any"
+	}
+
+	switch strings.ToLower(t) {
+	case "number", "int", "integer":
+		return "int"
+	case "int64", "bigint":
+		return "int64"
+	case "float", "float64", "double", "decimal":
+		return "float64"
+	case "string", "char":
+		return "string"
+	case "boolean", "bool":
+		return "bool"
+	case "void", "undefined":
+		return ""
+	default:
+		return "any"
+	}
+}
+
+// GoSignature renders the Go declaration of a solution function, such as
+// "func twoSum(nums []int, target int) []int". Parameter names that are Go keywords gain a
+// trailing underscore and names that are not identifiers are replaced by argN.
+func GoSignature(functionName string, params []Param, returns string) string {
+	var b strings.Builder
+	b.WriteString("func " + functionName + "(")
+	for i, param := range params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(goParamName(param.Name, i) + " " + GoType(param.Type))
+	}
+	b.WriteString(")")
+	if result := GoType(returns); result != "" {
+		b.WriteString(" " + result)
+	}
+	return b.String()
+}
+
+func goParamName(name string, index int) string {
+	name = strings.TrimSpace(name)
+	switch {
+	case token.IsKeyword(name):
+		return name + "_"
+	case token.IsIdentifier(name):
+		return name
+	default:
+		return fmt.Sprintf("arg%d", index)
+	}
+}
+
+// elementType is GoType for slice elements and map values, where "no result" is meaningless.
+func elementType(t string) string {
+	if element := GoType(t); element != "" {
+		return element
+	}
+	return "any"
+}
+
+// mapType builds a map from JSON object keys, which decode into string or integer keys only.
+func mapType(key, value string) string {
+	switch k := GoType(key); k {
+	case "string", "int", "int64":
+		return "map[" + k + "]" + elementType(value)
+	default:
+		return "any"
+	}
+}
+
+// closingBracket returns the index of the bracket matching the one at open, or -1.
+func closingBracket(t string, open int) int {
+	depth := 0
+	for i := open; i < len(t); i++ {
+		switch t[i] {
+		case '<', '[', '(', '{':
+			depth++
+		case '>', ']', ')', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTypeArgs splits a type argument list at its top-level commas.
+func splitTypeArgs(list string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '<', '[', '(', '{':
+			depth++
+		case '>', ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, list[start:])
+}
@@ -0,0 +1,284 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var twoSumParams = []Param{{Name: "nums", Type: "number[]"}, {Name: "target", Type: "number"}}
+
+func runGo(t *testing.T, req Request) []Result {
+	t.Helper()
+	if _, err := exec.LookPath(defaultGo); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	results, err := NewGo("").Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(results) != len(req.Inputs) {
+		t.Fatalf("expected %d results, got %d", len(req.Inputs), len(results))
+	}
+	return results
+}
+
+func TestGoTypeMapsSignatureNotation(t *testing.T) {
+	cases := map[string]string{
+		"number":                     "int",
+		"integer":                    "int",
+		"float":                      "float64",
+		"string":                     "string",
+		"boolean":                    "bool",
+		"number[][]":                 "[][]int",
+		"(string)[]":                 "[]string",
+		"Array<Array<boolean>>":      "[][]bool",
+		"Record<string, number[]>":   "map[string][]int",
+		"[]float64":                  "[]float64",
+		"map[string][]int":           "map[string][]int",
+		"void":                       "",
+		"":                           "any",
+		"number | null":              "any",
+		"Record<boolean, number>":    "any",
+		"ListNode":                   "any",
+		"Array<void>":                "[]any",
+		"Map<string, Array<string>>": "map[string][]string",
+	}
+	for input, want := range cases {
+		if got := GoType(input); got != want {
+			t.Errorf("GoType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestGoSignatureRendersDeclaration(t *testing.T) {
+	cases := []struct {
+		params  []Param
+		returns string
+		want    string
+	}{
+		{twoSumParams, "number[]", "func twoSum(nums []int, target int) []int"},
+		{[]Param{{Name: "type", Type: "string"}, {Name: "max-depth", Type: "number"}}, "void", "func twoSum(type_ string, arg1 int)"},
+		{nil, "", "func twoSum() any"},
+	}
+	for _, tc := range cases {
+		if got := GoSignature("twoSum", tc.params, tc.returns); got != tc.want {
+			t.Errorf("GoSignature(%v, %q) = %q, want %q", tc.params, tc.returns, got, tc.want)
+		}
+	}
+}
+
+func TestGoReturnsJSONValues(t *testing.T) {
+	code := `func twoSum(nums []int, target int) []int {
+	seen := map[int]int{}
+	for i, n := range nums {
+		if j, ok := seen[target-n]; ok {
+			return []int{j, i}
+		}
+		seen[n] = i
+	}
+	return nil
+}
+`
+
+	results := runGo(t, Request{
+		Code:         code,
+		FunctionName: "twoSum",
+		Params:       twoSumParams,
+		Returns:      "number[]",
+		Inputs:       [][]any{{[]any{2, 7, 11}, 9}, {[]any{1}, 5}},
+	})
+	if results[0].Status != StatusOK || !reflect.DeepEqual(results[0].Value, []any{float64(0), float64(1)}) {
+		t.Fatalf("unexpected first result %s %#v (%s)", results[0].Status, results[0].Value, results[0].Error)
+	}
+	if results[1].Status != StatusOK || results[1].Value != nil {
+		t.Fatalf("expected nil slice to encode as null, got %s %#v", results[1].Status, results[1].Value)
+	}
+}
+
+func TestGoAcceptsPackageClauseAndImports(t *testing.T) {
+	code := `package solution
+
+import (
+	"fmt"
+	"os"
+)
+
+func label(counts map[string]int) string {
+	fmt.Fprintln(os.Stderr, "debug")
+	return fmt.Sprint(counts["a"])
+}
+`
+
+	results := runGo(t, Request{
+		Code:         code,
+		FunctionName: "label",
+		Params:       []Param{{Name: "counts", Type: "Record<string, number>"}},
+		Returns:      "string",
+		Inputs:       [][]any{{map[string]any{"a": 3}}},
+	})
+	if results[0].Status != StatusCompileError || !strings.Contains(results[0].Error, `solution.go:5:2: import "os" is not allowed in the sandbox`) {
+		t.Fatalf("expected os import to be rejected, got %s %q", results[0].Status, results[0].Error)
+	}
+
+	results = runGo(t, Request{
+		Code:         strings.Replace(strings.Replace(code, "\t\"os\"\n", "", 1), "fmt.Fprintln(os.Stderr, ", "fmt.Println(", 1),
+		FunctionName: "label",
+		Params:       []Param{{Name: "counts", Type: "Record<string, number>"}},
+		Returns:      "string",
+		Inputs:       [][]any{{map[string]any{"a": 3}}},
+	})
+	if results[0].Status != StatusOK || results[0].Value != "3" || results[0].Stdout != "debug\n" {
+		t.Fatalf("unexpected result %s %#v stdout %q (%s)", results[0].Status, results[0].Value, results[0].Stdout, results[0].Error)
+	}
+}
+
+func TestGoReportsPanicsWithSourceLine(t *testing.T) {
+	code := `func pick(nums []int, i int) int {
+	println("picking")
+	return nums[i]
+}
+`
+
+	results := runGo(t, Request{
+		Code:         code,
+		FunctionName: "pick",
+		Params:       []Param{{Name: "nums", Type: "number[]"}, {Name: "i", Type: "number"}},
+		Returns:      "number",
+		Inputs:       [][]any{{[]any{4, 5}, 1}, {[]any{4, 5}, 2}, {[]any{4, 5}, 1.5}},
+	})
+	if results[0].Status != StatusOK || results[0].Value != float64(5) || results[0].Stderr != "picking\n" {
+		t.Fatalf("unexpected first result %s %#v stderr %q", results[0].Status, results[0].Value, results[0].Stderr)
+	}
+	if results[1].Status != StatusError || !strings.Contains(results[1].Error, "panic: runtime error: index out of range [2] with length 2 (solution.go:3)") {
+		t.Fatalf("expected panic with source line, got %s %q", results[1].Status, results[1].Error)
+	}
+	if results[2].Status != StatusError || !strings.Contains(results[2].Error, "argument i:") {
+		t.Fatalf("expected argument decoding error, got %s %q", results[2].Status, results[2].Error)
+	}
+}
+
+func TestGoReportsCompileErrors(t *testing.T) {
+	results := runGo(t, Request{
+		Code:         "func solve(n int) int {\n\treturn m\n}\n",
+		FunctionName: "solve",
+		Params:       []Param{{Name: "n", Type: "number"}},
+		Returns:      "number",
+		Inputs:       [][]any{{1}, {2}},
+	})
+	for _, result := range results {
+		if result.Status != StatusCompileError || !strings.HasPrefix(result.Error, "solution.go:2:9: undefined: m") {
+			t.Fatalf("expected compile error, got %s %q", result.Status, result.Error)
+		}
+	}
+
+	results = runGo(t, Request{
+		Code:         "func solve(n float64) float64 {\n\treturn n\n}\n",
+		FunctionName: "solve",
+		Params:       []Param{{Name: "n", Type: "number"}},
+		Returns:      "number",
+		Inputs:       [][]any{{1}},
+	})
+	if results[0].Status != StatusCompileError || !strings.Contains(results[0].Error, "the solution must be declared as: func solve(n int) int") {
+		t.Fatalf("expected signature mismatch, got %s %q", results[0].Status, results[0].Error)
+	}
+
+	results = runGo(t, Request{
+		Code:         "import \"github.com/acme/fast\"\n\nfunc solve(n int) int { return fast.Do(n) }\n",
+		FunctionName: "solve",
+		Params:       []Param{{Name: "n", Type: "number"}},
+		Returns:      "number",
+		Inputs:       [][]any{{1}},
+	})
+	if results[0].Status != StatusCompileError || !strings.Contains(results[0].Error, "only the standard library can be imported") {
+		t.Fatalf("expected third-party import to be rejected, got %s %q", results[0].Status, results[0].Error)
+	}
+}
+
+func TestGoEnforcesLimits(t *testing.T) {
+	spin := `func spin() {
+	for {
+	}
+}
+`
+	// The initializer runs before spin is called, so it is only bounded if the harness sets
+	// its limits before the solution's package initialization.
+	spinInInit := "var ready = func() bool {\n\tfor {\n\t}\n}()\n\nfunc spin() {}\n"
+	hog := `func hog(n int) int {
+	blocks := [][]byte{}
+	for i := 0; i < n; i++ {
+		blocks = append(blocks, make([]byte, 1<<20))
+		blocks[i][0] = 1
+	}
+	return len(blocks)
+}
+`
+
+	for _, code := range []string{spin, spinInInit} {
+		start := time.Now()
+		results := runGo(t, Request{Code: code, FunctionName: "spin", Returns: "void", Inputs: [][]any{{}}, Limits: Limits{CPUTime: 100 * time.Millisecond}})
+		if elapsed := time.Since(start); elapsed > 30*time.Second {
+			t.Fatalf("timeout took too long: %s", elapsed)
+		}
+		if results[0].Status != StatusTimeout {
+			t.Fatalf("expected timeout, got %s (%s)", results[0].Status, results[0].Error)
+		}
+	}
+
+	results := runGo(t, Request{
+		Code:         hog,
+		FunctionName: "hog",
+		Params:       []Param{{Name: "n", Type: "number"}},
+		Returns:      "number",
+		Inputs:       [][]any{{8}, {512}},
+		Limits:       Limits{MaxMemoryBytes: 128 << 20},
+	})
+	if results[0].Status != StatusOK || results[0].Value != float64(8) {
+		t.Fatalf("expected small allocation to succeed, got %s %#v (%s)", results[0].Status, results[0].Value, results[0].Error)
+	}
+	if results[1].Status != StatusError || !strings.Contains(results[1].Stderr, "out of memory") {
+		t.Fatalf("expected memory limit, got %s %q", results[1].Status, results[1].Stderr)
+	}
+}
+
+func TestGoCannotReadParentEnvironment(t *testing.T) {
+	const secret = "sk-secret-from-parent"
+	t.Setenv("IMPROVIEW_SANDBOX_PROBE", secret)
+	// text/template reads files without importing os, which the import check blocks.
+	code := `import (
+	"fmt"
+	"text/template"
+)
+
+func probe(pid string) string {
+	tmpl, err := template.ParseFiles(fmt.Sprintf("/proc/%s/environ", pid))
+	if err != nil {
+		panic(err)
+	}
+	return tmpl.Tree.Root.String()
+}
+`
+
+	results := runGo(t, Request{
+		Code:         code,
+		FunctionName: "probe",
+		Params:       []Param{{Name: "pid", Type: "string"}},
+		Returns:      "string",
+		Inputs:       [][]any{{strconv.Itoa(os.Getpid())}, {"1"}, {"self"}},
+	})
+	for i, result := range results {
+		if result.Status == StatusOK {
+			t.Fatalf("probe %d: expected the read to fail, got %q", i, result.Value)
+		}
+		if leaked := fmt.Sprint(result.Value) + result.Stdout + result.Stderr + result.Error; strings.Contains(leaked, secret) {
+			t.Fatalf("probe %d: parent environment leaked: %q", i, leaked)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// isolationInitArg is argv[0] when a binary is re-executed to isolate one subprocess run.
+const isolationInitArg = "improview-sandbox-init"
+
+const (
+	// sandboxWorkDir is where a run's working directory appears inside its isolated root.
+	sandboxWorkDir = "/sandbox"
+	// isolationFailedExit is the exit status of an init that could not isolate its run.
+	isolationFailedExit = 125
+)
+
+// isolationSpec tells the re-executed init how to build the isolated root and what to run
+// in it. Work is mounted read-write at sandboxWorkDir and each ReadOnly host path appears
+// read-only at the same path; nothing else of the host filesystem is visible.
+type isolationSpec struct {
+	Root     string   `json:"root"`
+	Work     string   `json:"work"`
+	ReadOnly []string `json:"read_only"`
+	Path     string   `json:"path"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env"`
+}
+
+// isolationStartError explains a failure to create the namespaces, which usually means
+// the host does not allow unprivileged user namespaces.
+func isolationStartError(runtime string, err error) error {
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("sandbox: start %s: %w (isolated runs need user namespaces)", runtime, err)
+	}
+	return fmt.Errorf("sandbox: start %s: %w", runtime, err)
+}
+
+// trimIsolationError extracts the init's own message from a run's stderr.
+func trimIsolationError(stderr string) string {
+	if _, message, ok := strings.Cut(stderr, "sandbox: isolate: "); ok {
+		return strings.TrimSpace(message)
+	}
+	return strings.TrimSpace(stderr)
+}
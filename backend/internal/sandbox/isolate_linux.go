@@ -0,0 +1,268 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sandboxID is the user and group a run has inside its user namespace.
+	sandboxID = 1000
+	// nobodyID is the host user and group runs are mapped to when the server runs as root.
+	nobodyID = 65534
+)
+
+// isolationDevices are the device nodes bound into every isolated root.
+var isolationDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// Init runs the isolation side of a subprocess sandbox. Executors that start subprocesses
+// re-execute the current binary to set up each run, so Init must be the first call in main
+// (and in TestMain of packages whose tests run those executors). In a re-executed process
+// Init never returns; otherwise it does nothing.
+func Init() {
+	if len(os.Args) != 2 || os.Args[0] != isolationInitArg {
+		return
+	}
+	// Namespaces, no_new_privs and the seccomp filter are per thread until execve.
+	runtime.LockOSThread()
+
+	var spec isolationSpec
+	err := json.Unmarshal([]byte(os.Args[1]), &spec)
+	if err == nil {
+		err = spec.enter()
+	}
+	fmt.Fprintf(os.Stderr, "sandbox: isolate: %v\n", err)
+	os.Exit(isolationFailedExit)
+}
+
+// isolatedCommand prepares proc to run in fresh user, mount, PID, network, IPC, UTS and
+// cgroup namespaces, seeing only an empty root that holds its working directory,
+// proc.readOnly and a few device nodes. The run itself is an unprivileged user: mapped to
+// nobody when the server is root, or to the server's own user otherwise. The returned
+// cleanup removes the root's mountpoint.
+func isolatedCommand(ctx context.Context, proc harnessProcess) (*exec.Cmd, func(), error) {
+	root, err := os.MkdirTemp("", "improview-root-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("sandbox: create isolated root: %w", err)
+	}
+	cleanup := func() { os.Remove(root) }
+
+	spec, err := json.Marshal(isolationSpec{
+		Root:     root,
+		Work:     proc.dir,
+		ReadOnly: proc.readOnly,
+		Path:     proc.path,
+		Args:     proc.args,
+		Env:      proc.env,
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("sandbox: encode isolation: %w", err)
+	}
+
+	attr := &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS | unix.CLONE_NEWCGROUP,
+		Pdeathsig: syscall.SIGKILL,
+	}
+	if uid, gid := os.Getuid(), os.Getgid(); uid == 0 {
+		// The init stays root long enough to bind host paths only root can reach, then
+		// switches to the sandbox user, which owns nothing on the host but the working dir.
+		if err := chownTree(nobodyID, nobodyID, proc.dir); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("sandbox: hand working dir to sandbox user: %w", err)
+		}
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: 0, Size: 1}, {ContainerID: sandboxID, HostID: nobodyID, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: 0, Size: 1}, {ContainerID: sandboxID, HostID: nobodyID, Size: 1}}
+		attr.GidMappingsEnableSetgroups = true
+	} else {
+		// Without root only the server's own user can be mapped; the init starts as the
+		// sandbox user and keeps just the capability it needs to mount.
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: sandboxID, HostID: uid, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: sandboxID, HostID: gid, Size: 1}}
+		attr.Credential = &syscall.Credential{Uid: sandboxID, Gid: sandboxID, NoSetGroups: true}
+		attr.AmbientCaps = []uintptr{unix.CAP_SYS_ADMIN}
+	}
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe", string(spec))
+	cmd.Args[0] = isolationInitArg
+	cmd.Env = []string{}
+	cmd.SysProcAttr = attr
+	return cmd, cleanup, nil
+}
+
+// chownTree hands paths and everything below them to the sandbox user without following
+// symlinks.
+func chownTree(uid, gid int, paths ...string) error {
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(name string, _ fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Lchown(name, uid, gid)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enter builds the isolated root, becomes the sandbox user without any capabilities,
+// installs the seccomp filter and replaces the init with the run. It only returns on
+// failure.
+func (s isolationSpec) enter() error {
+	if err := s.buildRoot(); err != nil {
+		return err
+	}
+	if os.Getuid() == 0 {
+		if err := unix.Setgroups(nil); err != nil {
+			return fmt.Errorf("clear groups: %w", err)
+		}
+		if err := unix.Setresgid(sandboxID, sandboxID, sandboxID); err != nil {
+			return fmt.Errorf("switch group: %w", err)
+		}
+		if err := unix.Setresuid(sandboxID, sandboxID, sandboxID); err != nil {
+			return fmt.Errorf("switch user: %w", err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("clear ambient capabilities: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	if err := installSeccomp(); err != nil {
+		return err
+	}
+	if err := unix.Exec(s.Path, append([]string{s.Path}, s.Args...), s.Env); err != nil {
+		return fmt.Errorf("exec %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// buildRoot mounts a small tmpfs at Root, binds the allowed host paths into it, pivots into
+// it and detaches the host filesystem. No /proc is mounted.
+func (s isolationSpec) buildRoot() error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := unix.Mount("tmpfs", s.Root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+	for _, path := range s.ReadOnly {
+		if err := bindInto(s.Root, path, path, true); err != nil {
+			return err
+		}
+	}
+	for _, device := range isolationDevices {
+		if err := bindInto(s.Root, device, device, false); err != nil {
+			return err
+		}
+	}
+	if err := bindInto(s.Root, s.Work, sandboxWorkDir, false); err != nil {
+		return err
+	}
+
+	oldRoot := filepath.Join(s.Root, ".oldroot")
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return fmt.Errorf("create old root: %w", err)
+	}
+	if err := unix.PivotRoot(s.Root, oldRoot); err != nil {
+		return fmt.Errorf("pivot root: %w", err)
+	}
+	if err := unix.Chdir("/"); err != nil {
+		return fmt.Errorf("enter root: %w", err)
+	}
+	if err := unix.Unmount("/.oldroot", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach host filesystem: %w", err)
+	}
+	if err := os.Remove("/.oldroot"); err != nil {
+		return fmt.Errorf("remove old root: %w", err)
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("make root read-only: %w", err)
+	}
+	if err := unix.Chdir(sandboxWorkDir); err != nil {
+		return fmt.Errorf("enter working dir: %w", err)
+	}
+	return nil
+}
+
+// bindInto makes the host path source visible at target inside root. Symlinks are
+// recreated rather than followed, so merged-/usr layouts look the same inside; missing
+// sources are skipped. Read-only binds also lose setuid bits and device access.
+func bindInto(root, source, target string, readOnly bool) error {
+	info, err := os.Lstat(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", source, err)
+	}
+
+	dest := filepath.Join(root, target)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", target, err)
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(source)
+		if err != nil {
+			return fmt.Errorf("read link %s: %w", source, err)
+		}
+		if err := os.Symlink(link, dest); err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("link %s: %w", target, err)
+		}
+		return nil
+	case info.IsDir():
+		err = os.MkdirAll(dest, 0o755)
+	default:
+		var file *os.File
+		if file, err = os.OpenFile(dest, os.O_CREATE|os.O_WRONLY, 0o644); err == nil {
+			err = file.Close()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("create %s: %w", target, err)
+	}
+
+	if err := unix.Mount(source, dest, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", source, err)
+	}
+	if !readOnly {
+		return nil
+	}
+	// A remount inside a user namespace must keep the flags the host mount locked in.
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dest, &stat); err != nil {
+		return fmt.Errorf("inspect mount %s: %w", target, err)
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY | unix.MS_NOSUID | unix.MS_NODEV)
+	for statFlag, mountFlag := range map[int64]uintptr{
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	} {
+		if int64(stat.Flags)&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	if err := unix.Mount("", dest, "", flags, ""); err != nil {
+		return fmt.Errorf("make %s read-only: %w", target, err)
+	}
+	return nil
+}
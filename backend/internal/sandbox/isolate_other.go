@@ -0,0 +1,18 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// Init does nothing on this platform; see the Linux implementation.
+func Init() {}
+
+// isolatedCommand always fails: subprocess runs are only isolated on Linux, and they are
+// never started without isolation.
+func isolatedCommand(context.Context, harnessProcess) (*exec.Cmd, func(), error) {
+	return nil, nil, errors.New("sandbox: isolated runs require Linux")
+}
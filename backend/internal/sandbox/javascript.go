@@ -0,0 +1,283 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+const jsSourceName = "solution.js"
+
+var errCPUTimeExceeded = errors.New("cpu time limit exceeded")
+
+// moduleSyntaxPattern matches ES module export prefixes so solutions written as modules can
+// run as plain scripts. Matches are blanked rather than removed to keep columns stable.
+var moduleSyntaxPattern = regexp.MustCompile(`(?m)^[ \t]*(export[ \t]+(?:default[ \t]+)?)(?:async[ \t]+function|function|class|const|let|var)\b`)
+
+// JavaScript executes ES2022 solutions inside an embedded interpreter. Each input runs in a
+// fresh runtime with no filesystem, network, module loader or host bindings beyond a
+// captured console.
+type JavaScript struct {
+	// sourceName labels the solution in error locations; it defaults to solution.js.
+	sourceName string
+}
+
+// NewJavaScript constructs a JavaScript executor.
+func NewJavaScript() *JavaScript {
+	return &JavaScript{}
+}
+
+// Execute compiles the solution once and calls the requested function with each input.
+func (j *JavaScript) Execute(ctx context.Context, req Request) ([]Result, error) {
+	if !ValidFunctionName(req.FunctionName) {
+		return nil, fmt.Errorf("sandbox: invalid function name %q", req.FunctionName)
+	}
+
+	sourceName := j.sourceName
+	if sourceName == "" {
+		sourceName = jsSourceName
+	}
+	program, err := goja.Compile(sourceName, stripModuleSyntax(req.Code), false)
+	if err != nil {
+		return compileFailure(len(req.Inputs), err.Error()), nil
+	}
+
+	limits := req.Limits.withDefaults()
+	results := make([]Result, 0, len(req.Inputs))
+	for _, input := range req.Inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results = append(results, callJS(ctx, program, req.FunctionName, input, limits))
+	}
+	return results, nil
+}
+
+func stripModuleSyntax(code string) string {
+	return moduleSyntaxPattern.ReplaceAllStringFunc(code, func(match string) string {
+		sub := moduleSyntaxPattern.FindStringSubmatchIndex(match)
+		prefix := match[sub[2]:sub[3]]
+		return match[:sub[2]] + strings.Repeat(" ", len(prefix)) + match[sub[3]:]
+	})
+}
+
+// jsRuntime bundles a goja runtime with host helpers captured before user code runs, so
+// overriding JSON or console from the solution cannot affect the harness.
+type jsRuntime struct {
+	vm        *goja.Runtime
+	parse     goja.Callable
+	stringify goja.Callable
+	stdout    *cappedBuffer
+	stderr    *cappedBuffer
+}
+
+func newJSRuntime(limits Limits) (*jsRuntime, error) {
+	vm := goja.New()
+	vm.SetMaxCallStackSize(limits.MaxStackDepth)
+
+	jsonObj := vm.Get("JSON").ToObject(vm)
+	parse, ok := goja.AssertFunction(jsonObj.Get("parse"))
+	if !ok {
+		return nil, errors.New("sandbox: JSON.parse unavailable")
+	}
+	stringify, ok := goja.AssertFunction(jsonObj.Get("stringify"))
+	if !ok {
+		return nil, errors.New("sandbox: JSON.stringify unavailable")
+	}
+
+	rt := &jsRuntime{
+		vm:        vm,
+		parse:     parse,
+		stringify: stringify,
+		stdout:    newCappedBuffer(limits.MaxOutputBytes),
+		stderr:    newCappedBuffer(limits.MaxOutputBytes),
+	}
+
+	console := vm.NewObject()
+	for name, sink := range map[string]*cappedBuffer{
+		"log":   rt.stdout,
+		"info":  rt.stdout,
+		"debug": rt.stdout,
+		"warn":  rt.stderr,
+		"error": rt.stderr,
+	} {
+		if err := console.Set(name, rt.consoleWriter(sink)); err != nil {
+			return nil, fmt.Errorf("sandbox: install console.%s: %w", name, err)
+		}
+	}
+	if err := vm.Set("console", console); err != nil {
+		return nil, fmt.Errorf("sandbox: install console: %w", err)
+	}
+
+	module := vm.NewObject()
+	exports := vm.NewObject()
+	if err := module.Set("exports", exports); err != nil {
+		return nil, fmt.Errorf("sandbox: install module: %w", err)
+	}
+	if err := vm.Set("module", module); err != nil {
+		return nil, fmt.Errorf("sandbox: install module: %w", err)
+	}
+	if err := vm.Set("exports", exports); err != nil {
+		return nil, fmt.Errorf("sandbox: install exports: %w", err)
+	}
+
+	return rt, nil
+}
+
+func (rt *jsRuntime) consoleWriter(sink *cappedBuffer) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			parts[i] = rt.formatConsoleArg(arg)
+		}
+		sink.WriteString(strings.Join(parts, " ") + "\n")
+		return goja.Undefined()
+	}
+}
+
+func (rt *jsRuntime) formatConsoleArg(arg goja.Value) string {
+	if arg == nil || goja.IsUndefined(arg) {
+		return "undefined"
+	}
+	if goja.IsNull(arg) {
+		return "null"
+	}
+	if _, isFunc := goja.AssertFunction(arg); isFunc {
+		return arg.String()
+	}
+	if _, isObject := arg.(*goja.Object); isObject {
+		if encoded, err := rt.stringify(goja.Undefined(), arg); err == nil && !goja.IsUndefined(encoded) {
+			return encoded.String()
+		}
+	}
+	return arg.String()
+}
+
+// lookup resolves the entry point from a global declaration or CommonJS-style exports.
+func (rt *jsRuntime) lookup(name string) (goja.Callable, bool) {
+	resolved, err := rt.vm.RunString(fmt.Sprintf(
+		"typeof %[1]s === 'function' ? %[1]s : (module.exports && typeof module.exports.%[1]s === 'function' ? module.exports.%[1]s : (typeof module.exports === 'function' ? module.exports : undefined))",
+		name,
+	))
+	if err != nil {
+		return nil, false
+	}
+	return goja.AssertFunction(resolved)
+}
+
+func (rt *jsRuntime) arguments(input []any) ([]goja.Value, error) {
+	if input == nil {
+		input = []any{}
+	}
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("encode input: %w", err)
+	}
+	parsed, err := rt.parse(goja.Undefined(), rt.vm.ToValue(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("decode input: %w", err)
+	}
+	array := parsed.ToObject(rt.vm)
+	length := int(array.Get("length").ToInteger())
+	args := make([]goja.Value, length)
+	for i := 0; i < length; i++ {
+		args[i] = array.Get(fmt.Sprint(i))
+	}
+	return args, nil
+}
+
+func (rt *jsRuntime) export(value goja.Value) (any, error) {
+	if promise, ok := value.Export().(*goja.Promise); ok {
+		switch promise.State() {
+		case goja.PromiseStateFulfilled:
+			value = promise.Result()
+		case goja.PromiseStateRejected:
+			return nil, fmt.Errorf("promise rejected: %s", rt.formatConsoleArg(promise.Result()))
+		default:
+			return nil, errors.New("returned promise never settled")
+		}
+	}
+
+	encoded, err := rt.stringify(goja.Undefined(), value)
+	if err != nil {
+		return nil, fmt.Errorf("return value is not JSON-serializable: %w", err)
+	}
+	if goja.IsUndefined(encoded) {
+		return nil, nil
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(encoded.String()), &decoded); err != nil {
+		return nil, fmt.Errorf("decode return value: %w", err)
+	}
+	return decoded, nil
+}
+
+func callJS(ctx context.Context, program *goja.Program, functionName string, input []any, limits Limits) Result {
+	rt, err := newJSRuntime(limits)
+	if err != nil {
+		return Result{Status: StatusError, Error: err.Error(), Stderr: err.Error()}
+	}
+
+	timer := time.AfterFunc(limits.CPUTime, func() { rt.vm.Interrupt(errCPUTimeExceeded) })
+	defer timer.Stop()
+	stop := context.AfterFunc(ctx, func() { rt.vm.Interrupt(ctx.Err()) })
+	defer stop()
+
+	finish := func(status Status, value any, duration time.Duration, runErr error) Result {
+		result := Result{
+			Status:   status,
+			Value:    value,
+			Stdout:   rt.stdout.String(),
+			Duration: duration,
+		}
+		if runErr != nil {
+			result.Error = runErr.Error()
+			rt.stderr.WriteString(result.Error + "\n")
+		}
+		result.Stderr = rt.stderr.String()
+		return result
+	}
+
+	if _, err := rt.vm.RunProgram(program); err != nil {
+		return finish(classifyJSError(err), nil, 0, err)
+	}
+
+	fn, ok := rt.lookup(functionName)
+	if !ok {
+		return finish(StatusError, nil, 0, fmt.Errorf("function %s is not defined", functionName))
+	}
+
+	args, err := rt.arguments(input)
+	if err != nil {
+		return finish(StatusError, nil, 0, err)
+	}
+
+	start := time.Now()
+	returned, err := fn(goja.Undefined(), args...)
+	duration := time.Since(start)
+	if err != nil {
+		return finish(classifyJSError(err), nil, duration, err)
+	}
+
+	value, err := rt.export(returned)
+	if err != nil {
+		return finish(classifyJSError(err), nil, duration, err)
+	}
+	return finish(StatusOK, value, duration, nil)
+}
+
+func classifyJSError(err error) Status {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if cause, ok := interrupted.Value().(error); ok && errors.Is(cause, errCPUTimeExceeded) {
+			return StatusTimeout
+		}
+	}
+	return StatusError
+}
@@ -0,0 +1,184 @@
+package sandbox
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runJS(t *testing.T, code, fn string, inputs [][]any, limits Limits) []Result {
+	t.Helper()
+
+	results, err := NewJavaScript().Execute(context.Background(), Request{
+		Code:         code,
+		FunctionName: fn,
+		Inputs:       inputs,
+		Limits:       limits,
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("expected %d results, got %d", len(inputs), len(results))
+	}
+	return results
+}
+
+func TestJavaScriptReturnsJSONValues(t *testing.T) {
+	code := `
+export function pairs(nums, target) {
+  const out = [];
+  for (let i = 0; i < nums.length; i++) {
+    for (let j = i + 1; j < nums.length; j++) {
+      if (nums[i] + nums[j] === target) out.push({ i, j, sum: nums[i] + nums[j] });
+    }
+  }
+  return out;
+}`
+
+	results := runJS(t, code, "pairs", [][]any{{[]any{1, 2, 3}, 4}}, Limits{})
+	if results[0].Status != StatusOK {
+		t.Fatalf("expected ok, got %s (%s)", results[0].Status, results[0].Error)
+	}
+
+	want := []any{map[string]any{"i": float64(0), "j": float64(2), "sum": float64(4)}}
+	if !reflect.DeepEqual(results[0].Value, want) {
+		t.Fatalf("unexpected value %#v", results[0].Value)
+	}
+}
+
+func TestJavaScriptSupportsModernSyntax(t *testing.T) {
+	code := `
+class Counter {
+  #count = 0;
+  add(n = 1) { this.#count += n; return this; }
+  get value() { return this.#count; }
+}
+const tally = async (items) => {
+  const counter = new Counter();
+  for (const { weight } of items) counter.add(weight ?? 1);
+  return counter.value;
+};`
+
+	results := runJS(t, code, "tally", [][]any{{[]any{map[string]any{"weight": 2}, map[string]any{}}}}, Limits{})
+	if results[0].Status != StatusOK {
+		t.Fatalf("expected ok, got %s (%s)", results[0].Status, results[0].Error)
+	}
+	if results[0].Value != float64(3) {
+		t.Fatalf("expected 3, got %#v", results[0].Value)
+	}
+}
+
+func TestJavaScriptCapturesConsoleOutput(t *testing.T) {
+	code := `function echo(x) { console.log("value", x, { ok: true }); console.error("oops"); return x; }`
+
+	results := runJS(t, code, "echo", [][]any{{"hi"}}, Limits{})
+	if got := results[0].Stdout; got != "value hi {\"ok\":true}\n" {
+		t.Fatalf("unexpected stdout %q", got)
+	}
+	if got := results[0].Stderr; got != "oops\n" {
+		t.Fatalf("unexpected stderr %q", got)
+	}
+}
+
+func TestJavaScriptTruncatesOutput(t *testing.T) {
+	code := `function noisy() { for (let i = 0; i < 1000; i++) console.log("0123456789"); return 1; }`
+
+	results := runJS(t, code, "noisy", [][]any{{}}, Limits{MaxOutputBytes: 64})
+	if !strings.HasSuffix(results[0].Stdout, truncationMarker) {
+		t.Fatalf("expected truncated stdout, got %q", results[0].Stdout)
+	}
+	if len(results[0].Stdout) > 64+len(truncationMarker) {
+		t.Fatalf("stdout exceeded limit: %d bytes", len(results[0].Stdout))
+	}
+}
+
+func TestJavaScriptEnforcesCPUTimeLimit(t *testing.T) {
+	code := `function spin() { while (true) {} }`
+
+	start := time.Now()
+	results := runJS(t, code, "spin", [][]any{{}, {}}, Limits{CPUTime: 50 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("timeouts took too long: %s", elapsed)
+	}
+	for i, result := range results {
+		if result.Status != StatusTimeout {
+			t.Fatalf("result %d: expected timeout, got %s", i, result.Status)
+		}
+	}
+}
+
+func TestJavaScriptReportsRuntimeErrors(t *testing.T) {
+	code := `function boom(n) {
+  if (n > 1) throw new RangeError("too big");
+  return n;
+}`
+
+	results := runJS(t, code, "boom", [][]any{{1}, {2}}, Limits{})
+	if results[0].Status != StatusOK {
+		t.Fatalf("expected first call ok, got %s", results[0].Status)
+	}
+	if results[1].Status != StatusError {
+		t.Fatalf("expected error, got %s", results[1].Status)
+	}
+	if !strings.Contains(results[1].Stderr, "RangeError: too big") || !strings.Contains(results[1].Stderr, "solution.js:2") {
+		t.Fatalf("expected error with source location, got %q", results[1].Stderr)
+	}
+}
+
+func TestJavaScriptReportsCompileErrors(t *testing.T) {
+	results := runJS(t, `function broken( {`, "broken", [][]any{{}, {}}, Limits{})
+	for _, result := range results {
+		if result.Status != StatusCompileError {
+			t.Fatalf("expected compile error, got %s", result.Status)
+		}
+	}
+}
+
+func TestJavaScriptReportsMissingFunction(t *testing.T) {
+	results := runJS(t, `function other() { return 1; }`, "solve", [][]any{{}}, Limits{})
+	if results[0].Status != StatusError || !strings.Contains(results[0].Error, "solve is not defined") {
+		t.Fatalf("expected missing function error, got %s %q", results[0].Status, results[0].Error)
+	}
+}
+
+func TestJavaScriptIsolatesHostAndTests(t *testing.T) {
+	code := `
+let calls = 0;
+function probe() {
+  calls++;
+  return {
+    calls,
+    require: typeof require,
+    process: typeof process,
+    fetch: typeof fetch,
+    xhr: typeof XMLHttpRequest,
+  };
+}`
+
+	results := runJS(t, code, "probe", [][]any{{}, {}}, Limits{})
+	for i, result := range results {
+		want := map[string]any{
+			"calls":   float64(1),
+			"require": "undefined",
+			"process": "undefined",
+			"fetch":   "undefined",
+			"xhr":     "undefined",
+		}
+		if !reflect.DeepEqual(result.Value, want) {
+			t.Fatalf("result %d: unexpected sandbox surface %#v", i, result.Value)
+		}
+	}
+}
+
+func TestJavaScriptRejectsInvalidFunctionName(t *testing.T) {
+	_, err := NewJavaScript().Execute(context.Background(), Request{
+		Code:         "function ok() {}",
+		FunctionName: "ok; globalThis.x = 1",
+	})
+	if err == nil {
+		t.Fatalf("expected error for invalid function name")
+	}
+}
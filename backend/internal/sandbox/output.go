@@ -0,0 +1,47 @@
+package sandbox
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const truncationMarker = "\n...(output truncated)"
+
+// cappedBuffer collects console output up to a fixed number of bytes.
+type cappedBuffer struct {
+	limit     int
+	builder   strings.Builder
+	truncated bool
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.WriteString(string(p))
+	return len(p), nil
+}
+
+func (b *cappedBuffer) WriteString(s string) {
+	if b.truncated {
+		return
+	}
+	remaining := b.limit - b.builder.Len()
+	if len(s) > remaining {
+		for remaining > 0 && !utf8.RuneStart(s[remaining]) {
+			remaining--
+		}
+		b.builder.WriteString(s[:remaining])
+		b.truncated = true
+		return
+	}
+	b.builder.WriteString(s)
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.builder.String() + truncationMarker
+	}
+	return b.builder.String()
+}
@@ -0,0 +1,138 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	harnessMaxFileBytes   = 1 << 20
+	harnessMaxResultBytes = 8 << 20
+	harnessWallClockGrace = time.Second
+	harnessProcessWait    = time.Second
+)
+
+// harnessResult is the JSON document a subprocess harness writes to fd 3.
+type harnessResult struct {
+	Status     Status  `json:"status"`
+	Value      any     `json:"value"`
+	Error      string  `json:"error"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// harnessProcess describes one run of a subprocess harness. The harness reads stdin and
+// reports exactly one harnessResult on fd 3, so the solution's own output stays in
+// stdout/stderr.
+type harnessProcess struct {
+	// runtime names the process in error messages, e.g. "python".
+	runtime string
+	// path is the program as seen inside the isolated root; dir appears there as
+	// sandboxWorkDir, which is also the working directory.
+	path string
+	args []string
+	dir  string
+	// readOnly lists the host paths the program needs, e.g. its interpreter and libraries.
+	readOnly []string
+	env      []string
+	stdin    []byte
+}
+
+// runHarness starts the process in isolation (see isolatedCommand) under a wall-clock
+// deadline of the CPU budget plus a grace period and converts its report into a Result. A
+// process that dies without reporting after spending its CPU budget or hitting the deadline
+// timed out; any other silent exit is a runtime error.
+func runHarness(ctx context.Context, proc harnessProcess, limits Limits) (Result, error) {
+	reportReader, reportWriter, err := os.Pipe()
+	if err != nil {
+		return Result{}, fmt.Errorf("sandbox: create result pipe: %w", err)
+	}
+	defer reportReader.Close()
+
+	runCtx, cancel := context.WithTimeout(ctx, limits.CPUTime+harnessWallClockGrace)
+	defer cancel()
+
+	stdout := newCappedBuffer(limits.MaxOutputBytes)
+	stderr := newCappedBuffer(limits.MaxOutputBytes)
+	cmd, cleanup, err := isolatedCommand(runCtx, proc)
+	if err != nil {
+		reportWriter.Close()
+		return Result{}, err
+	}
+	defer cleanup()
+	cmd.Stdin = bytes.NewReader(proc.stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{reportWriter}
+	cmd.WaitDelay = harnessProcessWait
+
+	if err := cmd.Start(); err != nil {
+		reportWriter.Close()
+		return Result{}, isolationStartError(proc.runtime, err)
+	}
+	reportWriter.Close()
+
+	report := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(io.LimitReader(reportReader, harnessMaxResultBytes))
+		report <- data
+	}()
+	waitErr := cmd.Wait()
+	reportReader.Close()
+	data := <-report
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	finish := func(status Status, value any, duration time.Duration, runErr error) Result {
+		result := Result{
+			Status:   status,
+			Value:    value,
+			Stdout:   stdout.String(),
+			Duration: duration,
+		}
+		if runErr != nil {
+			result.Error = runErr.Error()
+			stderr.WriteString(result.Error + "\n")
+		}
+		result.Stderr = stderr.String()
+		return result
+	}
+
+	var exitErr *exec.ExitError
+	if len(data) == 0 && errors.As(waitErr, &exitErr) && exitErr.ExitCode() == isolationFailedExit {
+		return Result{}, fmt.Errorf("sandbox: isolate %s: %s", proc.runtime, trimIsolationError(stderr.String()))
+	}
+
+	var outcome harnessResult
+	if len(data) == 0 || json.Unmarshal(data, &outcome) != nil {
+		if runCtx.Err() != nil || exceededCPU(cmd.ProcessState, limits.CPUTime) {
+			return finish(StatusTimeout, nil, 0, errCPUTimeExceeded), nil
+		}
+		if waitErr == nil {
+			waitErr = errors.New("no result reported")
+		}
+		return finish(StatusError, nil, 0, fmt.Errorf("%s exited unexpectedly: %w", proc.runtime, waitErr)), nil
+	}
+
+	duration := time.Duration(outcome.DurationMS * float64(time.Millisecond))
+	switch outcome.Status {
+	case StatusOK:
+		return finish(StatusOK, outcome.Value, duration, nil), nil
+	case StatusCompileError:
+		return finish(StatusCompileError, nil, 0, errors.New(outcome.Error)), nil
+	default:
+		return finish(StatusError, nil, duration, errors.New(outcome.Error)), nil
+	}
+}
+
+func exceededCPU(state *os.ProcessState, budget time.Duration) bool {
+	return state != nil && state.UserTime()+state.SystemTime() >= budget
+}
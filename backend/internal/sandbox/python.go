@@ -0,0 +1,171 @@
+package sandbox
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// pythonSystemPaths are the host paths an isolated interpreter needs besides its own
+// installation: the shared libraries and the dynamic linker's cache.
+var pythonSystemPaths = []string{"/usr", "/lib", "/lib64", "/bin", "/etc/ld.so.cache"}
+
+const (
+	pySourceName  = "solution.py"
+	pyHarnessName = "harness.py"
+	defaultPython = "python3"
+)
+
+//go:embed python_harness.py
+var pythonHarness []byte
+
+// Python executes solutions with a CPython interpreter. Each input runs in a fresh isolated
+// process (see isolatedCommand) that sees only its temporary working directory, the
+// interpreter's installation and the system libraries, with an empty environment and
+// rlimits on CPU time, address space, open files and written file size. The harness also
+// installs an audit hook that rejects sockets, subprocesses and ctypes before any solution
+// code runs.
+//
+// The CPU limit is enforced by the kernel in whole seconds, so fractional budgets round up;
+// a wall-clock deadline of the budget plus a grace period catches solutions that sleep.
+type Python struct {
+	interpreter string
+
+	resolve  sync.Once
+	resolved string
+	readOnly []string
+	err      error
+}
+
+// NewPython constructs a Python executor. An empty interpreter means python3 from PATH.
+func NewPython(interpreter string) *Python {
+	if strings.TrimSpace(interpreter) == "" {
+		interpreter = defaultPython
+	}
+	return &Python{interpreter: interpreter}
+}
+
+// pyRequest is the JSON document the harness reads from stdin.
+type pyRequest struct {
+	Function      string `json:"function"`
+	Args          []any  `json:"args"`
+	CPUMS         int64  `json:"cpu_ms"`
+	MemoryBytes   int64  `json:"memory_bytes"`
+	MaxOpenFiles  int    `json:"max_open_files"`
+	MaxFileBytes  int64  `json:"max_file_bytes"`
+	MaxStackDepth int    `json:"max_stack_depth"`
+}
+
+// Execute runs the solution once per input. A compile error in the first run is reported
+// for every input without starting further processes.
+func (p *Python) Execute(ctx context.Context, req Request) ([]Result, error) {
+	if !ValidFunctionName(req.FunctionName) {
+		return nil, fmt.Errorf("sandbox: invalid function name %q", req.FunctionName)
+	}
+	interpreter, readOnly, err := p.interpreterPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := req.Limits.withDefaults()
+	results := make([]Result, 0, len(req.Inputs))
+	for _, input := range req.Inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := p.call(ctx, interpreter, readOnly, req.Code, req.FunctionName, input, limits)
+		if err != nil {
+			return nil, err
+		}
+		if result.Status == StatusCompileError {
+			return compileFailure(len(req.Inputs), result.Error), nil
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// interpreterPath resolves the configured interpreter to the real executable once, so
+// launchers such as pyenv shims still work when solutions run with an empty environment.
+// It also collects the host paths the interpreter must be able to read when isolated.
+func (p *Python) interpreterPath(ctx context.Context) (string, []string, error) {
+	p.resolve.Do(func() {
+		out, err := exec.CommandContext(ctx, p.interpreter, "-c", "import sys; print(sys.executable); print(sys.base_prefix)").Output()
+		if err != nil {
+			p.err = fmt.Errorf("sandbox: python interpreter %q unavailable: %w", p.interpreter, err)
+			return
+		}
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(lines) != 2 {
+			p.err = fmt.Errorf("sandbox: python interpreter %q did not report its executable", p.interpreter)
+			return
+		}
+		p.resolved = lines[0]
+		p.readOnly = append([]string(nil), pythonSystemPaths...)
+		for _, path := range []string{filepath.Dir(lines[0]), lines[1]} {
+			if !coveredBy(path, p.readOnly) {
+				p.readOnly = append(p.readOnly, path)
+			}
+		}
+	})
+	return p.resolved, p.readOnly, p.err
+}
+
+// coveredBy reports whether path is one of roots or lies below one of them.
+func coveredBy(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Python) call(ctx context.Context, interpreter string, readOnly []string, code, functionName string, input []any, limits Limits) (Result, error) {
+	dir, err := os.MkdirTemp("", "improview-python-*")
+	if err != nil {
+		return Result{}, fmt.Errorf("sandbox: create working dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, pySourceName), []byte(code), 0o600); err != nil {
+		return Result{}, fmt.Errorf("sandbox: write solution: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, pyHarnessName), pythonHarness, 0o600); err != nil {
+		return Result{}, fmt.Errorf("sandbox: write harness: %w", err)
+	}
+
+	if input == nil {
+		input = []any{}
+	}
+	stdin, err := json.Marshal(pyRequest{
+		Function:      functionName,
+		Args:          input,
+		CPUMS:         limits.CPUTime.Milliseconds(),
+		MemoryBytes:   limits.MaxMemoryBytes,
+		MaxOpenFiles:  limits.MaxOpenFiles,
+		MaxFileBytes:  harnessMaxFileBytes,
+		MaxStackDepth: limits.MaxStackDepth,
+	})
+	if err != nil {
+		return Result{Status: StatusError, Error: fmt.Sprintf("encode input: %v", err), Stderr: fmt.Sprintf("encode input: %v\n", err)}, nil
+	}
+
+	// -I ignores PYTHON* variables and user site-packages, -S skips site imports and -B
+	// keeps the working directory free of bytecode caches.
+	return runHarness(ctx, harnessProcess{
+		runtime:  "python",
+		path:     interpreter,
+		args:     []string{"-I", "-S", "-B", pyHarnessName},
+		dir:      dir,
+		readOnly: readOnly,
+		env:      []string{"PATH=/usr/bin:/bin", "HOME=" + sandboxWorkDir, "TMPDIR=" + sandboxWorkDir, "LANG=C.UTF-8"},
+		stdin:    stdin,
+	}, limits)
+}
@@ -0,0 +1,112 @@
+# Runs one call of a Python solution for the Go sandbox. The request arrives as JSON on
+# stdin and exactly one JSON result is written to fd 3, so anything the solution prints
+# stays in stdout/stderr.
+import json
+import math
+import os
+import resource
+import sys
+import time
+
+# Audit events the solution may not trigger: networking, new processes and native code.
+BLOCKED_EVENT_PREFIXES = (
+    "socket.",
+    "subprocess.",
+    "os.system",
+    "os.exec",
+    "os.fork",
+    "os.forkpty",
+    "os.posix_spawn",
+    "os.spawn",
+    "os.kill",
+    "ctypes.",
+    "pty.",
+    "webbrowser.",
+)
+
+
+# The hook only turns common mistakes into clear errors; the process isolation around the
+# interpreter is what keeps solutions away from the host.
+def block_host_access(event, _args):
+    if event.startswith(BLOCKED_EVENT_PREFIXES):
+        raise PermissionError(f"{event} is not allowed in the sandbox")
+
+
+def limit(kind, value):
+    if value > 0:
+        resource.setrlimit(kind, (value, value))
+
+
+def describe(error):
+    message = f"{type(error).__name__}: {error}"
+    frame = error.__traceback__
+    line = None
+    while frame is not None:
+        if frame.tb_frame.f_code.co_filename == "solution.py":
+            line = frame.tb_lineno
+        frame = frame.tb_next
+    if isinstance(error, SyntaxError) and error.filename == "solution.py":
+        line = error.lineno
+    if line is not None:
+        message += f" (solution.py:{line})"
+    return message
+
+
+def main():
+    request = json.load(sys.stdin)
+    report = os.fdopen(3, "w", encoding="utf-8")
+
+    def finish(result):
+        report.write(json.dumps(result))
+        report.flush()
+        sys.stdout.flush()
+        sys.stderr.flush()
+        os._exit(0)
+
+    cpu_seconds = math.ceil(request["cpu_ms"] / 1000)
+    resource.setrlimit(resource.RLIMIT_CPU, (cpu_seconds, cpu_seconds + 1))
+    limit(resource.RLIMIT_AS, request["memory_bytes"])
+    limit(resource.RLIMIT_NOFILE, request["max_open_files"])
+    limit(resource.RLIMIT_FSIZE, request["max_file_bytes"])
+    limit(resource.RLIMIT_CORE, 0)
+    sys.setrecursionlimit(request["max_stack_depth"])
+    sys.addaudithook(block_host_access)
+
+    with open("solution.py", encoding="utf-8") as source:
+        code = source.read()
+    try:
+        program = compile(code, "solution.py", "exec")
+    except (SyntaxError, ValueError) as error:
+        finish({"status": "compile_error", "error": describe(error)})
+
+    namespace = {"__name__": "solution", "__builtins__": __builtins__}
+    try:
+        exec(program, namespace)
+    except BaseException as error:
+        finish({"status": "error", "error": describe(error)})
+
+    name = request["function"]
+    function = namespace.get(name)
+    if not callable(function):
+        solution = namespace.get("Solution")
+        if isinstance(solution, type) and callable(getattr(solution, name, None)):
+            function = getattr(solution(), name)
+        else:
+            finish({"status": "error", "error": f"function {name} is not defined"})
+
+    start = time.perf_counter()
+    try:
+        value = function(*request["args"])
+    except BaseException as error:
+        duration = time.perf_counter() - start
+        finish({"status": "error", "error": describe(error), "duration_ms": duration * 1000})
+    duration = time.perf_counter() - start
+
+    try:
+        encoded = json.loads(json.dumps(value, allow_nan=False))
+    except (TypeError, ValueError) as error:
+        finish({"status": "error", "error": f"return value is not JSON-serializable: {error}", "duration_ms": duration * 1000})
+    finish({"status": "ok", "value": encoded, "duration_ms": duration * 1000})
+
+
+main()
@@ -0,0 +1,244 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runPython(t *testing.T, code, fn string, inputs [][]any, limits Limits) []Result {
+	t.Helper()
+	if _, err := exec.LookPath(defaultPython); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	results, err := NewPython("").Execute(context.Background(), Request{
+		Code:         code,
+		FunctionName: fn,
+		Inputs:       inputs,
+		Limits:       limits,
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("expected %d results, got %d", len(inputs), len(results))
+	}
+	return results
+}
+
+func TestPythonReturnsJSONValues(t *testing.T) {
+	code := `
+def pairs(nums, target):
+    out = []
+    for i in range(len(nums)):
+        for j in range(i + 1, len(nums)):
+            if nums[i] + nums[j] == target:
+                out.append({"i": i, "j": j, "sum": nums[i] + nums[j]})
+    return out
+`
+
+	results := runPython(t, code, "pairs", [][]any{{[]any{1, 2, 3}, 4}}, Limits{})
+	if results[0].Status != StatusOK {
+		t.Fatalf("expected ok, got %s (%s)", results[0].Status, results[0].Error)
+	}
+
+	want := []any{map[string]any{"i": float64(0), "j": float64(2), "sum": float64(4)}}
+	if !reflect.DeepEqual(results[0].Value, want) {
+		t.Fatalf("unexpected value %#v", results[0].Value)
+	}
+}
+
+func TestPythonSupportsSolutionClass(t *testing.T) {
+	code := `
+class Solution:
+    def twoSum(self, nums, target):
+        seen = {}
+        for i, n in enumerate(nums):
+            if target - n in seen:
+                return [seen[target - n], i]
+            seen[n] = i
+`
+
+	results := runPython(t, code, "twoSum", [][]any{{[]any{2, 7, 11}, 9}}, Limits{})
+	if !reflect.DeepEqual(results[0].Value, []any{float64(0), float64(1)}) {
+		t.Fatalf("unexpected result %s %#v (%s)", results[0].Status, results[0].Value, results[0].Error)
+	}
+}
+
+func TestPythonCapturesOutput(t *testing.T) {
+	code := `
+import sys
+
+def echo(x):
+    print("value", x)
+    print("oops", file=sys.stderr)
+    return x
+`
+
+	results := runPython(t, code, "echo", [][]any{{"hi"}}, Limits{})
+	if got := results[0].Stdout; got != "value hi\n" {
+		t.Fatalf("unexpected stdout %q", got)
+	}
+	if got := results[0].Stderr; got != "oops\n" {
+		t.Fatalf("unexpected stderr %q", got)
+	}
+}
+
+func TestPythonEnforcesCPUTimeLimit(t *testing.T) {
+	code := `
+def spin():
+    while True:
+        pass
+`
+
+	start := time.Now()
+	results := runPython(t, code, "spin", [][]any{{}}, Limits{CPUTime: 100 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout took too long: %s", elapsed)
+	}
+	if results[0].Status != StatusTimeout {
+		t.Fatalf("expected timeout, got %s (%s)", results[0].Status, results[0].Error)
+	}
+}
+
+func TestPythonReportsRuntimeErrors(t *testing.T) {
+	code := `def boom(n):
+    if n > 1:
+        raise ValueError("too big")
+    return n
+`
+
+	results := runPython(t, code, "boom", [][]any{{1}, {2}}, Limits{})
+	if results[0].Status != StatusOK {
+		t.Fatalf("expected first call ok, got %s", results[0].Status)
+	}
+	if results[1].Status != StatusError {
+		t.Fatalf("expected error, got %s", results[1].Status)
+	}
+	if !strings.Contains(results[1].Stderr, "ValueError: too big") || !strings.Contains(results[1].Stderr, "solution.py:3") {
+		t.Fatalf("expected error with source location, got %q", results[1].Stderr)
+	}
+}
+
+func TestPythonReportsCompileErrors(t *testing.T) {
+	results := runPython(t, "def broken(:\n    pass\n", "broken", [][]any{{}, {}}, Limits{})
+	for _, result := range results {
+		if result.Status != StatusCompileError {
+			t.Fatalf("expected compile error, got %s", result.Status)
+		}
+	}
+}
+
+func TestPythonReportsMissingFunction(t *testing.T) {
+	results := runPython(t, "def other():\n    return 1\n", "solve", [][]any{{}}, Limits{})
+	if results[0].Status != StatusError || !strings.Contains(results[0].Error, "solve is not defined") {
+		t.Fatalf("expected missing function error, got %s %q", results[0].Status, results[0].Error)
+	}
+}
+
+func TestPythonBlocksHostAccess(t *testing.T) {
+	code := `
+import os
+
+def probe(kind):
+    if kind == "socket":
+        import socket
+        socket.create_connection(("127.0.0.1", 9))
+    elif kind == "subprocess":
+        import subprocess
+        subprocess.run(["true"])
+    elif kind == "env":
+        return sorted(k for k in os.environ if k.startswith("AWS"))
+    elif kind == "files":
+        return [open(str(i), "w") for i in range(1000)] and "opened"
+`
+
+	results := runPython(t, code, "probe", [][]any{{"socket"}, {"subprocess"}, {"env"}, {"files"}}, Limits{})
+	for i, kind := range []string{"socket", "subprocess"} {
+		if results[i].Status != StatusError || !strings.Contains(results[i].Error, "not allowed in the sandbox") {
+			t.Fatalf("%s: expected sandbox rejection, got %s %q", kind, results[i].Status, results[i].Error)
+		}
+	}
+	if results[2].Status != StatusOK || !reflect.DeepEqual(results[2].Value, []any{}) {
+		t.Fatalf("expected an empty environment, got %s %#v", results[2].Status, results[2].Value)
+	}
+	if results[3].Status != StatusError || !strings.Contains(results[3].Error, "Too many open files") {
+		t.Fatalf("expected open file limit, got %s %q", results[3].Status, results[3].Error)
+	}
+}
+
+func TestPythonCannotReadParentEnvironment(t *testing.T) {
+	const secret = "sk-secret-from-parent"
+	t.Setenv("IMPROVIEW_SANDBOX_PROBE", secret)
+	code := `
+import os
+
+def probe(pid):
+    if pid == 0:
+        pid = os.getppid()
+    with open("/proc/%s/environ" % pid, "rb") as f:
+        return f.read().decode("latin-1")
+`
+
+	results := runPython(t, code, "probe", [][]any{{os.Getpid()}, {0}, {"self"}}, Limits{})
+	for i, result := range results {
+		if result.Status == StatusOK {
+			t.Fatalf("probe %d: expected the read to fail, got %q", i, result.Value)
+		}
+		if leaked := fmt.Sprint(result.Value) + result.Stdout + result.Stderr + result.Error; strings.Contains(leaked, secret) {
+			t.Fatalf("probe %d: parent environment leaked: %q", i, leaked)
+		}
+	}
+}
+
+func TestIsolatedRunCannotFork(t *testing.T) {
+	if _, err := exec.LookPath(defaultPython); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	interpreter, readOnly, err := NewPython("").interpreterPath(context.Background())
+	if err != nil {
+		t.Fatalf("resolve interpreter: %v", err)
+	}
+
+	// Without the harness's audit hook, only the isolation stands between the code and fork.
+	code := `
+import os, threading
+
+thread = threading.Thread(target=print, args=("thread ran",))
+thread.start()
+thread.join()
+try:
+    pid = os.fork()
+except PermissionError:
+    print("fork denied")
+else:
+    if pid == 0:
+        os._exit(0)
+    print("fork allowed")
+`
+	cmd, cleanup, err := isolatedCommand(context.Background(), harnessProcess{
+		runtime:  "python",
+		path:     interpreter,
+		args:     []string{"-I", "-S", "-c", code},
+		dir:      t.TempDir(),
+		readOnly: readOnly,
+	})
+	if err != nil {
+		t.Fatalf("isolate: %v", err)
+	}
+	defer cleanup()
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("run: %v: %s", err, out)
+	}
+	if got := string(out); got != "thread ran\nfork denied\n" {
+		t.Fatalf("expected threads to work and fork to be denied, got %q", got)
+	}
+}
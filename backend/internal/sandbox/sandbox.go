@@ -0,0 +1,147 @@
+// Package sandbox executes untrusted solution code inside isolated, resource-limited runtimes.
+package sandbox
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Status describes how a single sandboxed invocation finished.
+type Status string
+
+const (
+	// StatusOK indicates the function returned a value.
+	StatusOK Status = "ok"
+	// StatusError indicates the code threw or otherwise failed at runtime.
+	StatusError Status = "error"
+	// StatusTimeout indicates the invocation exceeded its CPU time budget.
+	StatusTimeout Status = "timeout"
+	// StatusCompileError indicates the code could not be parsed or compiled.
+	StatusCompileError Status = "compile_error"
+)
+
+const (
+	defaultCPUTime        = 2 * time.Second
+	defaultMaxOutputBytes = 16 * 1024
+	defaultMaxStackDepth  = 10000
+	defaultMaxMemoryBytes = 256 * 1024 * 1024
+	defaultMaxOpenFiles   = 64
+)
+
+// Languages with a built-in executor.
+const (
+	LanguageJavaScript = "javascript"
+	LanguageTypeScript = "typescript"
+	LanguagePython     = "python"
+	LanguageGo         = "go"
+)
+
+// NormalizeLanguage maps a language name or common alias to its canonical form. An empty
+// name means JavaScript, the language attempts defaulted to before others were supported.
+// Unknown names come back lowercased and unchanged.
+func NormalizeLanguage(name string) string {
+	switch language := strings.ToLower(strings.TrimSpace(name)); language {
+	case "", "js", "node", "nodejs":
+		return LanguageJavaScript
+	case "ts":
+		return LanguageTypeScript
+	case "py", "python3":
+		return LanguagePython
+	case "golang":
+		return LanguageGo
+	default:
+		return language
+	}
+}
+
+// Limits bounds the resources a single invocation may consume. MaxMemoryBytes and
+// MaxOpenFiles only apply to executors that run solutions in a separate process.
+type Limits struct {
+	CPUTime        time.Duration
+	MaxOutputBytes int
+	MaxStackDepth  int
+	MaxMemoryBytes int64
+	MaxOpenFiles   int
+}
+
+// DefaultLimits returns the limits applied when a request leaves them unset.
+func DefaultLimits() Limits {
+	return Limits{
+		CPUTime:        defaultCPUTime,
+		MaxOutputBytes: defaultMaxOutputBytes,
+		MaxStackDepth:  defaultMaxStackDepth,
+		MaxMemoryBytes: defaultMaxMemoryBytes,
+		MaxOpenFiles:   defaultMaxOpenFiles,
+	}
+}
+
+func (l Limits) withDefaults() Limits {
+	defaults := DefaultLimits()
+	if l.CPUTime <= 0 {
+		l.CPUTime = defaults.CPUTime
+	}
+	if l.MaxOutputBytes <= 0 {
+		l.MaxOutputBytes = defaults.MaxOutputBytes
+	}
+	if l.MaxStackDepth <= 0 {
+		l.MaxStackDepth = defaults.MaxStackDepth
+	}
+	if l.MaxMemoryBytes <= 0 {
+		l.MaxMemoryBytes = defaults.MaxMemoryBytes
+	}
+	if l.MaxOpenFiles <= 0 {
+		l.MaxOpenFiles = defaults.MaxOpenFiles
+	}
+	return l
+}
+
+// Request describes a batch of calls against a single solution. Params and Returns carry
+// the declared signature in the problem's TypeScript-style notation; only executors for
+// statically typed languages need them.
+type Request struct {
+	Code         string
+	FunctionName string
+	Params       []Param
+	Returns      string
+	Inputs       [][]any
+	Limits       Limits
+}
+
+// Param is one declared parameter of the solution function.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Result captures the outcome of calling the solution with one input.
+type Result struct {
+	Status   Status
+	Value    any
+	Stdout   string
+	Stderr   string
+	Error    string
+	Duration time.Duration
+}
+
+// Executor runs solution code for one language. Implementations return one Result per
+// input; the error is reserved for failures of the sandbox itself.
+type Executor interface {
+	Execute(ctx context.Context, req Request) ([]Result, error)
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// ValidFunctionName reports whether name is safe to use as an entry point.
+func ValidFunctionName(name string) bool {
+	return identifierPattern.MatchString(name)
+}
+
+func compileFailure(count int, message string) []Result {
+	results := make([]Result, count)
+	for i := range results {
+		results[i] = Result{Status: StatusCompileError, Error: message, Stderr: message}
+	}
+	return results
+}
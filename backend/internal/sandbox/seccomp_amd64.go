@@ -0,0 +1,10 @@
+//go:build linux && amd64
+
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const seccompArch = unix.AUDIT_ARCH_X86_64
+
+// seccompArchDeniedSyscalls start processes without clone; arm64 only has clone.
+var seccompArchDeniedSyscalls = []uint32{unix.SYS_FORK, unix.SYS_VFORK}
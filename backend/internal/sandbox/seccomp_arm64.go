@@ -0,0 +1,10 @@
+//go:build linux && arm64
+
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const seccompArch = unix.AUDIT_ARCH_AARCH64
+
+// seccompArchDeniedSyscalls is empty: arm64 has no fork or vfork, only clone.
+var seccompArchDeniedSyscalls []uint32
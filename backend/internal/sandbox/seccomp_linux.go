@@ -0,0 +1,122 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// seccompDeniedSyscalls fail with EPERM inside a run: they inspect other processes, change
+// mounts or namespaces, load kernel code, open sockets or bypass the filter (io_uring).
+var seccompDeniedSyscalls = []uint32{
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_KCMP,
+	unix.SYS_PIDFD_GETFD,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_CHROOT,
+	unix.SYS_UNSHARE,
+	unix.SYS_SETNS,
+	unix.SYS_OPEN_TREE,
+	unix.SYS_MOVE_MOUNT,
+	unix.SYS_FSOPEN,
+	unix.SYS_FSCONFIG,
+	unix.SYS_FSMOUNT,
+	unix.SYS_FSPICK,
+	unix.SYS_MOUNT_SETATTR,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_NAME_TO_HANDLE_AT,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_REBOOT,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_ACCT,
+	unix.SYS_QUOTACTL,
+	unix.SYS_SETHOSTNAME,
+	unix.SYS_SETDOMAINNAME,
+	unix.SYS_SOCKET,
+	unix.SYS_IO_URING_SETUP,
+	unix.SYS_IO_URING_ENTER,
+	unix.SYS_IO_URING_REGISTER,
+}
+
+// seccompNamespaceFlags are the clone flags that would create new namespaces.
+const seccompNamespaceFlags = unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWNET |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUTS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWTIME
+
+// Offsets into struct seccomp_data. Arguments are read by their low 32 bits, which is where
+// they start on the little-endian architectures supported here.
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16
+	// seccompX32Bit marks x32 syscall numbers, which would otherwise dodge the list.
+	seccompX32Bit = 0x40000000
+)
+
+// installSeccomp applies the filter to the calling thread, which then execs the run. Calls
+// from any other architecture kill the process, clone3 reports ENOSYS so callers fall back
+// to clone, and clone may only start threads: new processes and namespaces are denied, so
+// a run cannot fork no matter how it reaches the syscall.
+func installSeccomp() error {
+	if seccompArch == 0 {
+		return errors.New("seccomp: unsupported architecture")
+	}
+
+	deny := unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	program := []unix.SockFilter{
+		load(seccompDataArch),
+		jumpIfEqual(seccompArch, 1, 0),
+		ret(unix.SECCOMP_RET_KILL_PROCESS),
+		load(seccompDataNr),
+		{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, K: seccompX32Bit, Jt: 0, Jf: 1},
+		ret(deny),
+		jumpIfEqual(unix.SYS_CLONE3, 0, 1),
+		ret(unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)),
+	}
+	for _, nr := range append(seccompDeniedSyscalls, seccompArchDeniedSyscalls...) {
+		program = append(program, jumpIfEqual(nr, 0, 1), ret(deny))
+	}
+	program = append(program,
+		jumpIfEqual(unix.SYS_CLONE, 0, 4),
+		load(seccompDataArg0),
+		unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, K: seccompNamespaceFlags, Jt: 1, Jf: 0},
+		unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, K: unix.CLONE_THREAD, Jt: 1, Jf: 0},
+		ret(deny),
+		ret(unix.SECCOMP_RET_ALLOW),
+	)
+
+	filter := unix.SockFprog{Len: uint16(len(program)), Filter: &program[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&filter)), 0, 0); err != nil {
+		return fmt.Errorf("seccomp: install filter: %w", err)
+	}
+	return nil
+}
+
+func load(offset uint32) unix.SockFilter {
+	return unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offset}
+}
+
+func jumpIfEqual(value uint32, jumpTrue, jumpFalse uint8) unix.SockFilter {
+	return unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: value, Jt: jumpTrue, Jf: jumpFalse}
+}
+
+func ret(action uint32) unix.SockFilter {
+	return unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: action}
+}
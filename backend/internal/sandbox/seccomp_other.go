@@ -0,0 +1,8 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// seccompArch is zero where no filter has been written, so isolation fails closed.
+const seccompArch = 0
+
+var seccompArchDeniedSyscalls []uint32
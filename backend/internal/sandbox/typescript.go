@@ -0,0 +1,34 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+)
+
+const tsSourceName = "solution.ts"
+
+// TypeScript executes TypeScript solutions by erasing their type syntax in-process and
+// running the result in the JavaScript sandbox. Erasure only overwrites types with spaces,
+// so runtime errors and stack locations point at the submitted lines and columns.
+type TypeScript struct {
+	js *JavaScript
+}
+
+// NewTypeScript constructs a TypeScript executor.
+func NewTypeScript() *TypeScript {
+	return &TypeScript{js: &JavaScript{sourceName: tsSourceName}}
+}
+
+// Execute strips types from the solution and runs it like JavaScript. Type syntax the
+// stripper cannot handle is reported as a compile error for every input.
+func (t *TypeScript) Execute(ctx context.Context, req Request) ([]Result, error) {
+	if !ValidFunctionName(req.FunctionName) {
+		return nil, fmt.Errorf("sandbox: invalid function name %q", req.FunctionName)
+	}
+	code, err := stripTypes(req.Code)
+	if err != nil {
+		return compileFailure(len(req.Inputs), err.Error()), nil
+	}
+	req.Code = code
+	return t.js.Execute(ctx, req)
+}
@@ -0,0 +1,347 @@
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tsTokenKind int
+
+const (
+	tsEOF tsTokenKind = iota
+	tsIdent
+	tsPrivateName
+	tsNumber
+	tsString
+	tsRegExp
+	tsPunct
+	// Template literals are split at substitutions: "`a${" is a head, "}b${" a middle and
+	// "}c`" a tail. A template without substitutions is a single tsTemplate token.
+	tsTemplate
+	tsTemplateHead
+	tsTemplateMiddle
+	tsTemplateTail
+)
+
+type tsToken struct {
+	kind  tsTokenKind
+	text  string
+	start int
+	end   int
+	// newline reports whether a line terminator precedes the token, which decides ASI and
+	// several TypeScript constructs that may not span lines.
+	newline bool
+}
+
+func (t tsToken) is(kind tsTokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+func (t tsToken) punct(text string) bool {
+	return t.is(tsPunct, text)
+}
+
+func (t tsToken) ident(text string) bool {
+	return t.is(tsIdent, text)
+}
+
+// tsSyntaxError reports a problem at a byte offset in the original source; Error renders it
+// like the interpreter's own syntax errors so both read the same in stderr.
+type tsSyntaxError struct {
+	source  string
+	offset  int
+	message string
+}
+
+func (e *tsSyntaxError) Error() string {
+	line, column := 1, 1
+	for _, r := range e.source[:min(e.offset, len(e.source))] {
+		if r == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+	return fmt.Sprintf("SyntaxError: %s: Line %d:%d %s", tsSourceName, line, column, e.message)
+}
+
+// Multi-character punctuators, longest first. '>' is always its own token so nested type
+// arguments such as Array<Array<number>> close one level per token; the stripper never
+// rebuilds operators, so splitting >> or >= is harmless.
+var tsPunctuators = []string{
+	"...", "===", "!==", "**=", "<<=", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+	"**", "++", "--", "<<", "&&", "||", "??",
+}
+
+// regexpPrecedingWords are keywords after which a '/' starts a regular expression.
+var regexpPrecedingWords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "throw": true, "case": true, "do": true, "else": true,
+	"yield": true, "await": true,
+}
+
+type tsLexer struct {
+	src    string
+	pos    int
+	tokens []tsToken
+	// braces tracks '{' versus '${' so a '}' can resume the enclosing template literal.
+	braces []bool
+}
+
+func lexTypeScript(src string) ([]tsToken, error) {
+	lx := &tsLexer{src: src}
+	for {
+		newline, err := lx.skipTrivia()
+		if err != nil {
+			return nil, err
+		}
+		if lx.pos >= len(lx.src) {
+			lx.tokens = append(lx.tokens, tsToken{kind: tsEOF, start: lx.pos, end: lx.pos, newline: true})
+			return lx.tokens, nil
+		}
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		tok.newline = newline
+		lx.tokens = append(lx.tokens, tok)
+	}
+}
+
+func (lx *tsLexer) fail(offset int, format string, args ...any) error {
+	return &tsSyntaxError{source: lx.src, offset: offset, message: fmt.Sprintf(format, args...)}
+}
+
+// skipTrivia consumes whitespace and comments, reporting whether a line terminator was seen.
+func (lx *tsLexer) skipTrivia() (bool, error) {
+	newline := false
+	for lx.pos < len(lx.src) {
+		r, size := utf8.DecodeRuneInString(lx.src[lx.pos:])
+		switch {
+		case isLineTerminator(r):
+			newline = true
+			lx.pos += size
+		case unicode.IsSpace(r) || r == '\ufeff':
+			lx.pos += size
+		case strings.HasPrefix(lx.src[lx.pos:], "//"):
+			end := strings.IndexAny(lx.src[lx.pos:], "\n\r\u2028\u2029")
+			if end < 0 {
+				lx.pos = len(lx.src)
+			} else {
+				lx.pos += end
+			}
+		case strings.HasPrefix(lx.src[lx.pos:], "/*"):
+			end := strings.Index(lx.src[lx.pos+2:], "*/")
+			if end < 0 {
+				return false, lx.fail(lx.pos, "Unterminated comment")
+			}
+			if strings.ContainsAny(lx.src[lx.pos:lx.pos+2+end], "\n\r\u2028\u2029") {
+				newline = true
+			}
+			lx.pos += end + 4
+		default:
+			return newline, nil
+		}
+	}
+	return newline, nil
+}
+
+func (lx *tsLexer) next() (tsToken, error) {
+	start := lx.pos
+	r, size := utf8.DecodeRuneInString(lx.src[start:])
+	switch {
+	case isIdentifierStart(r) || r == '\\':
+		lx.pos = lx.scanIdentifier(start)
+		return lx.token(tsIdent, start), nil
+	case r == '#':
+		lx.pos = lx.scanIdentifier(start + 1)
+		return lx.token(tsPrivateName, start), nil
+	case r >= '0' && r <= '9' || r == '.' && start+1 < len(lx.src) && isDigit(lx.src[start+1]):
+		lx.pos = lx.scanNumber(start)
+		return lx.token(tsNumber, start), nil
+	case r == '"' || r == '\'':
+		if err := lx.scanString(start, byte(r)); err != nil {
+			return tsToken{}, err
+		}
+		return lx.token(tsString, start), nil
+	case r == '`':
+		lx.pos++
+		return lx.scanTemplate(start, tsTemplate, tsTemplateHead)
+	case r == '}' && len(lx.braces) > 0 && lx.braces[len(lx.braces)-1]:
+		lx.braces = lx.braces[:len(lx.braces)-1]
+		lx.pos++
+		return lx.scanTemplate(start, tsTemplateTail, tsTemplateMiddle)
+	case r == '/' && lx.regexpAllowed():
+		if err := lx.scanRegExp(start); err != nil {
+			return tsToken{}, err
+		}
+		return lx.token(tsRegExp, start), nil
+	}
+
+	for _, punct := range tsPunctuators {
+		if strings.HasPrefix(lx.src[start:], punct) {
+			lx.pos += len(punct)
+			return lx.token(tsPunct, start), nil
+		}
+	}
+	if strings.HasPrefix(lx.src[start:], "?.") && !(start+2 < len(lx.src) && isDigit(lx.src[start+2])) {
+		lx.pos += 2
+		return lx.token(tsPunct, start), nil
+	}
+	if strings.ContainsRune("{}()[];,<>+-*/%&|^!~?:=.@", r) {
+		switch r {
+		case '{':
+			lx.braces = append(lx.braces, false)
+		case '}':
+			if len(lx.braces) > 0 {
+				lx.braces = lx.braces[:len(lx.braces)-1]
+			}
+		}
+		lx.pos += size
+		return lx.token(tsPunct, start), nil
+	}
+	return tsToken{}, lx.fail(start, "Unexpected character %q", r)
+}
+
+func (lx *tsLexer) token(kind tsTokenKind, start int) tsToken {
+	return tsToken{kind: kind, text: lx.src[start:lx.pos], start: start, end: lx.pos}
+}
+
+func (lx *tsLexer) scanIdentifier(pos int) int {
+	for pos < len(lx.src) {
+		r, size := utf8.DecodeRuneInString(lx.src[pos:])
+		switch {
+		case r == '\\' && strings.HasPrefix(lx.src[pos:], `\u`):
+			pos += 2
+			if pos < len(lx.src) && lx.src[pos] == '{' {
+				if end := strings.IndexByte(lx.src[pos:], '}'); end >= 0 {
+					pos += end + 1
+				}
+				continue
+			}
+			pos += min(4, len(lx.src)-pos)
+		case isIdentifierPart(r):
+			pos += size
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+func (lx *tsLexer) scanNumber(pos int) int {
+	hex := pos+1 < len(lx.src) && lx.src[pos] == '0' && (lx.src[pos+1] == 'x' || lx.src[pos+1] == 'X')
+	for pos < len(lx.src) {
+		c := lx.src[pos]
+		switch {
+		case isDigit(c) || c == '.' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			pos++
+		case (c == '+' || c == '-') && !hex && (lx.src[pos-1] == 'e' || lx.src[pos-1] == 'E'):
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+func (lx *tsLexer) scanString(start int, quote byte) error {
+	pos := start + 1
+	for pos < len(lx.src) {
+		switch lx.src[pos] {
+		case '\\':
+			pos += 2
+		case quote:
+			lx.pos = pos + 1
+			return nil
+		case '\n', '\r':
+			return lx.fail(start, "Unterminated string literal")
+		default:
+			pos++
+		}
+	}
+	return lx.fail(start, "Unterminated string literal")
+}
+
+// scanTemplate continues a template literal after its opening '`' or a closing '}', returning
+// done when the literal ends and more when it opens another substitution.
+func (lx *tsLexer) scanTemplate(start int, done, more tsTokenKind) (tsToken, error) {
+	for lx.pos < len(lx.src) {
+		switch {
+		case lx.src[lx.pos] == '\\':
+			lx.pos += 2
+		case lx.src[lx.pos] == '`':
+			lx.pos++
+			return lx.token(done, start), nil
+		case strings.HasPrefix(lx.src[lx.pos:], "${"):
+			lx.pos += 2
+			lx.braces = append(lx.braces, true)
+			return lx.token(more, start), nil
+		default:
+			lx.pos++
+		}
+	}
+	return tsToken{}, lx.fail(start, "Unterminated template literal")
+}
+
+func (lx *tsLexer) scanRegExp(start int) error {
+	pos := start + 1
+	inClass := false
+	for pos < len(lx.src) {
+		switch c := lx.src[pos]; {
+		case c == '\\':
+			pos += 2
+			continue
+		case c == '\n' || c == '\r':
+			return lx.fail(start, "Invalid regular expression: missing /")
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			lx.pos = lx.scanIdentifier(pos + 1)
+			return nil
+		}
+		pos++
+	}
+	return lx.fail(start, "Invalid regular expression: missing /")
+}
+
+// regexpAllowed decides whether '/' starts a regular expression from the previous token,
+// the usual approximation of the grammar's goal symbols.
+func (lx *tsLexer) regexpAllowed() bool {
+	if len(lx.tokens) == 0 {
+		return true
+	}
+	prev := lx.tokens[len(lx.tokens)-1]
+	switch prev.kind {
+	case tsIdent:
+		return regexpPrecedingWords[prev.text]
+	case tsPunct:
+		return prev.text != ")" && prev.text != "]" && prev.text != "}"
+	case tsTemplateHead, tsTemplateMiddle:
+		return true
+	default:
+		return false
+	}
+}
+
+func isLineTerminator(r rune) bool {
+	return r == '\n' || r == '\r' || r == '\u2028' || r == '\u2029'
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || r == '\u200c' || r == '\u200d'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
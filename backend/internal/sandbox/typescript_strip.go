@@ -0,0 +1,940 @@
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tsFrameKind classifies an open bracket, which decides what a ':' or '?' inside it means.
+type tsFrameKind int
+
+const (
+	tsFrameBlock tsFrameKind = iota
+	tsFrameObject
+	tsFrameClass
+	tsFrameParams
+	tsFrameControl
+	tsFrameParen
+	tsFrameBracket
+	tsFrameTemplate
+	tsFrameModule
+)
+
+type tsFrame struct {
+	kind tsFrameKind
+	open int
+
+	// Parameter lists: declStart is where the owning function or method declaration starts
+	// (-1 for expressions), so a signature without a body can be blanked as an overload.
+	declStart   int
+	constructor bool
+	defaulting  bool
+
+	// Class bodies: memberStart is the first token of the current member and modifiers the
+	// position where another modifier such as static or private may still appear.
+	memberStart  int
+	modifiers    int
+	initializing bool
+	pendingClass bool
+
+	// let/const/var declarations: binding is the token after which ':' starts a type.
+	declaring     bool
+	expectBinding bool
+	binding       int
+}
+
+// Keywords that cannot end an expression, so a following '!', 'as' or '<' is not TypeScript.
+var tsNonExpressionWords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "throw": true, "case": true, "do": true, "else": true,
+	"yield": true, "await": true, "extends": true, "let": true, "const": true, "var": true,
+	"if": true, "while": true, "for": true, "switch": true, "with": true, "catch": true,
+	"function": true, "class": true, "export": true, "import": true, "default": true,
+	"as": true, "satisfies": true, "keyof": true, "implements": true,
+}
+
+// Class member modifiers that only exist in TypeScript.
+var tsMemberModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "readonly": true, "override": true, "abstract": true,
+}
+
+// stripTypes erases TypeScript syntax from code by overwriting it with spaces, leaving
+// line breaks in place so every remaining token keeps its original line and column. Only
+// erasable syntax is supported: constructs that need code generation, such as enums,
+// namespaces, decorators and parameter properties, are reported as syntax errors.
+func stripTypes(code string) (string, error) {
+	tokens, err := lexTypeScript(code)
+	if err != nil {
+		return "", err
+	}
+	s := &tsStripper{
+		src:     code,
+		toks:    tokens,
+		match:   make([]int, len(tokens)),
+		kinds:   make([]tsFrameKind, len(tokens)),
+		blanked: make([]bool, len(tokens)),
+	}
+	if err := s.matchBrackets(); err != nil {
+		return "", err
+	}
+	if err := s.walk(); err != nil {
+		return "", err
+	}
+	return s.render(), nil
+}
+
+type tsStripper struct {
+	src     string
+	toks    []tsToken
+	match   []int
+	kinds   []tsFrameKind
+	blanked []bool
+	stack   []*tsFrame
+	ranges  [][2]int
+}
+
+func (s *tsStripper) tok(i int) tsToken {
+	if i < 0 || i >= len(s.toks) {
+		return s.toks[len(s.toks)-1]
+	}
+	return s.toks[i]
+}
+
+func (s *tsStripper) fail(i int, format string, args ...any) error {
+	return &tsSyntaxError{source: s.src, offset: s.tok(i).start, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *tsStripper) unexpected(i int) error {
+	if s.tok(i).kind == tsEOF {
+		return s.fail(i, "Unexpected end of input")
+	}
+	return s.fail(i, "Unexpected token %s", s.tok(i).text)
+}
+
+func (s *tsStripper) expect(i int, punct string) error {
+	if !s.tok(i).punct(punct) {
+		return s.unexpected(i)
+	}
+	return nil
+}
+
+// prev returns the index of the closest earlier token that has not been blanked, or -1.
+func (s *tsStripper) prev(i int) int {
+	for i--; i >= 0 && s.blanked[i]; i-- {
+	}
+	return i
+}
+
+func (s *tsStripper) blank(from, to int) {
+	if from >= to {
+		return
+	}
+	for i := from; i < to; i++ {
+		s.blanked[i] = true
+	}
+	s.ranges = append(s.ranges, [2]int{s.toks[from].start, s.toks[to-1].end})
+}
+
+func (s *tsStripper) render() string {
+	if len(s.ranges) == 0 {
+		return s.src
+	}
+	mask := make([]bool, len(s.src))
+	for _, r := range s.ranges {
+		for i := r[0]; i < r[1]; i++ {
+			mask[i] = true
+		}
+	}
+	var out strings.Builder
+	out.Grow(len(s.src))
+	for i := 0; i < len(s.src); {
+		r, size := utf8.DecodeRuneInString(s.src[i:])
+		if mask[i] && !isLineTerminator(r) {
+			out.WriteByte(' ')
+		} else {
+			out.WriteString(s.src[i : i+size])
+		}
+		i += size
+	}
+	return out.String()
+}
+
+func (s *tsStripper) matchBrackets() error {
+	var open []int
+	for i, t := range s.toks {
+		s.match[i] = -1
+		switch {
+		case t.kind == tsTemplateHead, t.punct("("), t.punct("["), t.punct("{"):
+			open = append(open, i)
+		case t.kind == tsTemplateMiddle:
+			if len(open) == 0 || s.toks[open[len(open)-1]].kind != tsTemplateHead {
+				return s.unexpected(i)
+			}
+		case t.kind == tsTemplateTail, t.punct(")"), t.punct("]"), t.punct("}"):
+			if len(open) == 0 || !bracketsPair(s.toks[open[len(open)-1]], t) {
+				return s.unexpected(i)
+			}
+			s.match[open[len(open)-1]] = i
+			s.match[i] = open[len(open)-1]
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return s.unexpected(len(s.toks) - 1)
+	}
+	return nil
+}
+
+func bracketsPair(open, close tsToken) bool {
+	switch open.text {
+	case "(":
+		return close.text == ")"
+	case "[":
+		return close.text == "]"
+	case "{":
+		return close.kind == tsPunct && close.text == "}"
+	}
+	return open.kind == tsTemplateHead && close.kind == tsTemplateTail
+}
+
+func (s *tsStripper) top() *tsFrame {
+	return s.stack[len(s.stack)-1]
+}
+
+func (s *tsStripper) push(kind tsFrameKind, open int) *tsFrame {
+	frame := &tsFrame{kind: kind, open: open, declStart: -1, memberStart: open + 1, binding: -1}
+	s.kinds[open] = kind
+	s.stack = append(s.stack, frame)
+	return frame
+}
+
+func (s *tsStripper) walk() error {
+	s.stack = []*tsFrame{{kind: tsFrameBlock, open: -1, declStart: -1, binding: -1}}
+	for i := 0; s.tok(i).kind != tsEOF; {
+		next, err := s.step(i)
+		if err != nil {
+			return err
+		}
+		i = next
+	}
+	return nil
+}
+
+// endsExpression reports whether the token at p can end an expression, which makes a
+// following '!' a non-null assertion and a following 'as' or '<' TypeScript syntax.
+func (s *tsStripper) endsExpression(p int) bool {
+	if p < 0 {
+		return false
+	}
+	t := s.toks[p]
+	switch t.kind {
+	case tsIdent:
+		return !tsNonExpressionWords[t.text]
+	case tsPrivateName, tsNumber, tsString, tsRegExp, tsTemplate, tsTemplateTail:
+		return true
+	case tsPunct:
+		switch t.text {
+		case ")":
+			return s.kinds[s.match[p]] != tsFrameControl
+		case "]":
+			return true
+		case "}":
+			return s.kinds[s.match[p]] == tsFrameObject
+		}
+	}
+	return false
+}
+
+// startsStatement reports whether the token at i begins a statement.
+func (s *tsStripper) startsStatement(i int) bool {
+	p := s.prev(i)
+	if p < 0 || s.toks[i].newline {
+		return true
+	}
+	t := s.toks[p]
+	return t.punct(";") || t.punct("{") || t.punct("}") || t.ident("export") || t.ident("default") || t.ident("declare")
+}
+
+// declarationStart extends a declaration back over export, default and declare prefixes.
+func (s *tsStripper) declarationStart(i int) int {
+	for {
+		p := s.prev(i)
+		if p < 0 || !(s.toks[p].ident("export") || s.toks[p].ident("default") || s.toks[p].ident("declare") || s.toks[p].ident("async")) {
+			return i
+		}
+		i = p
+	}
+}
+
+// statementEnd returns the index after a statement that starts at i: through its ';', or up
+// to the first token on a new line, skipping over bracketed groups.
+func (s *tsStripper) statementEnd(i int) int {
+	j := i + 1
+	for s.tok(j).kind != tsEOF {
+		t := s.toks[j]
+		switch {
+		case t.punct(";"):
+			return j + 1
+		case t.newline:
+			return j
+		case s.match[j] > j:
+			j = s.match[j] + 1
+		default:
+			j++
+		}
+	}
+	return j
+}
+
+func (s *tsStripper) step(i int) (int, error) {
+	t := s.toks[i]
+	frame := s.top()
+
+	if frame.expectBinding {
+		frame.expectBinding = false
+		switch {
+		case t.kind == tsIdent:
+			frame.binding = i
+		case t.punct("{") || t.punct("["):
+			frame.binding = s.match[i]
+		}
+	}
+	if frame.kind == tsFrameClass {
+		if next, handled, err := s.classMember(i, frame); handled || err != nil {
+			return next, err
+		}
+	}
+
+	switch t.kind {
+	case tsIdent:
+		return s.identifier(i, frame)
+	case tsTemplateHead:
+		s.push(tsFrameTemplate, i)
+		return i + 1, nil
+	case tsTemplateTail:
+		s.stack = s.stack[:len(s.stack)-1]
+		return i + 1, nil
+	case tsPunct:
+		return s.punctuator(i, frame)
+	}
+	return i + 1, nil
+}
+
+// classMember handles TypeScript-only syntax at the start of a class member: modifiers,
+// ambient fields and index signatures.
+func (s *tsStripper) classMember(i int, frame *tsFrame) (int, bool, error) {
+	t := s.toks[i]
+	if i != frame.memberStart && t.newline && (!frame.initializing || s.endsExpression(s.prev(i)) && (t.kind == tsIdent || t.kind == tsPrivateName)) {
+		frame.memberStart = i
+	}
+	if i == frame.memberStart {
+		frame.initializing = false
+		frame.modifiers = i
+	}
+	if i != frame.modifiers {
+		return i, false, nil
+	}
+
+	next := s.tok(i + 1)
+	nameFollows := !next.newline && (next.kind == tsIdent || next.kind == tsPrivateName || next.kind == tsString ||
+		next.kind == tsNumber || next.punct("[") || next.punct("*"))
+	switch {
+	case t.kind == tsIdent && tsMemberModifiers[t.text] && nameFollows:
+		s.blank(i, i+1)
+		frame.modifiers = i + 1
+		return i + 1, true, nil
+	case t.kind == tsIdent && (t.text == "static" || t.text == "async" || t.text == "get" || t.text == "set" || t.text == "accessor") && nameFollows:
+		frame.modifiers = i + 1
+	case t.ident("declare") && nameFollows, t.punct("[") && next.kind == tsIdent && s.tok(i+2).punct(":"):
+		end := s.statementEnd(i)
+		s.blank(frame.memberStart, end)
+		frame.memberStart = end
+		return end, true, nil
+	}
+	return i, false, nil
+}
+
+func (s *tsStripper) identifier(i int, frame *tsFrame) (int, error) {
+	t := s.toks[i]
+	next := s.tok(i + 1)
+	p := s.prev(i)
+	if p >= 0 && (s.toks[p].punct(".") || s.toks[p].punct("?.")) {
+		return i + 1, nil
+	}
+
+	if frame.kind == tsFrameBlock && s.startsStatement(i) {
+		if end, ok, err := s.declaration(i); ok || err != nil {
+			return end, err
+		}
+	}
+
+	switch t.text {
+	case "let", "const", "var":
+		if t.text == "const" && next.ident("enum") {
+			return 0, s.fail(i, "Enums are not supported; use a plain object instead")
+		}
+		if next.kind == tsIdent || next.punct("{") || next.punct("[") {
+			frame.declaring = true
+			frame.expectBinding = true
+		}
+	case "class":
+		if next.kind == tsIdent || next.punct("{") {
+			frame.pendingClass = true
+		}
+	case "abstract":
+		if next.ident("class") && !next.newline {
+			s.blank(i, i+1)
+		}
+	case "implements":
+		if frame.pendingClass {
+			end, err := s.skipTypeSequence(i + 1)
+			if err != nil {
+				return 0, err
+			}
+			s.blank(i, end)
+			return end, nil
+		}
+	case "as", "satisfies":
+		if !t.newline && s.endsExpression(p) && frame.kind != tsFrameModule {
+			end, err := s.skipType(i+1, true)
+			if err != nil {
+				return 0, err
+			}
+			s.blank(i, end)
+			return end, nil
+		}
+	case "this":
+		if frame.kind == tsFrameParams && i == frame.open+1 && next.punct(":") {
+			end, err := s.skipType(i+2, true)
+			if err != nil {
+				return 0, err
+			}
+			if s.tok(end).punct(",") {
+				end++
+			}
+			s.blank(i, end)
+			return end, nil
+		}
+	}
+
+	if frame.kind == tsFrameParams && frame.constructor && tsMemberModifiers[t.text] &&
+		(next.kind == tsIdent || next.punct("{") || next.punct("[")) {
+		return 0, s.fail(i, "Parameter properties are not supported; assign the field in the constructor body")
+	}
+	return i + 1, nil
+}
+
+// declaration blanks statement-level TypeScript declarations: interfaces, type aliases,
+// ambient declarations and type-only imports and exports.
+func (s *tsStripper) declaration(i int) (int, bool, error) {
+	t := s.toks[i]
+	next := s.tok(i + 1)
+	if next.newline {
+		return 0, false, nil
+	}
+	switch {
+	case t.ident("interface") && next.kind == tsIdent:
+		j := i + 2
+		var err error
+		if s.tok(j).punct("<") {
+			if j, err = s.skipTypeList(j); err != nil {
+				return 0, true, err
+			}
+		}
+		if s.tok(j).ident("extends") {
+			if j, err = s.skipTypeSequence(j + 1); err != nil {
+				return 0, true, err
+			}
+		}
+		if err := s.expect(j, "{"); err != nil {
+			return 0, true, err
+		}
+		end := s.match[j] + 1
+		s.blank(s.declarationStart(i), end)
+		return end, true, nil
+
+	case t.ident("type") && next.kind == tsIdent && (s.tok(i+2).punct("=") || s.tok(i+2).punct("<")):
+		j := i + 2
+		var err error
+		if s.tok(j).punct("<") {
+			if j, err = s.skipTypeList(j); err != nil {
+				return 0, true, err
+			}
+		}
+		if err := s.expect(j, "="); err != nil {
+			return 0, true, err
+		}
+		if j, err = s.skipType(j+1, true); err != nil {
+			return 0, true, err
+		}
+		if s.tok(j).punct(";") {
+			j++
+		}
+		s.blank(s.declarationStart(i), j)
+		return j, true, nil
+
+	case t.ident("declare") && next.kind == tsIdent:
+		end := s.statementEnd(i)
+		s.blank(s.declarationStart(i), end)
+		return end, true, nil
+
+	case (t.ident("import") || t.ident("export")) && next.ident("type") &&
+		(s.tok(i+2).kind == tsIdent || s.tok(i+2).punct("{") || s.tok(i+2).punct("*")):
+		if t.ident("export") && s.tok(i+2).kind == tsIdent {
+			return 0, false, nil
+		}
+		end := s.statementEnd(i)
+		s.blank(i, end)
+		return end, true, nil
+
+	case t.ident("enum") && next.kind == tsIdent:
+		return 0, true, s.fail(i, "Enums are not supported; use a plain object instead")
+
+	case (t.ident("namespace") || t.ident("module")) && (next.kind == tsIdent || next.kind == tsString) &&
+		(s.tok(i+2).punct("{") || s.tok(i+2).punct(".")):
+		return 0, true, s.fail(i, "Namespaces are not supported")
+	}
+	return 0, false, nil
+}
+
+func (s *tsStripper) punctuator(i int, frame *tsFrame) (int, error) {
+	t := s.toks[i]
+	p := s.prev(i)
+	switch t.text {
+	case "(":
+		s.openParen(i, p, frame)
+		return i + 1, nil
+	case "[":
+		s.push(tsFrameBracket, i)
+		return i + 1, nil
+	case "{":
+		s.openBrace(i, p, frame)
+		return i + 1, nil
+	case ")", "]", "}":
+		s.stack = s.stack[:len(s.stack)-1]
+		if parent := s.top(); parent.kind == tsFrameClass && t.text == "}" {
+			parent.memberStart = i + 1
+		}
+		if t.text == ")" && s.kinds[s.match[i]] == tsFrameParams {
+			return s.afterParams(i, frame)
+		}
+		return i + 1, nil
+
+	case ":":
+		annotates := false
+		switch {
+		case frame.kind == tsFrameParams:
+			annotates = !frame.defaulting
+		case frame.kind == tsFrameClass:
+			annotates = !frame.initializing
+		case frame.declaring && frame.binding == p:
+			annotates = true
+		}
+		if !annotates {
+			return i + 1, nil
+		}
+		end, err := s.skipType(i+1, true)
+		if err != nil {
+			return 0, err
+		}
+		s.blank(i, end)
+		return end, nil
+
+	case "?":
+		if frame.kind == tsFrameParams && !frame.defaulting || frame.kind == tsFrameClass && !frame.initializing {
+			s.blank(i, i+1)
+		}
+
+	case "!":
+		if !t.newline && s.endsExpression(p) {
+			s.blank(i, i+1)
+		}
+
+	case "<":
+		return s.angleBracket(i, p, frame)
+
+	case "=":
+		switch frame.kind {
+		case tsFrameParams:
+			frame.defaulting = true
+		case tsFrameClass:
+			frame.initializing = true
+		}
+	case ",":
+		if frame.kind == tsFrameParams {
+			frame.defaulting = false
+		}
+		if frame.declaring {
+			frame.expectBinding = true
+		}
+	case ";":
+		frame.declaring = false
+		if frame.kind == tsFrameClass {
+			frame.memberStart = i + 1
+		}
+	case "@":
+		return 0, s.fail(i, "Decorators are not supported")
+	}
+	return i + 1, nil
+}
+
+func (s *tsStripper) openParen(i, p int, frame *tsFrame) {
+	prev := s.tok(p)
+	pp := s.prev(p)
+	switch {
+	case prev.ident("function") || prev.punct("*") && s.tok(pp).ident("function") ||
+		prev.kind == tsIdent && (s.tok(pp).ident("function") || s.tok(pp).punct("*") && s.tok(s.prev(pp)).ident("function")):
+		f := p
+		for !s.toks[f].ident("function") {
+			f = s.prev(f)
+		}
+		s.push(tsFrameParams, i).declStart = s.declarationStart(f)
+		return
+
+	case frame.kind == tsFrameClass && !frame.initializing && isPropertyKey(prev):
+		params := s.push(tsFrameParams, i)
+		params.declStart = frame.memberStart
+		params.constructor = prev.ident("constructor")
+		return
+
+	case frame.kind == tsFrameObject && isPropertyKey(prev) && (s.tok(pp).punct("{") || s.tok(pp).punct(",") || s.tok(pp).punct("*") ||
+		s.tok(pp).ident("async") || s.tok(pp).ident("get") || s.tok(pp).ident("set")):
+		s.push(tsFrameParams, i)
+		return
+
+	case prev.ident("catch"):
+		s.push(tsFrameParams, i)
+		return
+
+	case prev.ident("if") || prev.ident("while") || prev.ident("for") || prev.ident("switch") || prev.ident("with"):
+		s.push(tsFrameControl, i)
+		return
+	}
+
+	closer := s.match[i]
+	after := s.tok(closer + 1)
+	if after.punct("=>") {
+		s.push(tsFrameParams, i)
+		return
+	}
+	if after.punct(":") {
+		if end, err := s.skipType(closer+2, true); err == nil && s.tok(end).punct("=>") {
+			s.push(tsFrameParams, i)
+			return
+		}
+	}
+	s.push(tsFrameParen, i)
+}
+
+func isPropertyKey(t tsToken) bool {
+	return t.kind == tsIdent || t.kind == tsPrivateName || t.kind == tsString || t.kind == tsNumber || t.punct("]")
+}
+
+func (s *tsStripper) openBrace(i, p int, frame *tsFrame) {
+	if frame.pendingClass {
+		frame.pendingClass = false
+		s.push(tsFrameClass, i)
+		return
+	}
+	prev := s.tok(p)
+	if prev.ident("import") || prev.ident("export") {
+		s.push(tsFrameModule, i)
+		return
+	}
+	block := p < 0
+	switch prev.kind {
+	case tsPunct:
+		switch prev.text {
+		case ";", ")", "}", "{", "=>":
+			block = true
+		case ":":
+			// A colon that closed a ternary or an object key precedes a value; case
+			// clauses and labels precede a block.
+			block = frame.kind != tsFrameObject && frame.kind != tsFrameParams && !s.closedTernary(p)
+		}
+	case tsIdent:
+		block = !regexpPrecedingWords[prev.text] || prev.text == "else" || prev.text == "do"
+	}
+	if block {
+		s.push(tsFrameBlock, i)
+	} else {
+		s.push(tsFrameObject, i)
+	}
+}
+
+// closedTernary reports whether the ':' at p belongs to a conditional expression, pairing
+// '?' and ':' tokens at its bracket depth back to the start of the statement.
+func (s *tsStripper) closedTernary(p int) bool {
+	colons := 0
+	for j := p - 1; j >= 0; j-- {
+		t := s.toks[j]
+		switch {
+		case s.blanked[j]:
+		case t.punct(")") || t.punct("]") || t.punct("}") || t.kind == tsTemplateTail:
+			j = s.match[j]
+		case t.punct(";") || t.punct("{") || t.punct("(") || t.punct("[") || t.kind == tsTemplateHead ||
+			t.kind == tsTemplateMiddle || t.ident("case"):
+			return false
+		case t.punct(":"):
+			colons++
+		case t.punct("?"):
+			if colons == 0 {
+				return true
+			}
+			colons--
+		}
+	}
+	return false
+}
+
+// afterParams blanks a return type and drops body-less function and method signatures,
+// which are overloads or abstract members.
+func (s *tsStripper) afterParams(i int, frame *tsFrame) (int, error) {
+	next := i + 1
+	if s.tok(next).punct(":") {
+		end, err := s.skipType(next+1, true)
+		if err != nil {
+			return 0, err
+		}
+		s.blank(next, end)
+		next = end
+	}
+	if frame.declStart >= 0 && !s.tok(next).punct("{") && !s.tok(next).punct("=>") {
+		if s.tok(next).punct(";") {
+			next++
+		}
+		s.blank(frame.declStart, next)
+		if parent := s.top(); parent.kind == tsFrameClass {
+			parent.memberStart = next
+		}
+	}
+	return next, nil
+}
+
+// angleBracket blanks type parameters and arguments, and type assertions written <T>value.
+// After an expression a '<' is only a type argument list if it parses as one and is
+// followed by a call, which is the same heuristic TypeScript uses.
+func (s *tsStripper) angleBracket(i, p int, frame *tsFrame) (int, error) {
+	if s.endsExpression(p) {
+		end, err := s.skipTypeList(i)
+		if err != nil {
+			return i + 1, nil
+		}
+		after := s.tok(end)
+		if after.punct("(") || after.kind == tsTemplate || after.kind == tsTemplateHead ||
+			frame.pendingClass && (after.punct("{") || after.ident("extends") || after.ident("implements")) {
+			s.blank(i, end)
+			return end, nil
+		}
+		return i + 1, nil
+	}
+	end, err := s.skipTypeList(i)
+	if err != nil {
+		return 0, err
+	}
+	s.blank(i, end)
+	return end, nil
+}
+
+// skipTypeSequence skips a comma-separated list of types, as in extends and implements
+// clauses.
+func (s *tsStripper) skipTypeSequence(i int) (int, error) {
+	for {
+		end, err := s.skipType(i, false)
+		if err != nil {
+			return 0, err
+		}
+		if !s.tok(end).punct(",") {
+			return end, nil
+		}
+		i = end + 1
+	}
+}
+
+// skipTypeList skips a <...> list of type parameters or arguments starting at i.
+func (s *tsStripper) skipTypeList(i int) (int, error) {
+	if err := s.expect(i, "<"); err != nil {
+		return 0, err
+	}
+	i++
+	for {
+		if s.tok(i).punct(">") {
+			return i + 1, nil
+		}
+		if t := s.tok(i); (t.ident("const") || t.ident("in") || t.ident("out")) && s.tok(i+1).kind == tsIdent {
+			i++
+		}
+		end, err := s.skipType(i, false)
+		if err != nil {
+			return 0, err
+		}
+		if s.tok(end).ident("extends") {
+			if end, err = s.skipType(end+1, false); err != nil {
+				return 0, err
+			}
+		}
+		if s.tok(end).punct("=") {
+			if end, err = s.skipType(end+1, true); err != nil {
+				return 0, err
+			}
+		}
+		switch {
+		case s.tok(end).punct(">"):
+			return end + 1, nil
+		case s.tok(end).punct(","):
+			i = end + 1
+		default:
+			return 0, s.unexpected(end)
+		}
+	}
+}
+
+// skipType returns the index just past the type starting at i.
+func (s *tsStripper) skipType(i int, allowConditional bool) (int, error) {
+	if s.tok(i).punct("|") || s.tok(i).punct("&") {
+		i++
+	}
+	for {
+		end, err := s.skipTypeOperand(i)
+		if err != nil {
+			return 0, err
+		}
+		i = end
+		if !s.tok(i).punct("|") && !s.tok(i).punct("&") {
+			break
+		}
+		i++
+	}
+	if allowConditional && s.tok(i).ident("extends") && !s.tok(i).newline {
+		var err error
+		if i, err = s.skipType(i+1, false); err != nil {
+			return 0, err
+		}
+		if err := s.expect(i, "?"); err != nil {
+			return 0, err
+		}
+		if i, err = s.skipType(i+1, true); err != nil {
+			return 0, err
+		}
+		if err := s.expect(i, ":"); err != nil {
+			return 0, err
+		}
+		return s.skipType(i+1, true)
+	}
+	return i, nil
+}
+
+func (s *tsStripper) skipTypeOperand(i int) (int, error) {
+	t := s.tok(i)
+	next := s.tok(i + 1)
+	switch {
+	case t.kind == tsIdent && (t.text == "keyof" || t.text == "readonly" || t.text == "unique") && !next.newline &&
+		(next.kind == tsIdent || next.punct("(") || next.punct("[") || next.punct("{")):
+		return s.skipTypeOperand(i + 1)
+
+	case t.ident("infer") && next.kind == tsIdent:
+		i += 2
+
+	case t.ident("typeof") && next.kind == tsIdent:
+		i = s.skipEntityName(i + 1)
+		if s.tok(i).punct("<") {
+			var err error
+			if i, err = s.skipTypeList(i); err != nil {
+				return 0, err
+			}
+		}
+
+	case t.ident("asserts") && (next.kind == tsIdent && !next.ident("is")) && !next.newline:
+		if s.tok(i + 2).ident("is") {
+			return s.skipType(i+3, true)
+		}
+		return i + 2, nil
+
+	case (t.ident("new") || t.ident("abstract") && next.ident("new")) && (next.punct("(") || next.punct("<") || next.ident("new")):
+		if t.ident("abstract") {
+			i++
+		}
+		i++
+		if s.tok(i).punct("<") {
+			var err error
+			if i, err = s.skipTypeList(i); err != nil {
+				return 0, err
+			}
+		}
+		if err := s.expect(i, "("); err != nil {
+			return 0, err
+		}
+		i = s.match[i] + 1
+		if err := s.expect(i, "=>"); err != nil {
+			return 0, err
+		}
+		return s.skipType(i+1, true)
+
+	case t.punct("<"):
+		end, err := s.skipTypeList(i)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.expect(end, "("); err != nil {
+			return 0, err
+		}
+		end = s.match[end] + 1
+		if err := s.expect(end, "=>"); err != nil {
+			return 0, err
+		}
+		return s.skipType(end+1, true)
+
+	case t.punct("("):
+		closer := s.match[i]
+		if s.tok(closer + 1).punct("=>") {
+			return s.skipType(closer+2, true)
+		}
+		end, err := s.skipType(i+1, true)
+		if err != nil {
+			return 0, err
+		}
+		if end != closer {
+			return 0, s.unexpected(end)
+		}
+		i = closer + 1
+
+	case t.punct("{") || t.punct("[") || t.kind == tsTemplateHead:
+		i = s.match[i] + 1
+
+	case t.kind == tsString || t.kind == tsNumber || t.kind == tsTemplate:
+		i++
+
+	case t.punct("-") && next.kind == tsNumber:
+		i += 2
+
+	case t.kind == tsIdent:
+		if next.ident("is") && !next.newline {
+			return s.skipType(i+2, true)
+		}
+		i = s.skipEntityName(i)
+		if s.tok(i).punct("<") && !s.tok(i).newline {
+			var err error
+			if i, err = s.skipTypeList(i); err != nil {
+				return 0, err
+			}
+		}
+
+	default:
+		return 0, s.unexpected(i)
+	}
+
+	for s.tok(i).punct("[") && !s.tok(i).newline {
+		i = s.match[i] + 1
+	}
+	return i, nil
+}
+
+// skipEntityName skips a dotted name such as Foo or ns.Foo.
+func (s *tsStripper) skipEntityName(i int) int {
+	i++
+	for s.tok(i).punct(".") && s.tok(i+1).kind == tsIdent {
+		i += 2
+	}
+	return i
+}
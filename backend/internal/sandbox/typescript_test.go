@@ -0,0 +1,108 @@
+package sandbox
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripTypesBlanksTypeSyntax(t *testing.T) {
+	cases := map[string]string{
+		"function f(a: number, b?: string): number[] { return [a] }":      "function f(a        , b         )           { return [a] }",
+		"const seen: Map<number, number> = new Map<number, number>();":    "const seen                      = new Map                ();",
+		"const j = seen.get(k)!; const w = obj as unknown as number[];":   "const j = seen.get(k) ; const w = obj                       ;",
+		"const id = <T,>(x: T): T => x;":                                  "const id =     (x   )    => x;",
+		"let q = <number>val, r = cfg satisfies Config;":                  "let q =         val, r = cfg                 ;",
+		"type Pair = [number, number];\nlet p;":                           "                             \nlet p;",
+		"interface Node<T> {\n  val: T\n}\nlet n;":                        "                   \n        \n \nlet n;",
+		"function o(a: string): string;\nfunction o(a: any) { return a }": "                              \nfunction o(a     ) { return a }",
+		"let v = c ? (a) : b; switch (k) { case 1: { break } }":           "let v = c ? (a) : b; switch (k) { case 1: { break } }",
+		"if (a < b && c > (d)) {} const m = { k: c ? 1 : 2, f(x: T) {} }": "if (a < b && c > (d)) {} const m = { k: c ? 1 : 2, f(x   ) {} }",
+		"const t = `${(x as number).toFixed(2)}`, re = /a:b/g;":           "const t = `${(x          ).toFixed(2)}`, re = /a:b/g;",
+		"class A<T> extends B<T> implements C {\n  private readonly n?: number = 1\n  declare tag: string\n  abstract size(): number;\n  get name(): string { return '' }\n}": "class A    extends B                 {\n                   n          = 1\n                     \n                          \n  get name()         { return '' }\n}",
+	}
+	for code, want := range cases {
+		got, err := stripTypes(code)
+		if err != nil {
+			t.Fatalf("stripTypes(%q): %v", code, err)
+		}
+		if got != want {
+			t.Fatalf("stripTypes(%q)\n got %q\nwant %q", code, got, want)
+		}
+	}
+}
+
+func TestStripTypesRejectsInvalidOrUnsupportedSyntax(t *testing.T) {
+	cases := map[string]string{
+		"function f(a: ) {}":                                "Line 1:15 Unexpected token )",
+		"let x: Map<string = 1;":                            "Line 1:22 Unexpected token ;",
+		"const a = 1;\nenum Color { Red }":                  "Line 2:1 Enums are not supported",
+		"class P {\n  constructor(private x: number) {}\n}": "Line 2:15 Parameter properties are not supported",
+		"@sealed class S {}":                                "Line 1:1 Decorators are not supported",
+	}
+	for code, want := range cases {
+		_, err := stripTypes(code)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("stripTypes(%q): expected error containing %q, got %v", code, want, err)
+		}
+	}
+}
+
+func TestTypeScriptRunsTypedSolutions(t *testing.T) {
+	code := `
+interface Pair { i: number; j: number }
+
+export function pairs(nums: number[], target: number): Pair[] {
+  const seen = new Map<number, number>();
+  const out: Pair[] = [];
+  nums.forEach((n: number, j: number): void => {
+    const i = seen.get(target - n);
+    if (i !== undefined) out.push({ i, j } as Pair);
+    seen.set(n, j);
+  });
+  return out;
+}`
+
+	results, err := NewTypeScript().Execute(context.Background(), Request{
+		Code:         code,
+		FunctionName: "pairs",
+		Inputs:       [][]any{{[]any{1, 2, 3}, 4}},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if results[0].Status != StatusOK {
+		t.Fatalf("expected ok, got %s (%s)", results[0].Status, results[0].Error)
+	}
+	want := []any{map[string]any{"i": float64(0), "j": float64(2)}}
+	if !reflect.DeepEqual(results[0].Value, want) {
+		t.Fatalf("unexpected value %#v", results[0].Value)
+	}
+}
+
+func TestTypeScriptErrorsPointAtOriginalLines(t *testing.T) {
+	code := `type Input = { items: number[] };
+function first(input: Input): number {
+  const items: number[] = input.items!;
+  return items[0]!.toFixed(2) as unknown as number + (null as any).x;
+}`
+
+	results, err := NewTypeScript().Execute(context.Background(), Request{Code: code, FunctionName: "first", Inputs: [][]any{{map[string]any{"items": []any{1}}}}})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if results[0].Status != StatusError || !strings.Contains(results[0].Stderr, "solution.ts:4:") {
+		t.Fatalf("expected runtime error on line 4 of solution.ts, got %s %q", results[0].Status, results[0].Stderr)
+	}
+
+	results, err = NewTypeScript().Execute(context.Background(), Request{Code: "function first(a: number[: number { return 1 }", FunctionName: "first", Inputs: [][]any{{}, {}}})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	for _, result := range results {
+		if result.Status != StatusCompileError || !strings.Contains(result.Error, "solution.ts") {
+			t.Fatalf("expected compile error, got %s %q", result.Status, result.Error)
+		}
+	}
+}
@@ -0,0 +1,950 @@
+// Package storetest is a behavioural conformance suite for storage backends. Every
+// implementation of the api store interfaces runs the same suite from its own tests, so
+// backends agree on pagination, filtering, ordering, partial updates, not-found handling,
+// cascading deletes and concurrent writes.
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"improview/backend/internal/api"
+	"improview/backend/internal/auth"
+	"improview/backend/internal/domain"
+	"improview/backend/internal/jsonfmt"
+)
+
+// Stores is the set of stores a backend provides. Nil stores are skipped.
+type Stores struct {
+	Profiles      api.UserProfileStore
+	SavedProblems api.SavedProblemStore
+	Attempts      api.AttemptStore
+	Problems      api.ProblemRepository
+}
+
+// Factory returns empty stores. It is called once per subtest so subtests never share
+// state; register any teardown with t.Cleanup.
+type Factory func(t *testing.T) Stores
+
+// Run runs the conformance suite against the stores returned by newStores.
+func Run(t *testing.T, newStores Factory) {
+	t.Run("Profiles", func(t *testing.T) { runProfiles(t, newStores) })
+	t.Run("SavedProblems", func(t *testing.T) { runSavedProblems(t, newStores) })
+	t.Run("Attempts", func(t *testing.T) { runAttempts(t, newStores) })
+	t.Run("Problems", func(t *testing.T) { runProblems(t, newStores) })
+}
+
+// concurrentWriters is the number of goroutines used by the concurrent append tests.
+const concurrentWriters = 10
+
+var baseTime = time.UnixMilli(1_700_000_000_000)
+
+func profiles(t *testing.T, newStores Factory) api.UserProfileStore {
+	t.Helper()
+	store := newStores(t).Profiles
+	if store == nil {
+		t.Skip("backend has no profile store")
+	}
+	return store
+}
+
+func savedProblems(t *testing.T, newStores Factory) api.SavedProblemStore {
+	t.Helper()
+	store := newStores(t).SavedProblems
+	if store == nil {
+		t.Skip("backend has no saved problem store")
+	}
+	return store
+}
+
+func attempts(t *testing.T, newStores Factory) api.AttemptStore {
+	t.Helper()
+	store := newStores(t).Attempts
+	if store == nil {
+		t.Skip("backend has no attempt store")
+	}
+	return store
+}
+
+func problems(t *testing.T, newStores Factory) api.ProblemRepository {
+	t.Helper()
+	store := newStores(t).Problems
+	if store == nil {
+		t.Skip("backend has no problem repository")
+	}
+	return store
+}
+
+func expectNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("%s: expected not found, got %v", what, err)
+	}
+}
+
+func runProfiles(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := profiles(t, newStores).GetProfile(ctx, "missing-user")
+		expectNotFound(t, "get profile", err)
+	})
+
+	t.Run("PartialUpdate", func(t *testing.T) {
+		store := profiles(t, newStores)
+
+		handle, name := " ada ", "Ada Lovelace"
+		created, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{
+			Handle:      &handle,
+			DisplayName: &name,
+			Preferences: map[string]string{"theme": "dark"},
+		}, baseTime)
+		if err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+		if created.UserID != "user-1" || created.Handle != "ada" || created.CreatedAt != baseTime.UnixMilli() {
+			t.Fatalf("unexpected created profile %+v", created)
+		}
+
+		bio := " analyst "
+		later := baseTime.Add(time.Minute)
+		if _, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{Bio: &bio}, later); err != nil {
+			t.Fatalf("update profile: %v", err)
+		}
+
+		got, err := store.GetProfile(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("get profile: %v", err)
+		}
+		want := domain.UserProfile{
+			UserID:      "user-1",
+			Handle:      "ada",
+			DisplayName: "Ada Lovelace",
+			Bio:         "analyst",
+			Preferences: map[string]string{"theme": "dark"},
+			CreatedAt:   baseTime.UnixMilli(),
+			UpdatedAt:   later.UnixMilli(),
+			Version:     2,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("profile mismatch\n got: %+v\nwant: %+v", got, want)
+		}
+	})
+
+	t.Run("VersionConflict", func(t *testing.T) {
+		store := profiles(t, newStores)
+
+		name := "Ada"
+		if _, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{DisplayName: &name, IfVersion: 1}, baseTime); !errors.Is(err, api.ErrConflict) {
+			t.Fatalf("expected conflict when the profile does not exist yet, got %v", err)
+		}
+		created, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{DisplayName: &name}, baseTime)
+		if err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+		if created.Version != 1 {
+			t.Fatalf("expected a new profile at version 1, got %d", created.Version)
+		}
+
+		first, second := "First tab", "Second tab"
+		updated, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{DisplayName: &first, IfVersion: created.Version}, baseTime)
+		if err != nil {
+			t.Fatalf("update at current version: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Fatalf("expected version 2 after update, got %d", updated.Version)
+		}
+		if _, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{DisplayName: &second, IfVersion: created.Version}, baseTime); !errors.Is(err, api.ErrConflict) {
+			t.Fatalf("expected conflict for a stale version, got %v", err)
+		}
+
+		got, err := store.GetProfile(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("get profile: %v", err)
+		}
+		if got.DisplayName != first || got.Version != 2 {
+			t.Fatalf("stale update must not be applied, got %+v", got)
+		}
+	})
+
+	t.Run("Handles", func(t *testing.T) {
+		store := profiles(t, newStores)
+		setHandle := func(userID, handle string) (domain.UserProfile, error) {
+			return store.UpsertProfile(ctx, userID, domain.UserProfileUpdate{Handle: &handle}, baseTime)
+		}
+
+		if _, err := setHandle("user-1", "no spaces"); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected bad request for an invalid handle, got %v", err)
+		}
+		if _, err := setHandle("user-1", "admin"); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected bad request for a reserved handle, got %v", err)
+		}
+
+		profile, err := setHandle("user-1", " Ada ")
+		if err != nil {
+			t.Fatalf("claim handle: %v", err)
+		}
+		if profile.Handle != "ada" {
+			t.Fatalf("expected normalized handle, got %q", profile.Handle)
+		}
+		if _, err := setHandle("user-1", "ada"); err != nil {
+			t.Fatalf("re-saving your own handle: %v", err)
+		}
+		if _, err := setHandle("user-2", "ADA"); !errors.Is(err, api.ErrConflict) {
+			t.Fatalf("expected conflict for a taken handle, got %v", err)
+		}
+		_, err = store.GetProfile(ctx, "user-2")
+		expectNotFound(t, "profile after a failed claim", err)
+
+		if _, err := setHandle("user-1", "lovelace"); err != nil {
+			t.Fatalf("change handle: %v", err)
+		}
+		if _, err := setHandle("user-2", "ada"); err != nil {
+			t.Fatalf("claim released handle: %v", err)
+		}
+		if _, err := setHandle("user-1", ""); err != nil {
+			t.Fatalf("clear handle: %v", err)
+		}
+		if _, err := setHandle("user-3", "lovelace"); err != nil {
+			t.Fatalf("claim cleared handle: %v", err)
+		}
+	})
+
+	t.Run("LookupByHandle", func(t *testing.T) {
+		store := profiles(t, newStores)
+
+		handle, name := "Ada", "Ada Lovelace"
+		if _, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{Handle: &handle, DisplayName: &name}, baseTime); err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+		got, err := store.GetProfileByHandle(ctx, "ada")
+		if err != nil {
+			t.Fatalf("get profile by handle: %v", err)
+		}
+		if got.UserID != "user-1" || got.DisplayName != name {
+			t.Fatalf("unexpected profile %+v", got)
+		}
+		_, err = store.GetProfileByHandle(ctx, "grace")
+		expectNotFound(t, "unknown handle", err)
+
+		renamed := "lovelace"
+		if _, err := store.UpsertProfile(ctx, "user-1", domain.UserProfileUpdate{Handle: &renamed}, baseTime); err != nil {
+			t.Fatalf("change handle: %v", err)
+		}
+		_, err = store.GetProfileByHandle(ctx, "ada")
+		expectNotFound(t, "released handle", err)
+		if got, err := store.GetProfileByHandle(ctx, renamed); err != nil || got.UserID != "user-1" {
+			t.Fatalf("expected new handle to resolve to user-1, got %+v, %v", got, err)
+		}
+	})
+}
+
+func runSavedProblems(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, store api.SavedProblemStore, userID string, input domain.SavedProblemCreateInput, at time.Time) domain.SavedProblemSummary {
+		t.Helper()
+		if input.Language == "" {
+			input.Language = "javascript"
+		}
+		summary, err := store.CreateSavedProblem(ctx, userID, input, at)
+		if err != nil {
+			t.Fatalf("create saved problem: %v", err)
+		}
+		return summary
+	}
+
+	t.Run("Validation", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		if _, err := store.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{Language: "go"}, baseTime); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected bad request without problem ID, got %v", err)
+		}
+		if _, err := store.CreateSavedProblem(ctx, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected bad request without language, got %v", err)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		saved := create(t, store, "user-1", domain.SavedProblemCreateInput{
+			ProblemID: " prob_1 ",
+			Title:     "  Two Sum ",
+			Tags:      []string{"graphs", " Arrays ", "GRAPHS", " "},
+		}, baseTime)
+
+		if saved.ID == "" || saved.ProblemID != "prob_1" || saved.Title != "Two Sum" {
+			t.Fatalf("unexpected summary %+v", saved)
+		}
+		if saved.Status != domain.SavedProblemStatusInProgress {
+			t.Fatalf("expected default status %q, got %q", domain.SavedProblemStatusInProgress, saved.Status)
+		}
+		if !reflect.DeepEqual(saved.Tags, []string{"Arrays", "graphs"}) {
+			t.Fatalf("expected trimmed, de-duplicated, sorted tags, got %v", saved.Tags)
+		}
+		if saved.CreatedAt != baseTime.UnixMilli() || saved.UpdatedAt != baseTime.UnixMilli() || saved.LastAttempt != nil {
+			t.Fatalf("unexpected timestamps or last attempt %+v", saved)
+		}
+	})
+
+	t.Run("PaginationAndOrdering", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		created := make(map[string]bool)
+		for i := 0; i < 5; i++ {
+			saved := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: fmt.Sprintf("prob_%d", i)}, baseTime.Add(time.Duration(i)*time.Second))
+			created[saved.ID] = true
+		}
+		create(t, store, "user-2", domain.SavedProblemCreateInput{ProblemID: "prob_other"}, baseTime)
+
+		seen := make(map[string]bool)
+		opts := domain.SavedProblemListOptions{Limit: 2}
+		for page := 0; ; page++ {
+			if page > len(created) {
+				t.Fatalf("pagination did not terminate")
+			}
+			result, err := store.ListSavedProblems(ctx, "user-1", opts)
+			if err != nil {
+				t.Fatalf("list page %d: %v", page, err)
+			}
+			if len(result.Items) > 2 {
+				t.Fatalf("page %d exceeds the limit: %d items", page, len(result.Items))
+			}
+			for i, item := range result.Items {
+				if !created[item.ID] || seen[item.ID] {
+					t.Fatalf("page %d: unexpected or repeated saved problem %s", page, item.ID)
+				}
+				if i > 0 && item.UpdatedAt > result.Items[i-1].UpdatedAt {
+					t.Fatalf("page %d: expected most recently updated first", page)
+				}
+				seen[item.ID] = true
+			}
+			if result.NextToken == "" {
+				break
+			}
+			opts.NextToken = result.NextToken
+		}
+		if len(seen) != len(created) {
+			t.Fatalf("expected %d saved problems across pages, saw %d", len(created), len(seen))
+		}
+
+		all, err := store.ListSavedProblems(ctx, "user-1", domain.SavedProblemListOptions{})
+		if err != nil {
+			t.Fatalf("list all: %v", err)
+		}
+		if len(all.Items) != len(created) || all.NextToken != "" {
+			t.Fatalf("expected a single default page of %d items, got %d (token %q)", len(created), len(all.Items), all.NextToken)
+		}
+		for i := 1; i < len(all.Items); i++ {
+			if all.Items[i].UpdatedAt > all.Items[i-1].UpdatedAt {
+				t.Fatalf("expected most recently updated first, got %+v", all.Items)
+			}
+		}
+	})
+
+	t.Run("StatusFilter", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		statuses := []domain.SavedProblemStatus{
+			domain.SavedProblemStatusInProgress,
+			domain.SavedProblemStatusCompleted,
+			domain.SavedProblemStatusCompleted,
+			domain.SavedProblemStatusArchived,
+		}
+		for i, status := range statuses {
+			create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: fmt.Sprintf("prob_%d", i), Status: status}, baseTime)
+		}
+
+		result, err := store.ListSavedProblems(ctx, "user-1", domain.SavedProblemListOptions{Status: domain.SavedProblemStatusCompleted})
+		if err != nil {
+			t.Fatalf("list completed: %v", err)
+		}
+		if len(result.Items) != 2 {
+			t.Fatalf("expected 2 completed saved problems, got %d", len(result.Items))
+		}
+		for _, item := range result.Items {
+			if item.Status != domain.SavedProblemStatusCompleted {
+				t.Fatalf("filter returned status %q", item.Status)
+			}
+		}
+
+		// The limit bounds the evaluated items before filtering, so paging a filtered
+		// list still visits every match exactly once.
+		matches := 0
+		opts := domain.SavedProblemListOptions{Status: domain.SavedProblemStatusCompleted, Limit: 1}
+		for page := 0; ; page++ {
+			if page > len(statuses) {
+				t.Fatalf("filtered pagination did not terminate")
+			}
+			result, err := store.ListSavedProblems(ctx, "user-1", opts)
+			if err != nil {
+				t.Fatalf("list filtered page: %v", err)
+			}
+			matches += len(result.Items)
+			if result.NextToken == "" {
+				break
+			}
+			opts.NextToken = result.NextToken
+		}
+		if matches != 2 {
+			t.Fatalf("expected 2 completed saved problems across pages, got %d", matches)
+		}
+	})
+
+	t.Run("PartialUpdate", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		saved := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1", Tags: []string{"dp"}, Notes: "first"}, baseTime)
+
+		later := baseTime.Add(time.Minute)
+		status := domain.SavedProblemStatusCompleted
+		updated, err := store.UpdateSavedProblem(ctx, "user-1", saved.ID, domain.SavedProblemUpdateInput{Status: &status}, later)
+		if err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+		if updated.Status != status || updated.Notes != "first" || !reflect.DeepEqual(updated.Tags, []string{"dp"}) || updated.UpdatedAt != later.UnixMilli() {
+			t.Fatalf("expected only status and updated_at to change, got %+v", updated)
+		}
+
+		notes, hint := " second ", true
+		if _, err := store.UpdateSavedProblem(ctx, "user-1", saved.ID, domain.SavedProblemUpdateInput{
+			Notes:        &notes,
+			HintUnlocked: &hint,
+			Tags:         []string{"Trees", "trees", "bfs"},
+		}, later); err != nil {
+			t.Fatalf("update notes and tags: %v", err)
+		}
+
+		detail, err := store.GetSavedProblem(ctx, "user-1", saved.ID)
+		if err != nil {
+			t.Fatalf("get saved problem: %v", err)
+		}
+		if detail.Status != status || detail.Notes != "second" || !detail.HintUnlocked || !reflect.DeepEqual(detail.Tags, []string{"Trees", "bfs"}) {
+			t.Fatalf("unexpected stored saved problem %+v", detail.SavedProblemSummary)
+		}
+		if detail.CreatedAt != baseTime.UnixMilli() {
+			t.Fatalf("update must not move created_at, got %d", detail.CreatedAt)
+		}
+	})
+
+	t.Run("VersionConflict", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		saved := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime)
+		if saved.Version != 1 {
+			t.Fatalf("expected a new saved problem at version 1, got %d", saved.Version)
+		}
+
+		if _, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{AttemptID: "attempt-1"}, baseTime); err != nil {
+			t.Fatalf("append attempt: %v", err)
+		}
+
+		first, second := "first tab", "second tab"
+		updated, err := store.UpdateSavedProblem(ctx, "user-1", saved.ID, domain.SavedProblemUpdateInput{Notes: &first, IfVersion: saved.Version}, baseTime)
+		if err != nil {
+			t.Fatalf("appending an attempt must not change the version: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Fatalf("expected version 2 after update, got %d", updated.Version)
+		}
+		if updated.LastAttempt == nil || updated.LastAttempt.AttemptID != "attempt-1" {
+			t.Fatalf("update must keep the last attempt, got %+v", updated.LastAttempt)
+		}
+		if _, err := store.UpdateSavedProblem(ctx, "user-1", saved.ID, domain.SavedProblemUpdateInput{Notes: &second, IfVersion: saved.Version}, baseTime); !errors.Is(err, api.ErrConflict) {
+			t.Fatalf("expected conflict for a stale version, got %v", err)
+		}
+
+		detail, err := store.GetSavedProblem(ctx, "user-1", saved.ID)
+		if err != nil {
+			t.Fatalf("get saved problem: %v", err)
+		}
+		if detail.Notes != first || detail.Version != 2 {
+			t.Fatalf("stale update must not be applied, got %+v", detail.SavedProblemSummary)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		foreign := create(t, store, "user-2", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime)
+
+		for _, id := range []string{"missing", foreign.ID} {
+			_, err := store.GetSavedProblem(ctx, "user-1", id)
+			expectNotFound(t, "get "+id, err)
+			_, err = store.UpdateSavedProblem(ctx, "user-1", id, domain.SavedProblemUpdateInput{}, baseTime)
+			expectNotFound(t, "update "+id, err)
+			_, err = store.AppendAttempt(ctx, "user-1", id, domain.SavedProblemAttemptInput{}, baseTime)
+			expectNotFound(t, "append attempt to "+id, err)
+			_, err = store.ListAttempts(ctx, "user-1", id)
+			expectNotFound(t, "list attempts of "+id, err)
+			expectNotFound(t, "delete "+id, store.DeleteSavedProblem(ctx, "user-1", id))
+		}
+
+		if _, err := store.GetSavedProblem(ctx, "user-2", foreign.ID); err != nil {
+			t.Fatalf("owner lost access after foreign calls: %v", err)
+		}
+	})
+
+	t.Run("Attempts", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		saved := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime)
+
+		submittedAt := baseTime.Add(time.Hour).UnixMilli()
+		for i := 1; i <= 3; i++ {
+			input := domain.SavedProblemAttemptInput{
+				AttemptID: fmt.Sprintf("attempt-%d", i),
+				Code:      fmt.Sprintf("return %d", i),
+				PassCount: i,
+				FailCount: 3 - i,
+			}
+			if i == 3 {
+				input.Status = domain.SavedAttemptStatusPassed
+				input.SubmittedAt = &submittedAt
+			}
+			if _, err := store.AppendAttempt(ctx, "user-1", saved.ID, input, baseTime.Add(time.Duration(i)*time.Minute)); err != nil {
+				t.Fatalf("append attempt %d: %v", i, err)
+			}
+		}
+
+		list, err := store.ListAttempts(ctx, "user-1", saved.ID)
+		if err != nil {
+			t.Fatalf("list attempts: %v", err)
+		}
+		if len(list) != 3 {
+			t.Fatalf("expected 3 attempts, got %d", len(list))
+		}
+		for i, want := range []string{"attempt-3", "attempt-2", "attempt-1"} {
+			if list[i].AttemptID != want {
+				t.Fatalf("expected newest attempt first, got order %s,%s,%s", list[0].AttemptID, list[1].AttemptID, list[2].AttemptID)
+			}
+		}
+		if list[1].Status != domain.SavedAttemptStatusSubmitted {
+			t.Fatalf("expected default attempt status %q, got %q", domain.SavedAttemptStatusSubmitted, list[1].Status)
+		}
+
+		detail, err := store.GetSavedProblem(ctx, "user-1", saved.ID)
+		if err != nil {
+			t.Fatalf("get saved problem: %v", err)
+		}
+		if !reflect.DeepEqual(detail.Attempts, list) {
+			t.Fatalf("detail attempts differ from ListAttempts")
+		}
+		last := detail.LastAttempt
+		if last == nil || last.AttemptID != "attempt-3" || last.Status != domain.SavedAttemptStatusPassed || last.PassCount != 3 || last.Code != "return 3" {
+			t.Fatalf("unexpected last attempt %+v", last)
+		}
+		if last.SubmittedAt == nil || *last.SubmittedAt != submittedAt {
+			t.Fatalf("expected submitted_at on last attempt, got %v", last.SubmittedAt)
+		}
+		if detail.UpdatedAt != baseTime.Add(3*time.Minute).UnixMilli() {
+			t.Fatalf("expected append to bump updated_at, got %d", detail.UpdatedAt)
+		}
+	})
+
+	t.Run("CascadingDelete", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		doomed := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime)
+		kept := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_2"}, baseTime)
+		for _, saved := range []domain.SavedProblemSummary{doomed, kept} {
+			for i := 0; i < 3; i++ {
+				if _, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{}, baseTime.Add(time.Duration(i)*time.Second)); err != nil {
+					t.Fatalf("append attempt: %v", err)
+				}
+			}
+		}
+
+		if err := store.DeleteSavedProblem(ctx, "user-1", doomed.ID); err != nil {
+			t.Fatalf("delete saved problem: %v", err)
+		}
+		_, err := store.GetSavedProblem(ctx, "user-1", doomed.ID)
+		expectNotFound(t, "get deleted saved problem", err)
+		_, err = store.ListAttempts(ctx, "user-1", doomed.ID)
+		expectNotFound(t, "list attempts of deleted saved problem", err)
+
+		list, err := store.ListSavedProblems(ctx, "user-1", domain.SavedProblemListOptions{})
+		if err != nil {
+			t.Fatalf("list saved problems: %v", err)
+		}
+		if len(list.Items) != 1 || list.Items[0].ID != kept.ID {
+			t.Fatalf("expected only the kept saved problem, got %+v", list.Items)
+		}
+		remaining, err := store.ListAttempts(ctx, "user-1", kept.ID)
+		if err != nil {
+			t.Fatalf("list kept attempts: %v", err)
+		}
+		if len(remaining) != 3 {
+			t.Fatalf("delete must not touch other saved problems' attempts, got %d", len(remaining))
+		}
+	})
+
+	t.Run("StaleAppendIsRetryable", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		saved := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime)
+
+		if _, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{AttemptID: "newer"}, baseTime.Add(2*time.Second)); err != nil {
+			t.Fatalf("append newer attempt: %v", err)
+		}
+		_, err := store.AppendAttempt(ctx, "user-1", saved.ID, domain.SavedProblemAttemptInput{AttemptID: "older"}, baseTime.Add(time.Second))
+		if !errors.Is(err, api.ErrRetryable) {
+			t.Fatalf("expected retryable error for an append older than the last attempt, got %v", err)
+		}
+
+		detail, err := store.GetSavedProblem(ctx, "user-1", saved.ID)
+		if err != nil {
+			t.Fatalf("get saved problem: %v", err)
+		}
+		if detail.LastAttempt == nil || detail.LastAttempt.AttemptID != "newer" {
+			t.Fatalf("stale append rolled back the last attempt: %+v", detail.LastAttempt)
+		}
+		if len(detail.Attempts) != 1 {
+			t.Fatalf("stale append must not store its attempt, got %d attempts", len(detail.Attempts))
+		}
+	})
+
+	t.Run("ConcurrentAppends", func(t *testing.T) {
+		store := savedProblems(t, newStores)
+		saved := create(t, store, "user-1", domain.SavedProblemCreateInput{ProblemID: "prob_1"}, baseTime)
+
+		// Each try takes a fresh timestamp, as a retried request would.
+		var clock atomic.Int64
+		clock.Store(baseTime.UnixMilli())
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWriters; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				input := domain.SavedProblemAttemptInput{AttemptID: fmt.Sprintf("attempt-%d", i)}
+				for {
+					_, err := store.AppendAttempt(ctx, "user-1", saved.ID, input, time.UnixMilli(clock.Add(1)))
+					if errors.Is(err, api.ErrRetryable) {
+						continue
+					}
+					if err != nil {
+						t.Errorf("append attempt %d: %v", i, err)
+					}
+					return
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		detail, err := store.GetSavedProblem(ctx, "user-1", saved.ID)
+		if err != nil {
+			t.Fatalf("get saved problem: %v", err)
+		}
+		if len(detail.Attempts) != concurrentWriters {
+			t.Fatalf("expected %d attempts, got %d", concurrentWriters, len(detail.Attempts))
+		}
+		newest := detail.Attempts[0]
+		if detail.LastAttempt == nil || detail.LastAttempt.AttemptID != newest.AttemptID || detail.LastAttempt.UpdatedAt != newest.UpdatedAt {
+			t.Fatalf("expected last attempt to be the newest %s, got %+v", newest.AttemptID, detail.LastAttempt)
+		}
+	})
+}
+
+func runAttempts(t *testing.T, newStores Factory) {
+	ctx := api.WithIdentity(context.Background(), auth.Identity{Subject: "user-1"})
+
+	start := func(t *testing.T, store api.AttemptStore) domain.Attempt {
+		t.Helper()
+		attempt, err := store.Create(ctx, api.CreateAttemptRequest{ProblemID: "prob_1", Language: "javascript"})
+		if err != nil {
+			t.Fatalf("create attempt: %v", err)
+		}
+		return attempt
+	}
+
+	run := func(pass, fail int) domain.AttemptRun {
+		run := domain.AttemptRun{Which: "public", CodeHash: domain.CodeHash("code")}
+		for i := 0; i < pass; i++ {
+			run.Results = append(run.Results, domain.RunResult{TestID: fmt.Sprintf("public_%d", i+1), Status: "pass"})
+		}
+		for i := 0; i < fail; i++ {
+			run.Results = append(run.Results, domain.RunResult{TestID: fmt.Sprintf("hidden_%d", i+1), Status: "fail"})
+		}
+		return run
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		store := attempts(t, newStores)
+		if _, err := store.Create(ctx, api.CreateAttemptRequest{}); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected bad request without problem ID, got %v", err)
+		}
+
+		attempt := start(t, store)
+		if attempt.ID == "" || attempt.UserID != "user-1" || attempt.ProblemID != "prob_1" || attempt.StartedAt == 0 {
+			t.Fatalf("unexpected attempt %+v", attempt)
+		}
+		got, err := store.Get(ctx, attempt.ID)
+		if err != nil {
+			t.Fatalf("get attempt: %v", err)
+		}
+		if got.ID != attempt.ID || got.UserID != "user-1" || got.Language != "javascript" || got.EndedAt != 0 {
+			t.Fatalf("unexpected stored attempt %+v", got)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		store := attempts(t, newStores)
+		_, err := store.Get(ctx, "missing")
+		expectNotFound(t, "get", err)
+		_, err = store.RecordRun(ctx, "missing", run(1, 0))
+		expectNotFound(t, "record run", err)
+		_, err = store.ListRuns(ctx, "missing", domain.RunListOptions{})
+		expectNotFound(t, "list runs", err)
+		expectNotFound(t, "complete", store.Complete(ctx, "missing", run(1, 0), domain.SubmissionSummary{}))
+		_, err = store.RecordUnlock(ctx, "missing", domain.UnlockHint)
+		expectNotFound(t, "unlock", err)
+	})
+
+	t.Run("RunResultValues", func(t *testing.T) {
+		store := attempts(t, newStores)
+		attempt := start(t, store)
+
+		results := []domain.RunResult{
+			{
+				TestID:   "public_1",
+				Status:   "fail",
+				Expected: json.RawMessage(`{"ids":[1,2]}`),
+				Actual:   json.RawMessage(`{"ids":[1]}`),
+				Diff:     []jsonfmt.Difference{{Path: "$.ids[1]", Kind: jsonfmt.DiffMissing, Expected: float64(2)}},
+				DiffText: "$.ids[1]: missing 2",
+			},
+			{TestID: "hidden_1", Status: "fail", Actual: json.RawMessage(`null`)},
+			{TestID: "hidden_2", Status: "error", Stderr: "boom"},
+		}
+		if _, err := store.RecordRun(ctx, attempt.ID, domain.AttemptRun{Which: "all", Results: results}); err != nil {
+			t.Fatalf("record run: %v", err)
+		}
+		listed, err := store.ListRuns(ctx, attempt.ID, domain.RunListOptions{})
+		if err != nil {
+			t.Fatalf("list runs: %v", err)
+		}
+		if len(listed.Runs) != 1 || !reflect.DeepEqual(listed.Runs[0].Results, results) {
+			t.Fatalf("run results did not round-trip: %+v", listed.Runs)
+		}
+	})
+
+	t.Run("RunsAndTotals", func(t *testing.T) {
+		store := attempts(t, newStores)
+		attempt := start(t, store)
+
+		counts := [][2]int{{1, 2}, {2, 1}, {3, 0}, {0, 3}, {2, 1}}
+		recorded := make(map[string]bool)
+		for _, c := range counts {
+			stored, err := store.RecordRun(ctx, attempt.ID, run(c[0], c[1]))
+			if err != nil {
+				t.Fatalf("record run: %v", err)
+			}
+			if stored.ID == "" || stored.CreatedAt == 0 || stored.PassCount != c[0] || stored.FailCount != c[1] {
+				t.Fatalf("unexpected stored run %+v", stored)
+			}
+			recorded[stored.ID] = true
+		}
+
+		got, err := store.Get(ctx, attempt.ID)
+		if err != nil {
+			t.Fatalf("get attempt: %v", err)
+		}
+		if got.PassCount != 2 || got.FailCount != 1 {
+			t.Fatalf("expected latest run counts 2/1, got %d/%d", got.PassCount, got.FailCount)
+		}
+		if want := (domain.RunTotals{Runs: 5, PassCount: 8, FailCount: 7}); got.Lifetime != want {
+			t.Fatalf("expected lifetime %+v, got %+v", want, got.Lifetime)
+		}
+		if !recorded[got.LastRunID] {
+			t.Fatalf("last run ID %q is not a recorded run", got.LastRunID)
+		}
+
+		seen := make(map[string]bool)
+		var lastCreated int64
+		opts := domain.RunListOptions{Limit: 2}
+		for page := 0; ; page++ {
+			if page > len(counts) {
+				t.Fatalf("run pagination did not terminate")
+			}
+			result, err := store.ListRuns(ctx, attempt.ID, opts)
+			if err != nil {
+				t.Fatalf("list runs: %v", err)
+			}
+			if len(result.Runs) > 2 {
+				t.Fatalf("page %d exceeds the limit: %d runs", page, len(result.Runs))
+			}
+			for _, r := range result.Runs {
+				if !recorded[r.ID] || seen[r.ID] {
+					t.Fatalf("unexpected or repeated run %s", r.ID)
+				}
+				if r.CreatedAt < lastCreated {
+					t.Fatalf("expected oldest run first")
+				}
+				if len(r.Results) != r.PassCount+r.FailCount || r.Which != "public" || r.CodeHash != domain.CodeHash("code") {
+					t.Fatalf("run lost its details: %+v", r)
+				}
+				lastCreated = r.CreatedAt
+				seen[r.ID] = true
+			}
+			if result.NextToken == "" {
+				break
+			}
+			opts.NextToken = result.NextToken
+		}
+		if len(seen) != len(counts) {
+			t.Fatalf("expected %d runs across pages, saw %d", len(counts), len(seen))
+		}
+	})
+
+	t.Run("CompleteOnce", func(t *testing.T) {
+		store := attempts(t, newStores)
+		attempt := start(t, store)
+
+		if err := store.Complete(ctx, attempt.ID, run(2, 1), domain.SubmissionSummary{AttemptID: attempt.ID, Passed: true}); err != nil {
+			t.Fatalf("complete: %v", err)
+		}
+		got, err := store.Get(ctx, attempt.ID)
+		if err != nil {
+			t.Fatalf("get attempt: %v", err)
+		}
+		if got.EndedAt == 0 || got.DurationMS != got.EndedAt-got.StartedAt {
+			t.Fatalf("expected ended_at and duration, got %+v", got)
+		}
+		if want := (domain.RunTotals{Runs: 1, PassCount: 2, FailCount: 1}); got.Lifetime != want || got.PassCount != 2 || got.LastRunID == "" {
+			t.Fatalf("expected the submission's run to be recorded, got %+v", got)
+		}
+
+		if err := store.Complete(ctx, attempt.ID, run(3, 0), domain.SubmissionSummary{AttemptID: attempt.ID}); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected second complete to be rejected, got %v", err)
+		}
+		if _, err := store.RecordRun(ctx, attempt.ID, run(3, 0)); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected a run after completion to be rejected, got %v", err)
+		}
+		again, err := store.Get(ctx, attempt.ID)
+		if err != nil {
+			t.Fatalf("get attempt: %v", err)
+		}
+		if again != got {
+			t.Fatalf("expected rejected writes to change nothing, got %+v after %+v", again, got)
+		}
+		listed, err := store.ListRuns(ctx, attempt.ID, domain.RunListOptions{})
+		if err != nil {
+			t.Fatalf("list runs: %v", err)
+		}
+		if len(listed.Runs) != 1 || listed.Runs[0].ID != got.LastRunID {
+			t.Fatalf("expected only the accepted submission's run, got %+v", listed.Runs)
+		}
+	})
+
+	t.Run("Unlocks", func(t *testing.T) {
+		store := attempts(t, newStores)
+		attempt := start(t, store)
+
+		if _, err := store.RecordUnlock(ctx, attempt.ID, domain.Unlock("answers")); !errors.Is(err, api.ErrBadRequest) {
+			t.Fatalf("expected unknown unlock to be rejected, got %v", err)
+		}
+		updated, err := store.RecordUnlock(ctx, attempt.ID, domain.UnlockHint)
+		if err != nil {
+			t.Fatalf("unlock hint: %v", err)
+		}
+		if !updated.HintUsed || updated.SolutionsUnlocked {
+			t.Fatalf("expected only the hint to be unlocked, got %+v", updated)
+		}
+		got, err := store.Get(ctx, attempt.ID)
+		if err != nil {
+			t.Fatalf("get attempt: %v", err)
+		}
+		if !got.HintUsed || got.SolutionsUnlocked {
+			t.Fatalf("unlock was not stored: %+v", got)
+		}
+	})
+
+	t.Run("ConcurrentRuns", func(t *testing.T) {
+		store := attempts(t, newStores)
+		attempt := start(t, store)
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWriters; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := store.RecordRun(ctx, attempt.ID, run(1, 1)); err != nil {
+					t.Errorf("record run: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		got, err := store.Get(ctx, attempt.ID)
+		if err != nil {
+			t.Fatalf("get attempt: %v", err)
+		}
+		want := domain.RunTotals{Runs: concurrentWriters, PassCount: concurrentWriters, FailCount: concurrentWriters}
+		if got.Lifetime != want {
+			t.Fatalf("expected lifetime %+v after concurrent runs, got %+v", want, got.Lifetime)
+		}
+		result, err := store.ListRuns(ctx, attempt.ID, domain.RunListOptions{Limit: concurrentWriters + 1})
+		if err != nil {
+			t.Fatalf("list runs: %v", err)
+		}
+		if len(result.Runs) != concurrentWriters {
+			t.Fatalf("expected %d runs, got %d", concurrentWriters, len(result.Runs))
+		}
+	})
+}
+
+func runProblems(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		store := problems(t, newStores)
+		record := domain.ProblemRecord{
+			ID:         "ignored",
+			Category:   "graphs",
+			Difficulty: "easy",
+			PromptHash: domain.PromptHash("graphs", "easy", ""),
+			CreatedBy:  "user-1",
+			CreatedAt:  baseTime.UnixMilli(),
+			Pack: domain.ProblemPack{
+				Problem: domain.ProblemMetadata{Title: "Shortest Path", Statement: "Find it."},
+				Hint:    "Use BFS.",
+				Tests:   domain.TestSuite{Public: []domain.Example{{Input: []any{"a"}, Output: "b"}}},
+			},
+			Generation: domain.GenerationMetadata{Generator: "static", Attempts: 1},
+		}
+
+		first, err := store.Save(ctx, record)
+		if err != nil {
+			t.Fatalf("save problem: %v", err)
+		}
+		second, err := store.Save(ctx, record)
+		if err != nil {
+			t.Fatalf("save problem again: %v", err)
+		}
+		if first == "" || first == "ignored" || first == second {
+			t.Fatalf("expected fresh distinct IDs, got %q and %q", first, second)
+		}
+
+		got, err := store.GetRecord(ctx, first)
+		if err != nil {
+			t.Fatalf("get record: %v", err)
+		}
+		if got.ID != first || got.Category != "graphs" || got.CreatedBy != "user-1" || got.CreatedAt != record.CreatedAt || got.Generation.Generator != "static" {
+			t.Fatalf("unexpected record %+v", got)
+		}
+		pack, err := store.Get(ctx, first)
+		if err != nil {
+			t.Fatalf("get pack: %v", err)
+		}
+		if pack.Problem.Title != "Shortest Path" || pack.Hint != "Use BFS." || len(pack.Tests.Public) != 1 {
+			t.Fatalf("unexpected pack %+v", pack)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		store := problems(t, newStores)
+		_, err := store.Get(ctx, "missing")
+		expectNotFound(t, "get", err)
+		_, err = store.GetRecord(ctx, "missing")
+		expectNotFound(t, "get record", err)
+	})
+}
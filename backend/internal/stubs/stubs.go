@@ -11,15 +11,15 @@ import (
 type ProblemGenerator struct{}
 
 // Generate currently returns ErrNotImplemented.
-func (ProblemGenerator) Generate(context.Context, api.GenerateRequest) (domain.ProblemPack, error) {
-	return domain.ProblemPack{}, api.ErrNotImplemented
+func (ProblemGenerator) Generate(context.Context, api.GenerateRequest) (domain.GeneratedProblem, error) {
+	return domain.GeneratedProblem{}, api.ErrNotImplemented
 }
 
 // ProblemRepository is a placeholder persistence layer.
 type ProblemRepository struct{}
 
 // Save currently returns ErrNotImplemented.
-func (ProblemRepository) Save(context.Context, domain.ProblemPack) (string, error) {
+func (ProblemRepository) Save(context.Context, domain.ProblemRecord) (string, error) {
 	return "", api.ErrNotImplemented
 }
 
@@ -28,6 +28,11 @@ func (ProblemRepository) Get(context.Context, string) (domain.ProblemPack, error
 	return domain.ProblemPack{}, api.ErrNotImplemented
 }
 
+// GetRecord currently returns ErrNotImplemented.
+func (ProblemRepository) GetRecord(context.Context, string) (domain.ProblemRecord, error) {
+	return domain.ProblemRecord{}, api.ErrNotImplemented
+}
+
 // AttemptStore is a placeholder store implementation.
 type AttemptStore struct{}
 
@@ -37,20 +42,30 @@ func (AttemptStore) Create(context.Context, api.CreateAttemptRequest) (domain.At
 }
 
 // RecordRun currently returns ErrNotImplemented.
-func (AttemptStore) RecordRun(context.Context, string, domain.RunSummary) error {
-	return api.ErrNotImplemented
+func (AttemptStore) RecordRun(context.Context, string, domain.AttemptRun) (domain.AttemptRun, error) {
+	return domain.AttemptRun{}, api.ErrNotImplemented
 }
 
 // Get currently returns ErrNotImplemented.
-func (AttemptStore) Get(context.Context, string) (domain.Attempt, []domain.RunResult, error) {
-	return domain.Attempt{}, nil, api.ErrNotImplemented
+func (AttemptStore) Get(context.Context, string) (domain.Attempt, error) {
+	return domain.Attempt{}, api.ErrNotImplemented
+}
+
+// ListRuns currently returns ErrNotImplemented.
+func (AttemptStore) ListRuns(context.Context, string, domain.RunListOptions) (domain.RunListResult, error) {
+	return domain.RunListResult{}, api.ErrNotImplemented
 }
 
 // Complete currently returns ErrNotImplemented.
-func (AttemptStore) Complete(context.Context, string, domain.SubmissionSummary) error {
+func (AttemptStore) Complete(context.Context, string, domain.AttemptRun, domain.SubmissionSummary) error {
 	return api.ErrNotImplemented
 }
 
+// RecordUnlock currently returns ErrNotImplemented.
+func (AttemptStore) RecordUnlock(context.Context, string, domain.Unlock) (domain.Attempt, error) {
+	return domain.Attempt{}, api.ErrNotImplemented
+}
+
 // TestRunner is a placeholder runner implementation.
 type TestRunner struct{}
 
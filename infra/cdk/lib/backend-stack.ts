@@ -119,7 +119,8 @@ export class BackendStack extends Stack {
     const httpApi = new HttpApi(this, 'HttpApi', {
       apiName: `improview-${envName}-api`,
       corsPreflight: {
-        allowHeaders: ['content-type', 'authorization'],
+        allowHeaders: ['content-type', 'authorization', 'if-match'],
+        exposeHeaders: ['etag', 'retry-after'],
         allowMethods: [
           CorsHttpMethod.GET,
           CorsHttpMethod.POST,